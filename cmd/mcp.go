@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/opencode-ai/opencode/internal/app"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/db"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/mcpserver"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve the built-in tools over the MCP stdio protocol",
+	Long: `Serve the built-in tools of OpenCode (view, edit, patch, grep, glob and the LSP
+tools) to other agents and editors over the MCP stdio protocol. The MCP client
+asks its user before calling a tool, so the calls are not confirmed again.
+
+With --agent, a coder_agent tool runs the coder agent on a task. Approving a
+call approves the whole task, so the agent may only read and change files:
+its shell commands, fetches, web searches and calls of MCP tools are denied.
+--agent-full-access allows them too, without confirmation. Enable the bash
+sandbox in the configuration to confine the commands to the working
+directory.`,
+	Example: `
+  # Serve the tools of the current directory
+  opencode mcp
+
+  # Serve only the tools that don't change files
+  opencode mcp --read-only
+
+  # Also offer a tool running the coder agent on a task
+  opencode mcp --agent -c /path/to/project
+
+  # Also let the agent run commands and reach the network
+  opencode mcp --agent --agent-full-access
+  `,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		debug, _ := cmd.Flags().GetBool("debug")
+		cwd, _ := cmd.Flags().GetString("cwd")
+		readOnly, _ := cmd.Flags().GetBool("read-only")
+		agent, _ := cmd.Flags().GetBool("agent")
+		agentFullAccess, _ := cmd.Flags().GetBool("agent-full-access")
+
+		if cwd != "" {
+			err := os.Chdir(cwd)
+			if err != nil {
+				return fmt.Errorf("failed to change directory: %v", err)
+			}
+		}
+		if cwd == "" {
+			c, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current working directory: %v", err)
+			}
+			cwd = c
+		}
+		_, err := config.Load(cwd, debug)
+		if err != nil {
+			return err
+		}
+
+		conn, err := db.Connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		app, err := app.NewToolServer(ctx, conn, agent)
+		if err != nil {
+			logging.Error("Failed to create app: %v", err)
+			return err
+		}
+		defer app.Shutdown()
+
+		return mcpserver.Serve(ctx, app, mcpserver.Options{
+			ReadOnly:        readOnly,
+			Agent:           agent,
+			AgentFullAccess: agentFullAccess,
+		})
+	},
+}
+
+func init() {
+	mcpCmd.Flags().BoolP("debug", "d", false, "Debug")
+	mcpCmd.Flags().StringP("cwd", "c", "", "Current working directory")
+	mcpCmd.Flags().Bool("read-only", false, "Leave out the tools that change files")
+	mcpCmd.Flags().Bool("agent", false, "Offer a tool running the coder agent on a task")
+	mcpCmd.Flags().Bool("agent-full-access", false, "Let the coder agent run shell commands, reach the network and use MCP tools")
+	rootCmd.AddCommand(mcpCmd)
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/opencode-ai/opencode/internal/llm/tools/shell"
+	"github.com/spf13/cobra"
+)
+
+// sandboxExecCmd is used by the landlock sandbox backend to confine the shell
+// of the bash tool. It is not meant to be run by users.
+var sandboxExecCmd = &cobra.Command{
+	Use:                shell.SandboxExecCommand + " [--write path]... [--allow-network] -- command [args...]",
+	Short:              "Run a command inside the bash tool sandbox",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	SilenceUsage:       true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return shell.ExecSandboxed(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sandboxExecCmd)
+}
@@ -77,6 +77,12 @@ func generateSchema() map[string]any {
 		"default":     false,
 	}
 
+	schema["properties"].(map[string]any)["autoLSP"] = map[string]any{
+		"type":        "boolean",
+		"description": "Start the language servers of the languages detected in the project",
+		"default":     true,
+	}
+
 	schema["properties"].(map[string]any)["contextPaths"] = map[string]any{
 		"type":        "array",
 		"description": "Context paths for the application",
@@ -135,7 +141,7 @@ func generateSchema() map[string]any {
 				},
 				"env": map[string]any{
 					"type":        "array",
-					"description": "Environment variables for the MCP server",
+					"description": "Environment variables for the MCP server, which can reference $VAR or ${VAR}",
 					"items": map[string]any{
 						"type": "string",
 					},
@@ -150,20 +156,46 @@ func generateSchema() map[string]any {
 				"type": map[string]any{
 					"type":        "string",
 					"description": "Type of MCP server",
-					"enum":        []string{"stdio", "sse"},
+					"enum":        []string{"stdio", "sse", "http"},
 					"default":     "stdio",
 				},
 				"url": map[string]any{
 					"type":        "string",
-					"description": "URL for SSE type MCP servers",
+					"description": "URL for SSE and HTTP type MCP servers",
 				},
 				"headers": map[string]any{
 					"type":        "object",
-					"description": "HTTP headers for SSE type MCP servers",
+					"description": "HTTP headers for SSE and HTTP type MCP servers, which can reference $VAR or ${VAR}",
 					"additionalProperties": map[string]any{
 						"type": "string",
 					},
 				},
+				"enabledTools": map[string]any{
+					"type":        "array",
+					"description": "Names or glob patterns of the only tools offered to the AI assistant",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"disabledTools": map[string]any{
+					"type":        "array",
+					"description": "Names or glob patterns of the tools not offered to the AI assistant",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"trustedTools": map[string]any{
+					"type":        "array",
+					"description": "Names or glob patterns of the tools called without asking for permission",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"timeout": map[string]any{
+					"type":        "integer",
+					"description": "Number of seconds a tool call may take, unlimited when 0",
+					"minimum":     0,
+				},
 			},
 			"required": []string{"command"},
 		},
@@ -286,7 +318,7 @@ func generateSchema() map[string]any {
 				},
 				"command": map[string]any{
 					"type":        "string",
-					"description": "Command to execute for the LSP server",
+					"description": "Command to execute for the LSP server, defaults to the preset command for go, typescript, python, rust and c",
 				},
 				"args": map[string]any{
 					"type":        "array",
@@ -299,8 +331,21 @@ func generateSchema() map[string]any {
 					"type":        "object",
 					"description": "Additional options for the LSP server",
 				},
+				"rootMarkers": map[string]any{
+					"type":        "array",
+					"description": "Files marking the project root the LSP server is started in",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+				"extensions": map[string]any{
+					"type":        "array",
+					"description": "File extensions handled by the LSP server, all files when empty",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
 			},
-			"required": []string{"command"},
 		},
 	}
 
@@ -9,13 +9,17 @@ import (
 	"sync"
 	"time"
 
+	"github.com/opencode-ai/opencode/internal/checkpoint"
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/db"
 	"github.com/opencode-ai/opencode/internal/format"
 	"github.com/opencode-ai/opencode/internal/history"
 	"github.com/opencode-ai/opencode/internal/llm/agent"
+	"github.com/opencode-ai/opencode/internal/llm/tools/shell"
 	"github.com/opencode-ai/opencode/internal/logging"
 	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/lsp/watcher"
+	"github.com/opencode-ai/opencode/internal/mcpclient"
 	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/permission"
 	"github.com/opencode-ai/opencode/internal/session"
@@ -27,19 +31,54 @@ type App struct {
 	Messages    message.Service
 	History     history.Service
 	Permissions permission.Service
+	Checkpoints checkpoint.Service
+
+	BackgroundJobs shell.BackgroundService
 
 	CoderAgent agent.Service
 
 	LSPClients map[string]*lsp.Client
 
+	MCPClients mcpclient.Service
+
 	clientsMutex sync.RWMutex
 
+	// fileWatcher watches the workspace for all the LSP clients
+	fileWatcher *watcher.FileWatcher
+
 	watcherCancelFuncs []context.CancelFunc
 	cancelFuncsMutex   sync.Mutex
 	watcherWG          sync.WaitGroup
 }
 
 func New(ctx context.Context, conn *sql.DB) (*App, error) {
+	app := newApp(ctx, conn)
+
+	// Initialize theme based on configuration
+	app.initTheme()
+
+	if err := app.startCoderAgent(ctx); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// NewToolServer creates the app of the MCP server. Serving the built-in tools
+// only needs the services and the LSP clients, the coder agent and the
+// connections to the MCP servers are only made when withAgent is set.
+func NewToolServer(ctx context.Context, conn *sql.DB, withAgent bool) (*App, error) {
+	app := newApp(ctx, conn)
+	if !withAgent {
+		return app, nil
+	}
+	if err := app.startCoderAgent(ctx); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// newApp creates the services and starts the LSP clients
+func newApp(ctx context.Context, conn *sql.DB) *App {
 	q := db.New(conn)
 	sessions := session.NewService(q)
 	messages := message.NewService(q)
@@ -50,15 +89,25 @@ func New(ctx context.Context, conn *sql.DB) (*App, error) {
 		Messages:    messages,
 		History:     files,
 		Permissions: permission.NewPermissionService(),
+		Checkpoints: checkpoint.NewService(config.WorkingDirectory(), config.Get().Data.Directory),
 		LSPClients:  make(map[string]*lsp.Client),
-	}
 
-	// Initialize theme based on configuration
-	app.initTheme()
+		BackgroundJobs: shell.NewBackgroundService(),
+	}
 
 	// Initialize LSP clients in the background
 	go app.initLSPClients(ctx)
 
+	return app
+}
+
+// startCoderAgent connects to the MCP servers and creates the coder agent
+func (app *App) startCoderAgent(ctx context.Context) error {
+	// Connect to the MCP servers before the first request, which offers their
+	// tools
+	app.MCPClients = mcpclient.NewService(config.Get().MCPServers)
+	app.MCPClients.Start(ctx)
+
 	var err error
 	app.CoderAgent, err = agent.NewAgent(
 		config.AgentCoder,
@@ -70,14 +119,18 @@ func New(ctx context.Context, conn *sql.DB) (*App, error) {
 			app.Messages,
 			app.History,
 			app.LSPClients,
+			app.BackgroundJobs,
 		),
+		agent.NewMcpTools(app.Permissions, app.MCPClients),
+		app.Checkpoints,
+		app.LSPClients,
 	)
 	if err != nil {
 		logging.Error("Failed to create coder agent", err)
-		return nil, err
+		app.MCPClients.Shutdown()
+		return err
 	}
-
-	return app, nil
+	return nil
 }
 
 // initTheme sets the application theme based on the configuration
@@ -162,6 +215,14 @@ func (a *App) RunNonInteractive(ctx context.Context, prompt string, outputFormat
 
 // Shutdown performs a clean shutdown of the application
 func (app *App) Shutdown() {
+	// Stop background jobs started by the bash tool
+	app.BackgroundJobs.Shutdown()
+
+	// Close the connections to the MCP servers
+	if app.MCPClients != nil {
+		app.MCPClients.Shutdown()
+	}
+
 	// Cancel all watcher goroutines
 	app.cancelFuncsMutex.Lock()
 	for _, cancel := range app.watcherCancelFuncs {
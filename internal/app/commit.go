@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/diff"
+	"github.com/opencode-ai/opencode/internal/git"
+	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/opencode-ai/opencode/internal/llm/agent"
+)
+
+const recentCommitsForDraft = 10
+
+// CommitDraft is a commit message drafted for a set of changed files.
+type CommitDraft struct {
+	Message string
+	// Files are relative to the working directory
+	Files []string
+}
+
+// DraftCommit drafts a commit message for the files changed in the session.
+// When the session did not change any file that git sees as modified, all
+// changes in the working tree are used instead.
+func (app *App) DraftCommit(ctx context.Context, sessionID string) (CommitDraft, error) {
+	wd := config.WorkingDirectory()
+	changed, err := git.ChangedFiles(ctx, wd)
+	if err != nil {
+		return CommitDraft{}, err
+	}
+	if len(changed) == 0 {
+		return CommitDraft{}, errors.New("there are no changes to commit")
+	}
+
+	files, sessionDiff, err := app.sessionChanges(ctx, wd, sessionID, changed)
+	if err != nil {
+		return CommitDraft{}, err
+	}
+	if len(files) == 0 {
+		files = changed
+		sessionDiff, err = workingTreeDiff(ctx, wd, changed)
+		if err != nil {
+			return CommitDraft{}, err
+		}
+	}
+
+	// Recent subjects help the model follow the repository's conventions,
+	// a repository without commits simply has none.
+	recent, _ := git.Run(ctx, wd, "log", "--format=%s", fmt.Sprintf("-n%d", recentCommitsForDraft))
+
+	message, err := agent.GenerateCommitMessage(ctx, sessionDiff, recent)
+	if err != nil {
+		return CommitDraft{}, err
+	}
+	return CommitDraft{Message: message, Files: files}, nil
+}
+
+// Commit commits files with message and returns the short hash of the commit.
+func (app *App) Commit(ctx context.Context, message string, files []string) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("commit message is empty")
+	}
+	return git.Commit(ctx, config.WorkingDirectory(), message, files)
+}
+
+// sessionChanges returns the session's files that git reports as changed in
+// wd, together with the diff of everything the session did to them.
+func (app *App) sessionChanges(ctx context.Context, wd, sessionID string, changed []string) ([]string, string, error) {
+	if sessionID == "" {
+		return nil, "", nil
+	}
+	latestFiles, err := app.History.ListLatestSessionFiles(ctx, sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	allFiles, err := app.History.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var files []string
+	var diffs strings.Builder
+	for _, file := range latestFiles {
+		rel, err := filepath.Rel(wd, file.Path)
+		if err != nil || !slices.Contains(changed, rel) {
+			continue
+		}
+		files = append(files, rel)
+
+		var initialContent string
+		for _, v := range allFiles {
+			if v.Path == file.Path && v.Version == history.InitialVersion {
+				initialContent = v.Content
+				break
+			}
+		}
+		if initialContent == file.Content {
+			continue
+		}
+		fileDiff, _, _ := diff.GenerateDiff(initialContent, file.Content, file.Path)
+		diffs.WriteString(fileDiff)
+		diffs.WriteString("\n")
+	}
+	return files, diffs.String(), nil
+}
+
+// workingTreeDiff returns the diff of files against HEAD. Untracked files
+// are not part of git's diff, so they are listed by name.
+func workingTreeDiff(ctx context.Context, dir string, files []string) (string, error) {
+	args := append([]string{"diff", "HEAD", "--no-color", "--no-ext-diff", "--"}, files...)
+	out, err := git.Run(ctx, dir, args...)
+	if err != nil {
+		// Without a HEAD commit everything is new.
+		out = ""
+	}
+	untracked, err := git.Run(ctx, dir, append([]string{"ls-files", "--others", "--exclude-standard", "--"}, files...)...)
+	if err != nil {
+		return "", err
+	}
+	if untracked != "" {
+		out += "\nNew files:\n" + untracked
+	}
+	return out, nil
+}
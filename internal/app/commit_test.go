@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/git"
+	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// Diffs are generated relative to the working directory of the config
+	if _, err := config.Load(os.TempDir(), false); err != nil {
+		os.Exit(2)
+	}
+	os.Exit(m.Run())
+}
+
+// sessionHistory is the file history of a single session
+type sessionHistory struct {
+	history.Service
+	files []history.File
+}
+
+func (h *sessionHistory) ListBySession(ctx context.Context, sessionID string) ([]history.File, error) {
+	return h.files, nil
+}
+
+func (h *sessionHistory) ListLatestSessionFiles(ctx context.Context, sessionID string) ([]history.File, error) {
+	latest := map[string]history.File{}
+	var paths []string
+	for _, file := range h.files {
+		if _, ok := latest[file.Path]; !ok {
+			paths = append(paths, file.Path)
+		}
+		latest[file.Path] = file
+	}
+	var files []history.File
+	for _, path := range paths {
+		files = append(files, latest[path])
+	}
+	return files, nil
+}
+
+func TestSessionChanges(t *testing.T) {
+	ctx := context.Background()
+	if _, err := git.Run(ctx, t.TempDir(), "--version"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		_, err := git.Run(ctx, dir, args...)
+		require.NoError(t, err)
+	}
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("session.go", "package main\n")
+	write("user.go", "package main\n")
+	write("reverted.go", "package main\n")
+	_, err := git.Run(ctx, dir, "add", "--all")
+	require.NoError(t, err)
+	_, err = git.Run(ctx, dir, "commit", "--quiet", "-m", "initial")
+	require.NoError(t, err)
+
+	// The session edited session.go and created new.go, the user edited
+	// user.go, and the session's change to reverted.go was undone
+	write("session.go", "package main\n\nfunc main() {}\n")
+	write("new.go", "package main\n")
+	write("user.go", "package main\n\n// edited by the user\n")
+	app := &App{History: &sessionHistory{files: []history.File{
+		{Path: filepath.Join(dir, "session.go"), Version: history.InitialVersion, Content: "package main\n"},
+		{Path: filepath.Join(dir, "session.go"), Version: "v1", Content: "package main\n\nfunc main() {}\n"},
+		{Path: filepath.Join(dir, "new.go"), Version: history.InitialVersion, Content: ""},
+		{Path: filepath.Join(dir, "new.go"), Version: "v1", Content: "package main\n"},
+		{Path: filepath.Join(dir, "reverted.go"), Version: history.InitialVersion, Content: "package main\n"},
+	}}}
+
+	changed, err := git.ChangedFiles(ctx, dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"session.go", "new.go", "user.go"}, changed)
+
+	files, sessionDiff, err := app.sessionChanges(ctx, dir, "session", changed)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"session.go", "new.go"}, files)
+	assert.Contains(t, sessionDiff, "+func main() {}")
+	assert.NotContains(t, sessionDiff, "edited by the user")
+
+	files, _, err = app.sessionChanges(ctx, dir, "", changed)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
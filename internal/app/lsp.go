@@ -13,32 +13,65 @@ import (
 func (app *App) initLSPClients(ctx context.Context) {
 	cfg := config.Get()
 
+	enabled := 0
+	for _, clientConfig := range cfg.LSP {
+		if !clientConfig.Disabled {
+			enabled++
+		}
+	}
+	if enabled == 0 {
+		return
+	}
+
+	// A single watcher forwards the changes of the workspace to all clients
+	app.fileWatcher = watcher.NewFileWatcher(config.WorkingDirectory())
+	watchCtx, cancel := context.WithCancel(ctx)
+	app.cancelFuncsMutex.Lock()
+	app.watcherCancelFuncs = append(app.watcherCancelFuncs, cancel)
+	app.cancelFuncsMutex.Unlock()
+	app.watcherWG.Add(1)
+	go func() {
+		defer app.watcherWG.Done()
+		defer logging.RecoverPanic("file-watcher", nil)
+		app.fileWatcher.Run(watchCtx)
+	}()
+
 	// Initialize LSP clients
 	for name, clientConfig := range cfg.LSP {
+		if clientConfig.Disabled {
+			continue
+		}
 		// Start each client initialization in its own goroutine
-		go app.createAndStartLSPClient(ctx, name, clientConfig.Command, clientConfig.Args...)
+		go app.createAndStartLSPClient(ctx, name, clientConfig)
 	}
 	logging.Info("LSP clients initialization started in background")
 }
 
 // createAndStartLSPClient creates a new LSP client, initializes it, and starts its workspace watcher
-func (app *App) createAndStartLSPClient(ctx context.Context, name string, command string, args ...string) {
+func (app *App) createAndStartLSPClient(ctx context.Context, name string, clientConfig config.LSPConfig) {
+	// Start the server in the project root, which may be a parent of the working directory
+	rootDir := config.FindProjectRoot(config.WorkingDirectory(), clientConfig.RootMarkers)
+	if rootDir == "" {
+		rootDir = config.WorkingDirectory()
+	}
+
 	// Create a specific context for initialization with a timeout
-	logging.Info("Creating LSP client", "name", name, "command", command, "args", args)
+	logging.Info("Creating LSP client", "name", name, "command", clientConfig.Command, "args", clientConfig.Args, "root", rootDir)
 	
 	// Create the LSP client
-	lspClient, err := lsp.NewClient(ctx, command, args...)
+	lspClient, err := lsp.NewClient(ctx, clientConfig.Command, clientConfig.Args...)
 	if err != nil {
 		logging.Error("Failed to create LSP client for", name, err)
 		return
 	}
+	lspClient.ConfigureWorkspace(clientConfig.Extensions, clientConfig.RootMarkers)
 
 	// Create a longer timeout for initialization (some servers take time to start)
 	initCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 	
 	// Initialize with the initialization context
-	_, err = lspClient.InitializeLSPClient(initCtx, config.WorkingDirectory())
+	_, err = lspClient.InitializeLSPClient(initCtx, rootDir)
 	if err != nil {
 		logging.Error("Initialize failed", "name", name, "error", err)
 		// Clean up the client to prevent resource leaks
@@ -91,7 +124,7 @@ func (app *App) runWorkspaceWatcher(ctx context.Context, name string, workspaceW
 		app.restartLSPClient(ctx, name)
 	})
 
-	workspaceWatcher.WatchWorkspace(ctx, config.WorkingDirectory())
+	workspaceWatcher.WatchWorkspace(ctx, app.fileWatcher)
 	logging.Info("Workspace watcher stopped", "client", name)
 }
 
@@ -121,6 +154,6 @@ func (app *App) restartLSPClient(ctx context.Context, name string) {
 	}
 
 	// Create a new client using the shared function
-	app.createAndStartLSPClient(ctx, name, clientConfig.Command, clientConfig.Args...)
+	app.createAndStartLSPClient(ctx, name, clientConfig)
 	logging.Info("Successfully restarted LSP client", "client", name)
 }
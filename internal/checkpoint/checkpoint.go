@@ -0,0 +1,234 @@
+// Package checkpoint records snapshots of the working tree in a shadow git
+// repository, so that changes made during a session can be diffed and
+// reverted, including those made by shell commands.
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sessionRefPrefix = "refs/sessions/"
+	maxPromptLength  = 100
+)
+
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Checkpoint is a snapshot of the working tree taken before an agent turn.
+type Checkpoint struct {
+	ID        string
+	SessionID string
+	Prompt    string
+	CreatedAt int64
+}
+
+type Service interface {
+	// Create records the current state of the working tree for the session.
+	Create(ctx context.Context, sessionID, prompt string) (Checkpoint, error)
+	// List returns the checkpoints of the session, newest first.
+	List(ctx context.Context, sessionID string) ([]Checkpoint, error)
+	// Diff returns a unified diff of the changes made to the working tree
+	// since the checkpoint was taken.
+	Diff(ctx context.Context, id string) (string, error)
+	// Restore resets the working tree to the checkpoint. The current state is
+	// recorded first, and that checkpoint is returned so the restore can be
+	// undone.
+	Restore(ctx context.Context, sessionID, id string) (Checkpoint, error)
+}
+
+type service struct {
+	workingDir string
+	gitDir     string
+	excludes   []string
+	mu         sync.Mutex
+}
+
+// NewService creates a checkpoint service for workingDir that stores its
+// snapshots in a git repository below dataDir. The user's own repository,
+// refs and index are never touched.
+func NewService(workingDir, dataDir string) Service {
+	if !filepath.IsAbs(dataDir) {
+		dataDir = filepath.Join(workingDir, dataDir)
+	}
+	hash := sha256.Sum256([]byte(workingDir))
+	s := &service{
+		workingDir: workingDir,
+		gitDir:     filepath.Join(dataDir, "checkpoints", hex.EncodeToString(hash[:])[:16]),
+	}
+	// Don't snapshot opencode's own data, it changes on every turn.
+	if rel, err := filepath.Rel(workingDir, dataDir); err == nil && !strings.HasPrefix(rel, "..") {
+		s.excludes = append(s.excludes, "/"+filepath.ToSlash(rel)+"/")
+	}
+	return s
+}
+
+func (s *service) Create(ctx context.Context, sessionID, prompt string) (Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.create(ctx, sessionID, prompt)
+}
+
+func (s *service) create(ctx context.Context, sessionID, prompt string) (Checkpoint, error) {
+	if err := s.init(ctx); err != nil {
+		return Checkpoint{}, err
+	}
+	tree, err := s.snapshot(ctx)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	prompt = strings.Join(strings.Fields(prompt), " ")
+	if runes := []rune(prompt); len(runes) > maxPromptLength {
+		prompt = string(runes[:maxPromptLength]) + "..."
+	}
+	args := []string{"commit-tree", tree, "-m", prompt}
+	ref := sessionRefPrefix + sessionID
+	if parent, err := s.git(ctx, "rev-parse", "--verify", "--quiet", ref); err == nil {
+		args = append(args, "-p", parent)
+	}
+	id, err := s.git(ctx, args...)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if _, err := s.git(ctx, "update-ref", ref, id); err != nil {
+		return Checkpoint{}, err
+	}
+
+	return Checkpoint{
+		ID:        id,
+		SessionID: sessionID,
+		Prompt:    prompt,
+		CreatedAt: time.Now().Unix(),
+	}, nil
+}
+
+func (s *service) List(ctx context.Context, sessionID string) ([]Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.gitDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	ref := sessionRefPrefix + sessionID
+	if _, err := s.git(ctx, "rev-parse", "--verify", "--quiet", ref); err != nil {
+		return nil, nil
+	}
+
+	out, err := s.git(ctx, "log", "--format=%H%x1f%ct%x1f%s", ref)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints []Checkpoint
+	for line := range strings.SplitSeq(out, "\n") {
+		fields := strings.SplitN(line, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		createdAt, _ := strconv.ParseInt(fields[1], 10, 64)
+		checkpoints = append(checkpoints, Checkpoint{
+			ID:        fields[0],
+			SessionID: sessionID,
+			Prompt:    fields[2],
+			CreatedAt: createdAt,
+		})
+	}
+	return checkpoints, nil
+}
+
+func (s *service) Diff(ctx context.Context, id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.verify(ctx, id); err != nil {
+		return "", err
+	}
+	if _, err := s.snapshot(ctx); err != nil {
+		return "", err
+	}
+	return s.git(ctx, "diff", "--cached", "--no-color", "--no-ext-diff", id)
+}
+
+func (s *service) Restore(ctx context.Context, sessionID, id string) (Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.verify(ctx, id); err != nil {
+		return Checkpoint{}, err
+	}
+	// The snapshot also leaves the index matching the working tree, so
+	// read-tree removes the files that didn't exist in the checkpoint.
+	current, err := s.create(ctx, sessionID, fmt.Sprintf("Before restoring checkpoint %s", id[:min(len(id), 8)]))
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if _, err := s.git(ctx, "read-tree", "-u", "--reset", id); err != nil {
+		return Checkpoint{}, err
+	}
+	return current, nil
+}
+
+// init creates the shadow repository on first use.
+func (s *service) init(ctx context.Context) error {
+	if _, err := os.Stat(s.gitDir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.gitDir), 0o755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, "git", "init", "--quiet", "--bare", s.gitDir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create checkpoint repository: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	exclude := filepath.Join(s.gitDir, "info", "exclude")
+	if err := os.MkdirAll(filepath.Dir(exclude), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(exclude, []byte(strings.Join(s.excludes, "\n")+"\n"), 0o644)
+}
+
+// snapshot stages the whole working tree in the shadow index, honoring the
+// project's .gitignore files, and returns the resulting tree.
+func (s *service) snapshot(ctx context.Context) (string, error) {
+	if _, err := s.git(ctx, "add", "--all", "."); err != nil {
+		return "", err
+	}
+	return s.git(ctx, "write-tree")
+}
+
+func (s *service) verify(ctx context.Context, id string) error {
+	if _, err := s.git(ctx, "cat-file", "-e", id+"^{commit}"); err != nil {
+		return fmt.Errorf("%w: %s", ErrCheckpointNotFound, id)
+	}
+	return nil
+}
+
+func (s *service) git(ctx context.Context, args ...string) (string, error) {
+	gitArgs := append([]string{"--git-dir", s.gitDir, "--work-tree", s.workingDir}, args...)
+	cmd := exec.CommandContext(ctx, "git", gitArgs...)
+	cmd.Dir = s.workingDir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=opencode",
+		"GIT_AUTHOR_EMAIL=opencode@localhost",
+		"GIT_COMMITTER_NAME=opencode",
+		"GIT_COMMITTER_EMAIL=opencode@localhost",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
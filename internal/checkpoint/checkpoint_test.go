@@ -0,0 +1,91 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	ctx := context.Background()
+	workingDir := t.TempDir()
+	dataDir := filepath.Join(workingDir, ".opencode")
+	s := NewService(workingDir, dataDir)
+
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(workingDir, name), []byte(content), 0o644))
+	}
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(workingDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	write(".gitignore", "build/\n")
+	write("main.go", "package main\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(workingDir, "build"), 0o755))
+	write("build/out", "binary")
+
+	first, err := s.Create(ctx, "session", "first prompt")
+	require.NoError(t, err)
+	assert.DirExists(t, dataDir)
+
+	// Changes made after the checkpoint, as a shell command would.
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("generated.go", "package main\n")
+	write("build/out", "changed binary")
+
+	diff, err := s.Diff(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+func main() {}")
+	assert.Contains(t, diff, "generated.go")
+	assert.NotContains(t, diff, "build/out")
+	assert.NotContains(t, diff, ".opencode")
+
+	second, err := s.Create(ctx, "session", "second prompt")
+	require.NoError(t, err)
+
+	undo, err := s.Restore(ctx, "session", first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", read("main.go"))
+	assert.NoFileExists(t, filepath.Join(workingDir, "generated.go"))
+	assert.Equal(t, "changed binary", read("build/out"), "ignored files are left alone")
+
+	checkpoints, err := s.List(ctx, "session")
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 3)
+	assert.Equal(t, undo.ID, checkpoints[0].ID)
+	assert.Equal(t, second.ID, checkpoints[1].ID)
+	assert.Equal(t, "first prompt", checkpoints[2].Prompt)
+
+	// Restoring the checkpoint taken before the restore undoes it.
+	_, err = s.Restore(ctx, "session", undo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", read("main.go"))
+	assert.FileExists(t, filepath.Join(workingDir, "generated.go"))
+
+	checkpoints, err = s.List(ctx, "other")
+	require.NoError(t, err)
+	assert.Empty(t, checkpoints)
+
+	// Long prompts are cut between characters
+	_, err = s.Create(ctx, "long", strings.Repeat("é", 150))
+	require.NoError(t, err)
+	checkpoints, err = s.List(ctx, "long")
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 1)
+	assert.Equal(t, strings.Repeat("é", 100)+"...", checkpoints[0].Prompt)
+
+	_, err = s.Diff(ctx, "0000000000000000000000000000000000000000")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+}
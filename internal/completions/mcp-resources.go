@@ -0,0 +1,87 @@
+package completions
+
+import (
+	"fmt"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/opencode-ai/opencode/internal/mcpclient"
+	"github.com/opencode-ai/opencode/internal/tui/components/dialog"
+	"github.com/opencode-ai/opencode/internal/tui/styles"
+	"github.com/opencode-ai/opencode/internal/tui/theme"
+)
+
+// MCPResourcesProviderID is the id of the provider of the MCP resources, the
+// value of its completions is the MCPResourceValue of the resource
+const MCPResourcesProviderID = "mcp-resource"
+
+type mcpResourcesContextGroup struct {
+	mcpClients mcpclient.Service
+}
+
+type mcpResourceItem struct {
+	resource mcpclient.Resource
+}
+
+func (i *mcpResourceItem) Render(selected bool, width int) string {
+	t := theme.CurrentTheme()
+	itemStyle := styles.BaseStyle().
+		Width(width).
+		Padding(0, 1)
+
+	if selected {
+		itemStyle = itemStyle.
+			Background(t.Background()).
+			Foreground(t.Primary()).
+			Bold(true)
+	}
+
+	return itemStyle.Render(i.DisplayValue())
+}
+
+func (i *mcpResourceItem) DisplayValue() string {
+	name := i.resource.Name
+	if name == "" {
+		name = i.resource.URI
+	}
+	return fmt.Sprintf("%s %s: %s", styles.DocumentIcon, i.resource.Server, name)
+}
+
+func (i *mcpResourceItem) GetValue() string {
+	return MCPResourceValue(i.resource)
+}
+
+// MCPResourceValue identifies a resource in the completions
+func MCPResourceValue(resource mcpclient.Resource) string {
+	return resource.Server + ":" + resource.URI
+}
+
+func (cg *mcpResourcesContextGroup) GetId() string {
+	return MCPResourcesProviderID
+}
+
+func (cg *mcpResourcesContextGroup) GetEntry() dialog.CompletionItemI {
+	return dialog.NewCompletionItem(dialog.CompletionItem{
+		Title: "MCP Resources",
+		Value: "mcp-resources",
+	})
+}
+
+func (cg *mcpResourcesContextGroup) GetChildEntries(query string) ([]dialog.CompletionItemI, error) {
+	var items []dialog.CompletionItemI
+	for _, resource := range cg.mcpClients.Resources() {
+		target := resource.Server + " " + resource.Name + " " + resource.URI
+		if query != "" && !fuzzy.MatchFold(query, target) {
+			continue
+		}
+		items = append(items, &mcpResourceItem{resource: resource})
+	}
+	return items, nil
+}
+
+// NewMCPResourcesContextGroup lists the resources of the connected MCP
+// servers, to attach them to a message
+func NewMCPResourcesContextGroup(mcpClients mcpclient.Service) dialog.CompletionProvider {
+	return &mcpResourcesContextGroup{
+		mcpClients: mcpClients,
+	}
+}
@@ -22,16 +22,28 @@ type MCPType string
 const (
 	MCPStdio MCPType = "stdio"
 	MCPSse   MCPType = "sse"
+	// MCPHttp is the streamable HTTP transport
+	MCPHttp MCPType = "http"
 )
 
 // MCPServer defines the configuration for a Model Control Protocol server.
 type MCPServer struct {
-	Command string            `json:"command"`
+	Command string `json:"command"`
+	// Env and Headers can reference environment variables as $VAR or ${VAR}
 	Env     []string          `json:"env"`
 	Args    []string          `json:"args"`
 	Type    MCPType           `json:"type"`
 	URL     string            `json:"url"`
 	Headers map[string]string `json:"headers"`
+	// EnabledTools lists the only tools offered to the AI assistant, and
+	// DisabledTools the tools that are not. Both take tool names or glob
+	// patterns such as "browser_*".
+	EnabledTools  []string `json:"enabledTools,omitempty"`
+	DisabledTools []string `json:"disabledTools,omitempty"`
+	// TrustedTools are called without asking for permission
+	TrustedTools []string `json:"trustedTools,omitempty"`
+	// Timeout is the number of seconds a tool call may take, unlimited when 0
+	Timeout int `json:"timeout,omitempty"`
 }
 
 type AgentName string
@@ -67,6 +79,10 @@ type LSPConfig struct {
 	Command  string   `json:"command"`
 	Args     []string `json:"args"`
 	Options  any      `json:"options"`
+	// RootMarkers are the files marking the directory the server is started in.
+	RootMarkers []string `json:"rootMarkers,omitempty"`
+	// Extensions are the file extensions handled by the server, all files when empty.
+	Extensions []string `json:"extensions,omitempty"`
 }
 
 // TUIConfig defines the configuration for the Terminal User Interface.
@@ -80,6 +96,107 @@ type ShellConfig struct {
 	Args []string `json:"args,omitempty"`
 }
 
+// SandboxConfig defines the sandbox applied to commands run by the bash tool.
+type SandboxConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Backend is one of "auto", "bwrap" or "landlock". Only available on Linux.
+	Backend string `json:"backend,omitempty"`
+	// WritablePaths lists extra paths, besides the working directory and the
+	// temp directory, that sandboxed commands may write to.
+	WritablePaths []string `json:"writablePaths,omitempty"`
+	AllowNetwork  bool     `json:"allowNetwork,omitempty"`
+	// AutoApprove skips the permission prompt for sandboxed bash commands.
+	AutoApprove bool `json:"autoApprove,omitempty"`
+}
+
+// WatcherConfig defines how the workspace is watched for changes made outside
+// of OpenCode, which are forwarded to the LSP servers.
+type WatcherConfig struct {
+	// Backend is one of "auto", "fsnotify" or "polling". With "auto", the
+	// workspace is polled when it has more directories than MaxWatches.
+	Backend string `json:"backend,omitempty"`
+	// Exclude lists gitignore style patterns of paths not to watch, on top of
+	// the ones ignored by .gitignore and .ignore files.
+	Exclude    []string `json:"exclude,omitempty"`
+	MaxWatches int      `json:"maxWatches,omitempty"`
+	// PollInterval is the number of seconds between two scans of the
+	// workspace when it is polled.
+	PollInterval int `json:"pollInterval,omitempty"`
+}
+
+// CheckpointsConfig defines the working tree checkpoints taken before each agent turn.
+type CheckpointsConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// FormatterConfig defines an external formatter. The formatter reads the file
+// content on stdin and writes the formatted content to stdout. "$FILE" in Args
+// is replaced with the path of the file being formatted.
+type FormatterConfig struct {
+	Command    string   `json:"command"`
+	Args       []string `json:"args,omitempty"`
+	Extensions []string `json:"extensions"`
+	Disabled   bool     `json:"disabled,omitempty"`
+}
+
+// FormatConfig defines the formatting applied to files written by the agent.
+type FormatConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// LSP formats files without a configured formatter with their language server.
+	LSP        bool                       `json:"lsp,omitempty"`
+	Formatters map[string]FormatterConfig `json:"formatters,omitempty"`
+}
+
+// VerifyConfig defines the verification of the files modified by the agent.
+// When the agent finishes a turn, the errors it introduced in the files it
+// modified are sent back to it, up to MaxAttempts times.
+type VerifyConfig struct {
+	Enabled     bool `json:"enabled,omitempty"`
+	MaxAttempts int  `json:"maxAttempts,omitempty"`
+}
+
+// RepoMapConfig defines the map of the repository added to the context of the
+// agents: the top-level symbols of its source files, the most referenced
+// first, within MaxTokens.
+type RepoMapConfig struct {
+	Enabled   bool `json:"enabled,omitempty"`
+	MaxTokens int  `json:"maxTokens,omitempty"`
+}
+
+// WebSearchConfig defines the search engine queried by the websearch tool,
+// which is only available when a backend is set.
+type WebSearchConfig struct {
+	// Backend is one of "searxng", "brave" or "tavily".
+	Backend string `json:"backend,omitempty"`
+	// URL is the address of the SearXNG instance. For the other backends, it
+	// replaces the address of their API.
+	URL        string `json:"url,omitempty"`
+	APIKey     string `json:"apiKey,omitempty"`
+	MaxResults int    `json:"maxResults,omitempty"`
+}
+
+// FetchConfig defines the domains the fetch tool may access, the headers it
+// sends and its cache. A domain also matches its subdomains.
+type FetchConfig struct {
+	// AllowedDomains restricts the fetched URLs to these domains, when set.
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+	// DeniedDomains are never fetched, even when they are allowed.
+	DeniedDomains []string `json:"deniedDomains,omitempty"`
+	// Headers are sent to their domain, such as the credentials of internal
+	// documentation sites.
+	Headers []FetchHeaders `json:"headers,omitempty"`
+	// Cache keeps the pages with an ETag or a Last-Modified header in the data
+	// directory, and revalidates them when they are fetched again.
+	Cache bool `json:"cache,omitempty"`
+}
+
+// FetchHeaders are the headers the fetch tool sends to a domain. The domain
+// is a value rather than a key, as viper splits keys on dots.
+type FetchHeaders struct {
+	Domain  string            `json:"domain"`
+	Headers map[string]string `json:"headers"`
+}
+
 // Config is the main configuration structure for the application.
 type Config struct {
 	Data         Data                              `json:"data"`
@@ -87,12 +204,21 @@ type Config struct {
 	MCPServers   map[string]MCPServer              `json:"mcpServers,omitempty"`
 	Providers    map[models.ModelProvider]Provider `json:"providers,omitempty"`
 	LSP          map[string]LSPConfig              `json:"lsp,omitempty"`
+	AutoLSP      bool                              `json:"autoLSP,omitempty"`
 	Agents       map[AgentName]Agent               `json:"agents,omitempty"`
 	Debug        bool                              `json:"debug,omitempty"`
 	DebugLSP     bool                              `json:"debugLSP,omitempty"`
 	ContextPaths []string                          `json:"contextPaths,omitempty"`
 	TUI          TUIConfig                         `json:"tui"`
 	Shell        ShellConfig                       `json:"shell,omitempty"`
+	Sandbox      SandboxConfig                     `json:"sandbox,omitempty"`
+	Watcher      WatcherConfig                     `json:"watcher,omitempty"`
+	Checkpoints  CheckpointsConfig                 `json:"checkpoints,omitempty"`
+	Format       FormatConfig                      `json:"format,omitempty"`
+	Verify       VerifyConfig                      `json:"verify,omitempty"`
+	RepoMap      RepoMapConfig                     `json:"repoMap,omitempty"`
+	WebSearch    WebSearchConfig                   `json:"webSearch,omitempty"`
+	Fetch        FetchConfig                       `json:"fetch,omitempty"`
 	AutoCompact  bool                              `json:"autoCompact,omitempty"`
 }
 
@@ -240,6 +366,16 @@ func setDefaults(debug bool) {
 	}
 	viper.SetDefault("shell.path", shellPath)
 	viper.SetDefault("shell.args", []string{"-l"})
+	viper.SetDefault("sandbox.backend", "auto")
+	viper.SetDefault("watcher.backend", "auto")
+	viper.SetDefault("watcher.maxWatches", 8192)
+	viper.SetDefault("watcher.pollInterval", 2)
+	viper.SetDefault("format.lsp", true)
+	viper.SetDefault("verify.maxAttempts", 3)
+	viper.SetDefault("repoMap.maxTokens", 1024)
+	viper.SetDefault("webSearch.maxResults", 5)
+	viper.SetDefault("fetch.cache", true)
+	viper.SetDefault("autoLSP", true)
 
 	if debug {
 		viper.SetDefault("debug", true)
@@ -462,13 +598,28 @@ func mergeLocalConfig(workingDir string) {
 
 // applyDefaultValues sets default values for configuration fields that need processing.
 func applyDefaultValues() {
-	// Set default MCP type if not specified
+	// Set default MCP type if not specified, and expand the environment
+	// variables referenced by the env and headers, which often hold secrets
 	for k, v := range cfg.MCPServers {
 		if v.Type == "" {
 			v.Type = MCPStdio
-			cfg.MCPServers[k] = v
+		}
+		for i, env := range v.Env {
+			v.Env[i] = os.ExpandEnv(env)
+		}
+		for name, value := range v.Headers {
+			v.Headers[name] = os.ExpandEnv(value)
+		}
+		cfg.MCPServers[k] = v
+	}
+	cfg.WebSearch.APIKey = os.ExpandEnv(cfg.WebSearch.APIKey)
+	for _, headers := range cfg.Fetch.Headers {
+		for name, value := range headers.Headers {
+			headers.Headers[name] = os.ExpandEnv(value)
 		}
 	}
+
+	applyLSPPresets()
 }
 
 // It validates model IDs and providers, ensuring they are supported.
@@ -621,7 +772,6 @@ func Validate() error {
 	// Validate providers
 	for provider, providerCfg := range cfg.Providers {
 		if providerCfg.APIKey == "" && !providerCfg.Disabled {
-			fmt.Printf("provider has no API key, marking as disabled %s", provider)
 			logging.Warn("provider has no API key, marking as disabled", "provider", provider)
 			providerCfg.Disabled = true
 			cfg.Providers[provider] = providerCfg
@@ -637,6 +787,19 @@ func Validate() error {
 		}
 	}
 
+	// Validate the web search backend
+	switch cfg.WebSearch.Backend {
+	case "", "brave", "tavily":
+	case "searxng":
+		if cfg.WebSearch.URL == "" {
+			logging.Warn("SearXNG web search has no URL, disabling web search")
+			cfg.WebSearch.Backend = ""
+		}
+	default:
+		logging.Warn("Unknown web search backend, disabling web search", "backend", cfg.WebSearch.Backend)
+		cfg.WebSearch.Backend = ""
+	}
+
 	return nil
 }
 
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestConfig loads a project configuration, without the user's
+// configuration, and restores the global configuration afterwards.
+func loadTestConfig(t *testing.T, content string) *Config {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	oldCfg := cfg
+	cfg = nil
+	viper.Reset()
+	t.Cleanup(func() {
+		cfg = oldCfg
+		viper.Reset()
+	})
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".opencode.json"), []byte(content), 0o644))
+	loaded, err := Load(dir, false)
+	require.NoError(t, err)
+	return loaded
+}
+
+func TestLoadFetchConfig(t *testing.T) {
+	t.Setenv("DOCS_TOKEN", "secret")
+
+	// The example of the README
+	loaded := loadTestConfig(t, `{
+  "fetch": {
+    "allowedDomains": ["go.dev", "docs.example.internal"],
+    "deniedDomains": ["play.go.dev"],
+    "headers": [
+      {
+        "domain": "docs.example.internal",
+        "headers": {
+          "Authorization": "Bearer $DOCS_TOKEN"
+        }
+      }
+    ]
+  }
+}`)
+
+	assert.Equal(t, []string{"go.dev", "docs.example.internal"}, loaded.Fetch.AllowedDomains)
+	assert.Equal(t, []string{"play.go.dev"}, loaded.Fetch.DeniedDomains)
+	require.Len(t, loaded.Fetch.Headers, 1)
+	assert.Equal(t, "docs.example.internal", loaded.Fetch.Headers[0].Domain)
+	require.Len(t, loaded.Fetch.Headers[0].Headers, 1)
+	for name, value := range loaded.Fetch.Headers[0].Headers {
+		assert.Equal(t, "authorization", strings.ToLower(name))
+		assert.Equal(t, "Bearer secret", value)
+	}
+	assert.True(t, loaded.Fetch.Cache)
+}
+
+func TestLoadMCPServersExpandEnv(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_secret")
+	t.Setenv("API_KEY", "key")
+
+	loaded := loadTestConfig(t, `{
+  "mcpServers": {
+    "github": {
+      "command": "github-mcp-server",
+      "env": ["GITHUB_TOKEN=$GITHUB_TOKEN", "HOST=${API_KEY}.example.com", "UNSET=$OPENCODE_TEST_UNSET"]
+    },
+    "remote": {
+      "type": "sse",
+      "url": "https://mcp.example.com/sse",
+      "headers": {
+        "Authorization": "Bearer ${API_KEY}",
+        "X-Token": "$GITHUB_TOKEN"
+      }
+    }
+  }
+}`)
+
+	github := loaded.MCPServers["github"]
+	assert.Equal(t, MCPStdio, github.Type)
+	assert.Equal(t, []string{"GITHUB_TOKEN=ghp_secret", "HOST=key.example.com", "UNSET="}, github.Env)
+
+	remote := loaded.MCPServers["remote"]
+	assert.Equal(t, MCPSse, remote.Type)
+	headers := map[string]string{}
+	for name, value := range remote.Headers {
+		headers[strings.ToLower(name)] = value
+	}
+	assert.Equal(t, map[string]string{"authorization": "Bearer key", "x-token": "ghp_secret"}, headers)
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/logging"
+)
+
+// lspPreset describes a language server that is started without configuration
+// when a project of its language is detected.
+type lspPreset struct {
+	Command string
+	Args    []string
+	// RootMarkers are the files that mark the root of a project of the language.
+	RootMarkers []string
+	Extensions  []string
+}
+
+var lspPresets = map[string]lspPreset{
+	"go": {
+		Command:     "gopls",
+		RootMarkers: []string{"go.work", "go.mod"},
+		Extensions:  []string{".go", ".mod", ".work"},
+	},
+	"typescript": {
+		Command:     "typescript-language-server",
+		Args:        []string{"--stdio"},
+		RootMarkers: []string{"tsconfig.json", "jsconfig.json", "package.json"},
+		Extensions:  []string{".ts", ".tsx", ".js", ".jsx", ".mts", ".cts", ".mjs", ".cjs"},
+	},
+	"python": {
+		Command:     "pyright-langserver",
+		Args:        []string{"--stdio"},
+		RootMarkers: []string{"pyproject.toml", "pyrightconfig.json", "setup.py", "setup.cfg", "requirements.txt", "Pipfile"},
+		Extensions:  []string{".py", ".pyi"},
+	},
+	"rust": {
+		Command:     "rust-analyzer",
+		RootMarkers: []string{"Cargo.toml"},
+		Extensions:  []string{".rs"},
+	},
+	"c": {
+		Command:     "clangd",
+		RootMarkers: []string{"compile_commands.json", "compile_flags.txt", ".clangd", "CMakeLists.txt"},
+		Extensions:  []string{".c", ".h", ".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx", ".m", ".mm"},
+	},
+}
+
+// applyLSPPresets fills in the language server configurations. Servers for the
+// languages detected in the working directory, its parents or, for monorepos,
+// its subdirectories are added when their binary is on the PATH. Configured servers named after a preset inherit the preset values
+// they don't set, so a configuration can override or disable a preset.
+func applyLSPPresets() {
+	if cfg.LSP == nil {
+		cfg.LSP = make(map[string]LSPConfig)
+	}
+	for name, preset := range lspPresets {
+		lspConfig, configured := cfg.LSP[name]
+		if !configured {
+			if !cfg.AutoLSP || lspCommandConfigured(preset.Command) {
+				continue
+			}
+			if FindProjectRoot(cfg.WorkingDir, preset.RootMarkers) == "" && !containsProject(cfg.WorkingDir, preset.RootMarkers, projectSearchDepth) {
+				continue
+			}
+			if _, err := exec.LookPath(preset.Command); err != nil {
+				continue
+			}
+			logging.Debug("Detected language server", "language", name, "command", preset.Command)
+		}
+		if lspConfig.Command == "" {
+			lspConfig.Command = preset.Command
+			if lspConfig.Args == nil {
+				lspConfig.Args = preset.Args
+			}
+		}
+		if lspConfig.RootMarkers == nil {
+			lspConfig.RootMarkers = preset.RootMarkers
+		}
+		if lspConfig.Extensions == nil {
+			lspConfig.Extensions = preset.Extensions
+		}
+		cfg.LSP[name] = lspConfig
+	}
+}
+
+// projectSearchDepth is how many levels of subdirectories are searched for
+// projects, for monorepos without a project at their root
+const projectSearchDepth = 2
+
+// skippedProjectDirs hold dependencies and build outputs rather than projects
+var skippedProjectDirs = []string{"node_modules", "vendor", "target", "build", "dist"}
+
+// containsProject reports whether dir or its subdirectories, up to depth
+// levels down, contain one of the marker files. Hidden directories are
+// skipped.
+func containsProject(dir string, markers []string, depth int) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if slices.Contains(markers, entry.Name()) {
+			return true
+		}
+	}
+	if depth == 0 {
+		return false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || slices.Contains(skippedProjectDirs, name) {
+			continue
+		}
+		if containsProject(filepath.Join(dir, name), markers, depth-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// lspCommandConfigured reports whether a configured server, under any name,
+// already runs command
+func lspCommandConfigured(command string) bool {
+	for _, lspConfig := range cfg.LSP {
+		if filepath.Base(lspConfig.Command) == command {
+			return true
+		}
+	}
+	return false
+}
+
+// FindProjectRoot returns the nearest directory, starting at dir, that contains
+// one of the marker files. The search goes up to the root of the git repository
+// containing dir, or only looks at dir itself when it isn't in a repository.
+// It returns an empty string when no marker is found.
+func FindProjectRoot(dir string, markers []string) string {
+	for _, candidate := range projectRootCandidates(dir) {
+		for _, marker := range markers {
+			if _, err := os.Stat(filepath.Join(candidate, marker)); err == nil {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// projectRootCandidates returns dir and its parents up to the git repository root
+func projectRootCandidates(dir string) []string {
+	var candidates []string
+	for current := filepath.Clean(dir); ; {
+		candidates = append(candidates, current)
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return candidates
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			// Not in a repository, don't pick up markers from unrelated parents
+			return []string{filepath.Clean(dir)}
+		}
+		current = parent
+	}
+}
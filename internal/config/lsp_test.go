@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProjectRoot(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "go.mod"), []byte("module x\n"), 0o644))
+	pkg := filepath.Join(repo, "web", "src")
+	require.NoError(t, os.MkdirAll(pkg, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "web", "package.json"), []byte("{}"), 0o644))
+
+	assert.Equal(t, filepath.Join(repo, "web"), FindProjectRoot(pkg, []string{"package.json"}))
+	assert.Equal(t, repo, FindProjectRoot(pkg, []string{"go.mod"}))
+	assert.Equal(t, "", FindProjectRoot(pkg, []string{"Cargo.toml"}))
+
+	// Outside of a repository only the directory itself is searched
+	plain := t.TempDir()
+	sub := filepath.Join(plain, "sub")
+	require.NoError(t, os.Mkdir(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(plain, "go.mod"), []byte("module x\n"), 0o644))
+	assert.Equal(t, "", FindProjectRoot(sub, []string{"go.mod"}))
+	assert.Equal(t, plain, FindProjectRoot(plain, []string{"go.mod"}))
+}
+
+func TestApplyLSPPresets(t *testing.T) {
+	oldCfg := cfg
+	defer func() { cfg = oldCfg }()
+
+	cfg = &Config{
+		WorkingDir: t.TempDir(),
+		LSP: map[string]LSPConfig{
+			"go":     {Args: []string{"-remote=auto"}},
+			"rust":   {Disabled: true},
+			"custom": {Command: "my-server"},
+		},
+	}
+	applyLSPPresets()
+
+	assert.Equal(t, "gopls", cfg.LSP["go"].Command)
+	assert.Equal(t, []string{"-remote=auto"}, cfg.LSP["go"].Args)
+	assert.Equal(t, []string{"go.work", "go.mod"}, cfg.LSP["go"].RootMarkers)
+	assert.True(t, cfg.LSP["rust"].Disabled)
+	assert.Equal(t, "my-server", cfg.LSP["custom"].Command)
+	// Nothing is detected with auto detection off
+	assert.NotContains(t, cfg.LSP, "typescript")
+}
+
+func TestLSPCommandConfigured(t *testing.T) {
+	oldCfg := cfg
+	defer func() { cfg = oldCfg }()
+
+	cfg = &Config{LSP: map[string]LSPConfig{"gopls": {Command: "/usr/local/bin/gopls"}}}
+	assert.True(t, lspCommandConfigured("gopls"))
+	assert.False(t, lspCommandConfigured("clangd"))
+}
+
+func TestContainsProject(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"services/api/go.mod", "web/node_modules/left-pad/package.json", ".cache/Cargo.toml", "a/b/c/setup.py"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+
+	assert.True(t, containsProject(dir, []string{"go.mod"}, projectSearchDepth))
+	assert.False(t, containsProject(dir, []string{"go.mod"}, 1))
+	// Dependencies, hidden directories and deeper projects are not detected
+	assert.False(t, containsProject(dir, []string{"package.json"}, projectSearchDepth))
+	assert.False(t, containsProject(dir, []string{"Cargo.toml"}, projectSearchDepth))
+	assert.False(t, containsProject(dir, []string{"setup.py"}, projectSearchDepth))
+}
@@ -846,6 +846,53 @@ func FormatDiff(diffText string, opts ...SideBySideOption) (string, error) {
 	return sb.String(), nil
 }
 
+// FileDiff is the part of a multi-file unified diff that applies to one file
+type FileDiff struct {
+	Path string
+	Diff string
+}
+
+// SplitUnifiedDiff splits a multi-file unified diff, like the output of git diff, into per-file diffs
+func SplitUnifiedDiff(diffText string) []FileDiff {
+	var files []FileDiff
+	var current []string
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		var path string
+		for _, line := range current {
+			if strings.HasPrefix(line, "+++ b/") {
+				path = strings.TrimPrefix(line, "+++ b/")
+				break
+			}
+			if strings.HasPrefix(line, "--- a/") {
+				path = strings.TrimPrefix(line, "--- a/")
+			}
+		}
+		if path == "" && strings.HasPrefix(current[0], "diff --git a/") {
+			// Binary files and mode changes have no ---/+++ headers
+			header := strings.TrimPrefix(current[0], "diff --git a/")
+			if i := strings.Index(header, " b/"); i >= 0 {
+				path = header[:i]
+			}
+		}
+		files = append(files, FileDiff{Path: path, Diff: strings.Join(current, "\n")})
+		current = nil
+	}
+
+	for line := range strings.SplitSeq(diffText, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	return files
+}
+
 // GenerateDiff creates a unified diff from two file contents
 func GenerateDiff(beforeContent, afterContent, fileName string) (string, int, int) {
 	// remove the cwd prefix and ensure consistent path format
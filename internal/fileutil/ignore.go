@@ -0,0 +1,207 @@
+package fileutil
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ignoreFileNames are the files listing the paths ignored in their directory
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+// Ignorer tells whether the paths of a tree are ignored by the .gitignore and
+// .ignore files found in it, by .git/info/exclude, or by extra patterns
+// relative to its root. The extra patterns take precedence over the files.
+// Ignore files are read when first needed and cached until invalidated.
+type Ignorer struct {
+	root  string
+	extra []ignoreRule
+
+	mu          sync.Mutex
+	rules       map[string][]ignoreRule
+	ignoredDirs map[string]bool
+}
+
+type ignoreRule struct {
+	// base is the directory the pattern is relative to
+	base    string
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// NewIgnorer creates an Ignorer for the tree at root. patterns use the
+// gitignore syntax.
+func NewIgnorer(root string, patterns []string) *Ignorer {
+	i := &Ignorer{
+		root:        filepath.Clean(root),
+		rules:       make(map[string][]ignoreRule),
+		ignoredDirs: make(map[string]bool),
+	}
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreRule(i.root, pattern); ok {
+			i.extra = append(i.extra, rule)
+		}
+	}
+	return i
+}
+
+// IsIgnored reports whether path is ignored. Paths inside an ignored directory
+// are ignored as well, and paths outside of the root never are.
+func (i *Ignorer) IsIgnored(path string, isDir bool) bool {
+	rel, err := filepath.Rel(i.root, filepath.Clean(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	current := i.root
+	parts := strings.Split(rel, string(filepath.Separator))
+	for n, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+		ignored, cached := i.ignoredDirs[current]
+		if !cached {
+			ignored = i.matches(current, true, parts[:n+1])
+			i.ignoredDirs[current] = ignored
+		}
+		if ignored {
+			return true
+		}
+	}
+	return i.matches(filepath.Clean(path), isDir, parts)
+}
+
+// Invalidate forgets the cached rules, it must be called when an ignore file
+// changes
+func (i *Ignorer) Invalidate() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	clear(i.rules)
+	clear(i.ignoredDirs)
+}
+
+// IsIgnoreFile reports whether path is a file listing ignored paths
+func IsIgnoreFile(path string) bool {
+	base := filepath.Base(path)
+	for _, name := range ignoreFileNames {
+		if base == name {
+			return true
+		}
+	}
+	return filepath.Base(filepath.Dir(filepath.Dir(path))) == ".git" && base == "exclude"
+}
+
+// matches applies the rules of the directories from the root down to the
+// parent of path, the last matching rule deciding. parts are the elements of
+// the path relative to the root.
+func (i *Ignorer) matches(path string, isDir bool, parts []string) bool {
+	if parts[len(parts)-1] == ".git" {
+		return true
+	}
+
+	ignored := false
+	dir := i.root
+	for n := 0; n < len(parts); n++ {
+		if n > 0 {
+			dir = filepath.Join(dir, parts[n-1])
+		}
+		for _, rule := range i.load(dir) {
+			if rule.match(path, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	for _, rule := range i.extra {
+		if rule.match(path, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// load returns the rules of the ignore files in dir
+func (i *Ignorer) load(dir string) []ignoreRule {
+	if rules, ok := i.rules[dir]; ok {
+		return rules
+	}
+
+	files := make([]string, 0, len(ignoreFileNames)+1)
+	if dir == i.root {
+		files = append(files, filepath.Join(dir, ".git", "info", "exclude"))
+	}
+	for _, name := range ignoreFileNames {
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	var rules []ignoreRule
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreRule(dir, scanner.Text()); ok {
+				rules = append(rules, rule)
+			}
+		}
+		f.Close()
+	}
+	i.rules[dir] = rules
+	return rules
+}
+
+// parseIgnoreRule parses a line of an ignore file in the base directory
+func parseIgnoreRule(base, line string) (ignoreRule, bool) {
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " \t\r")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\#") || strings.HasPrefix(line, "\\!") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// Patterns without a slash match at any depth, the others are relative
+	// to the directory of the ignore file
+	if strings.Contains(line, "/") {
+		rule.pattern = strings.TrimPrefix(line, "/")
+	} else {
+		rule.pattern = "**/" + line
+	}
+	if !doublestar.ValidatePattern(rule.pattern) {
+		return ignoreRule{}, false
+	}
+	return rule, true
+}
+
+func (r ignoreRule) match(path string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	rel, err := filepath.Rel(r.base, path)
+	if err != nil {
+		return false
+	}
+	ok, _ := doublestar.Match(r.pattern, filepath.ToSlash(rel))
+	return ok
+}
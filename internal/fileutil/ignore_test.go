@@ -0,0 +1,70 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnorer(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write(".gitignore", "# build output\n*.log\n!keep.log\n/dist\ncache/\n")
+	write("web/.ignore", "generated/**\n")
+	write(".git/info/exclude", "secret.txt\n")
+
+	ignorer := NewIgnorer(root, []string{"third_party/", "web/*.tmp"})
+	path := func(p string) string { return filepath.Join(root, p) }
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"main.go", false, false},
+		{"debug.log", false, true},
+		{"sub/debug.log", false, true},
+		{"sub/keep.log", false, false},
+		{"dist", true, true},
+		{"dist/app.js", false, true},
+		{"sub/dist", true, false},
+		{"cache", true, true},
+		{"sub/cache/file", false, true},
+		{"cache", false, false},
+		{"web/generated/api.ts", false, true},
+		{"generated/api.ts", false, false},
+		{"secret.txt", false, true},
+		{".git", true, true},
+		{".git/config", false, true},
+		{"third_party/lib/lib.go", false, true},
+		{"web/x.tmp", false, true},
+		{"web/sub/x.tmp", false, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.ignored, ignorer.IsIgnored(path(tt.path), tt.isDir), tt.path)
+	}
+	assert.False(t, ignorer.IsIgnored(root, true))
+	assert.False(t, ignorer.IsIgnored(filepath.Join(filepath.Dir(root), "other.log"), false))
+
+	// Changes to ignore files apply once invalidated
+	write(".gitignore", "*.go\n")
+	assert.True(t, ignorer.IsIgnored(path("debug.log"), false))
+	ignorer.Invalidate()
+	assert.False(t, ignorer.IsIgnored(path("debug.log"), false))
+	assert.True(t, ignorer.IsIgnored(path("main.go"), false))
+}
+
+func TestIsIgnoreFile(t *testing.T) {
+	assert.True(t, IsIgnoreFile("/repo/.gitignore"))
+	assert.True(t, IsIgnoreFile("/repo/sub/.ignore"))
+	assert.True(t, IsIgnoreFile("/repo/.git/info/exclude"))
+	assert.False(t, IsIgnoreFile("/repo/exclude"))
+	assert.False(t, IsIgnoreFile("/repo/main.go"))
+}
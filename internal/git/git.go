@@ -0,0 +1,95 @@
+// Package git runs git commands in the user's repository.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNotRepository = errors.New("not a git repository")
+
+// Run runs git with args in dir and returns its output with surrounding
+// whitespace removed. Errors include git's stderr.
+func Run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "not a git repository") {
+			return "", ErrNotRepository
+		}
+		if msg == "" {
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimRight(stdout.String(), " \t\r\n"), nil
+}
+
+// ChangedFiles returns the paths, relative to dir, of all files with staged,
+// unstaged or untracked changes.
+func ChangedFiles(ctx context.Context, dir string) ([]string, error) {
+	root, err := Run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	out, err := Run(ctx, dir, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	// Porcelain paths are relative to the repository root.
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(absDir); err == nil {
+		absDir = resolved
+	}
+	var files []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		path := entry[3:]
+		if rel, err := filepath.Rel(absDir, filepath.Join(root, path)); err == nil {
+			path = rel
+		}
+		files = append(files, path)
+		// Renames and copies are followed by the original path.
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+	return files, nil
+}
+
+// Commit commits the given paths, or everything staged if paths is empty,
+// and returns the short hash of the new commit. Untracked paths are added
+// first.
+func Commit(ctx context.Context, dir, message string, paths []string) (string, error) {
+	if len(paths) > 0 {
+		addArgs := append([]string{"add", "--all", "--"}, paths...)
+		if _, err := Run(ctx, dir, addArgs...); err != nil {
+			return "", err
+		}
+	}
+	commitArgs := []string{"commit", "--quiet", "-m", message}
+	if len(paths) > 0 {
+		commitArgs = append(commitArgs, "--")
+		commitArgs = append(commitArgs, paths...)
+	}
+	if _, err := Run(ctx, dir, commitArgs...); err != nil {
+		return "", err
+	}
+	return Run(ctx, dir, "rev-parse", "--short", "HEAD")
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepo creates a repository with a first commit of the given files
+func newTestRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := Run(context.Background(), t.TempDir(), "--version"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run(t, dir, "init", "--quiet")
+	run(t, dir, "config", "user.email", "test@example.com")
+	run(t, dir, "config", "user.name", "Test")
+	run(t, dir, "config", "commit.gpgsign", "false")
+	for name, content := range files {
+		writeFile(t, dir, name, content)
+	}
+	run(t, dir, "add", "--all")
+	run(t, dir, "commit", "--quiet", "-m", "initial")
+	return dir
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := Run(context.Background(), dir, args...)
+	require.NoError(t, err)
+	return out
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestChangedFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := newTestRepo(t, map[string]string{
+		"with space.txt": "a\n",
+		"old name.txt":   "b\n",
+		"sub/kept.go":    "package sub\n",
+	})
+
+	files, err := ChangedFiles(ctx, dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	writeFile(t, dir, "with space.txt", "changed\n")
+	run(t, dir, "mv", "old name.txt", "new name.txt")
+	writeFile(t, dir, "sub/new file.go", "package sub\n")
+
+	files, err = ChangedFiles(ctx, dir)
+	require.NoError(t, err)
+	// The original path of the rename is not listed
+	assert.ElementsMatch(t, []string{"with space.txt", "new name.txt", "sub/new file.go"}, files)
+
+	// Paths are relative to the directory
+	files, err = ChangedFiles(ctx, filepath.Join(dir, "sub"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"../with space.txt", "../new name.txt", "new file.go"}, files)
+
+	_, err = ChangedFiles(ctx, t.TempDir())
+	assert.ErrorIs(t, err, ErrNotRepository)
+}
+
+func TestCommit(t *testing.T) {
+	ctx := context.Background()
+	dir := newTestRepo(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+
+	writeFile(t, dir, "a.txt", "a2\n")
+	writeFile(t, dir, "b.txt", "b2\n")
+	writeFile(t, dir, "new file.txt", "new\n")
+
+	// Only the given paths are committed, untracked ones included
+	hash, err := Commit(ctx, dir, "update a", []string{"a.txt", "new file.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, run(t, dir, "rev-parse", "--short", "HEAD"), hash)
+	assert.Equal(t, "a.txt\nnew file.txt", run(t, dir, "show", "--name-only", "--format=", "HEAD"))
+	assert.Equal(t, "update a", run(t, dir, "log", "-1", "--format=%s"))
+	files, err := ChangedFiles(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.txt"}, files)
+
+	// Without paths, what is staged is committed
+	run(t, dir, "add", "b.txt")
+	_, err = Commit(ctx, dir, "update b", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "b.txt", run(t, dir, "show", "--name-only", "--format=", "HEAD"))
+
+	_, err = Commit(ctx, dir, "nothing", nil)
+	assert.Error(t, err)
+}
@@ -4,16 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/opencode-ai/opencode/internal/checkpoint"
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/llm/models"
 	"github.com/opencode-ai/opencode/internal/llm/prompt"
 	"github.com/opencode-ai/opencode/internal/llm/provider"
 	"github.com/opencode-ai/opencode/internal/llm/tools"
 	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/lsp"
 	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/permission"
 	"github.com/opencode-ai/opencode/internal/pubsub"
@@ -58,10 +61,13 @@ type Service interface {
 
 type agent struct {
 	*pubsub.Broker[AgentEvent]
-	sessions session.Service
-	messages message.Service
+	sessions    session.Service
+	messages    message.Service
+	checkpoints checkpoint.Service
+	lspClients  map[string]*lsp.Client
 
 	tools    []tools.BaseTool
+	mcpTools *McpTools
 	provider provider.Provider
 
 	titleProvider     provider.Provider
@@ -75,6 +81,9 @@ func NewAgent(
 	sessions session.Service,
 	messages message.Service,
 	agentTools []tools.BaseTool,
+	mcpTools *McpTools,
+	checkpoints checkpoint.Service,
+	lspClients map[string]*lsp.Client,
 ) (Service, error) {
 	agentProvider, err := createAgentProvider(agentName)
 	if err != nil {
@@ -101,7 +110,10 @@ func NewAgent(
 		provider:          agentProvider,
 		messages:          messages,
 		sessions:          sessions,
+		checkpoints:       checkpoints,
+		lspClients:        lspClients,
 		tools:             agentTools,
+		mcpTools:          mcpTools,
 		titleProvider:     titleProvider,
 		summarizeProvider: summarizeProvider,
 		activeRequests:    sync.Map{},
@@ -197,7 +209,11 @@ func (a *agent) err(err error) AgentEvent {
 
 func (a *agent) Run(ctx context.Context, sessionID string, content string, attachments ...message.Attachment) (<-chan AgentEvent, error) {
 	if !a.provider.Model().SupportsAttachments && attachments != nil {
-		attachments = nil
+		// Text attachments and the text of documents are inlined in the
+		// prompt, any model can read them
+		attachments = slices.DeleteFunc(attachments, func(attachment message.Attachment) bool {
+			return !message.IsTextMIMEType(attachment.MimeType) && !message.IsPDFMIMEType(attachment.MimeType)
+		})
 	}
 	events := make(chan AgentEvent)
 	if a.IsSessionBusy(sessionID) {
@@ -212,9 +228,10 @@ func (a *agent) Run(ctx context.Context, sessionID string, content string, attac
 		defer logging.RecoverPanic("agent.Run", func() {
 			events <- a.err(fmt.Errorf("panic while running the agent"))
 		})
+		a.createCheckpoint(genCtx, sessionID, content)
 		var attachmentParts []message.ContentPart
 		for _, attachment := range attachments {
-			attachmentParts = append(attachmentParts, message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content})
+			attachmentParts = append(attachmentParts, attachmentContent(attachment))
 		}
 		result := a.processGeneration(genCtx, sessionID, content, attachmentParts)
 		if result.Error != nil && !errors.Is(result.Error, ErrRequestCancelled) && !errors.Is(result.Error, context.Canceled) {
@@ -230,6 +247,32 @@ func (a *agent) Run(ctx context.Context, sessionID string, content string, attac
 	return events, nil
 }
 
+// attachmentContent converts an attachment to the content of a message. The
+// text of PDF documents is extracted once, for the providers that can't read
+// them.
+func attachmentContent(attachment message.Attachment) message.BinaryContent {
+	content := message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content}
+	if content.IsPDF() {
+		text, err := message.ExtractPDFText(content.Data)
+		if err != nil {
+			logging.Warn("Failed to extract the text of a PDF attachment", "path", attachment.FilePath, "error", err)
+		}
+		content.Text = text
+	}
+	return content
+}
+
+// createCheckpoint records the working tree before the agent starts working on
+// a new prompt. Failures are logged and don't stop the agent.
+func (a *agent) createCheckpoint(ctx context.Context, sessionID, content string) {
+	if a.checkpoints == nil || !config.Get().Checkpoints.Enabled {
+		return
+	}
+	if _, err := a.checkpoints.Create(ctx, sessionID, content); err != nil {
+		logging.Warn("Failed to create checkpoint", "sessionID", sessionID, "error", err)
+	}
+}
+
 func (a *agent) processGeneration(ctx context.Context, sessionID, content string, attachmentParts []message.ContentPart) AgentEvent {
 	cfg := config.Get()
 	// List existing messages; if none, start title generation asynchronously.
@@ -273,6 +316,12 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 	// Append the new user message to the conversation history.
 	msgHistory := append(msgs, userMsg)
 
+	// The files modified while answering this message are verified against
+	// their diagnostics from before the first modification
+	tools.ClearEditBaselines(sessionID)
+	defer tools.ClearEditBaselines(sessionID)
+	verifyAttempts := 0
+
 	for {
 		// Check for cancellation before each iteration
 		select {
@@ -302,6 +351,13 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 			msgHistory = append(msgHistory, agentMessage, *toolResults)
 			continue
 		}
+		if verifyAttempts < cfg.Verify.MaxAttempts && agentMessage.FinishReason() == message.FinishReasonEndTurn {
+			if verifyMsg, ok := a.verifyEdits(ctx, sessionID); ok {
+				verifyAttempts++
+				msgHistory = append(msgHistory, agentMessage, verifyMsg)
+				continue
+			}
+		}
 		return AgentEvent{
 			Type:    AgentEventTypeResponse,
 			Message: agentMessage,
@@ -310,6 +366,36 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 	}
 }
 
+// verifySessionEdits reports the errors introduced by the edits of a session.
+// It is replaced in tests.
+var verifySessionEdits = tools.VerifyEdits
+
+// verifyEdits sends the errors introduced in the files modified by the agent
+// back to it as a user message. It returns false when verification is
+// disabled or the edits introduced no errors.
+func (a *agent) verifyEdits(ctx context.Context, sessionID string) (message.Message, bool) {
+	if !config.Get().Verify.Enabled || len(a.lspClients) == 0 {
+		return message.Message{}, false
+	}
+	report := verifySessionEdits(ctx, sessionID, a.lspClients)
+	if report == "" || ctx.Err() != nil {
+		return message.Message{}, false
+	}
+	logging.Info("Edits introduced errors, asking the agent to fix them", "sessionID", sessionID)
+	msg, err := a.createUserMessage(ctx, sessionID, report, nil)
+	if err != nil {
+		logging.Warn("Failed to create verification message", "sessionID", sessionID, "error", err)
+		return message.Message{}, false
+	}
+	return msg, true
+}
+
+// allTools returns the tools offered to the model, with the current tools of
+// the MCP servers
+func (a *agent) allTools() []tools.BaseTool {
+	return append(slices.Clip(a.tools), a.mcpTools.Tools()...)
+}
+
 func (a *agent) createUserMessage(ctx context.Context, sessionID, content string, attachmentParts []message.ContentPart) (message.Message, error) {
 	parts := []message.ContentPart{message.TextContent{Text: content}}
 	parts = append(parts, attachmentParts...)
@@ -321,7 +407,8 @@ func (a *agent) createUserMessage(ctx context.Context, sessionID, content string
 
 func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msgHistory []message.Message) (message.Message, *message.Message, error) {
 	ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
-	eventChan := a.provider.StreamResponse(ctx, msgHistory, a.tools)
+	agentTools := a.allTools()
+	eventChan := a.provider.StreamResponse(ctx, msgHistory, agentTools)
 
 	assistantMsg, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
 		Role:  message.Assistant,
@@ -365,7 +452,7 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 		default:
 			// Continue processing
 			var tool tools.BaseTool
-			for _, availableTool := range a.tools {
+			for _, availableTool := range agentTools {
 				if availableTool.Info().Name == toolCall.Name {
 					tool = availableTool
 					break
@@ -416,6 +503,12 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 				Metadata:   toolResult.Metadata,
 				IsError:    toolResult.IsError,
 			}
+			for _, image := range toolResult.Images {
+				toolResults[i].Images = append(toolResults[i].Images, message.BinaryContent{
+					MIMEType: image.MIMEType,
+					Data:     image.Data,
+				})
+			}
 		}
 	}
 out:
@@ -703,7 +796,7 @@ func (a *agent) Summarize(ctx context.Context, sessionID string) error {
 	return nil
 }
 
-func createAgentProvider(agentName config.AgentName) (provider.Provider, error) {
+func createAgentProvider(agentName config.AgentName, extraOpts ...provider.ProviderClientOption) (provider.Provider, error) {
 	cfg := config.Get()
 	agentConfig, ok := cfg.Agents[agentName]
 	if !ok {
@@ -728,7 +821,7 @@ func createAgentProvider(agentName config.AgentName) (provider.Provider, error)
 	opts := []provider.ProviderClientOption{
 		provider.WithAPIKey(providerCfg.APIKey),
 		provider.WithModel(model),
-		provider.WithSystemMessage(prompt.GetAgentPrompt(agentName, model.Provider)),
+		provider.WithSystemMessageFunc(prompt.GetAgentSystemMessage(agentName, model.Provider)),
 		provider.WithMaxTokens(maxTokens),
 	}
 	if model.Provider == models.ProviderOpenAI || model.Provider == models.ProviderLocal && model.CanReason {
@@ -748,7 +841,7 @@ func createAgentProvider(agentName config.AgentName) (provider.Provider, error)
 	}
 	agentProvider, err := provider.NewProvider(
 		model.Provider,
-		opts...,
+		append(opts, extraOpts...)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("could not create provider: %v", err)
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/pubsub"
+	"github.com/opencode-ai/opencode/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endTurnProvider ends every turn without calling tools and records the
+// history it was sent
+type endTurnProvider struct {
+	histories [][]message.Message
+}
+
+func (p *endTurnProvider) SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*provider.ProviderResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *endTurnProvider) StreamResponse(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan provider.ProviderEvent {
+	p.histories = append(p.histories, messages)
+	events := make(chan provider.ProviderEvent, 1)
+	events <- provider.ProviderEvent{
+		Type:     provider.EventComplete,
+		Response: &provider.ProviderResponse{FinishReason: message.FinishReasonEndTurn},
+	}
+	close(events)
+	return events
+}
+
+func (p *endTurnProvider) Model() models.Model {
+	return models.Model{}
+}
+
+type memoryMessages struct {
+	message.Service
+	messages []message.Message
+}
+
+func (s *memoryMessages) Create(ctx context.Context, sessionID string, params message.CreateMessageParams) (message.Message, error) {
+	msg := message.Message{
+		ID:        fmt.Sprintf("message-%d", len(s.messages)),
+		Role:      params.Role,
+		SessionID: sessionID,
+		Parts:     params.Parts,
+	}
+	s.messages = append(s.messages, msg)
+	return msg, nil
+}
+
+func (s *memoryMessages) Update(ctx context.Context, msg message.Message) error {
+	return nil
+}
+
+func (s *memoryMessages) List(ctx context.Context, sessionID string) ([]message.Message, error) {
+	// A previous message keeps the agent from generating a title
+	return []message.Message{{ID: "previous", Role: message.User, SessionID: sessionID}}, nil
+}
+
+type memorySessions struct {
+	session.Service
+}
+
+func (s *memorySessions) Get(ctx context.Context, id string) (session.Session, error) {
+	return session.Session{ID: id}, nil
+}
+
+func (s *memorySessions) Save(ctx context.Context, sess session.Session) (session.Session, error) {
+	return sess, nil
+}
+
+func TestProcessGenerationVerifiesEdits(t *testing.T) {
+	_, err := config.Load(t.TempDir(), false)
+	require.NoError(t, err)
+	cfg := config.Get()
+	originalVerify := cfg.Verify
+	t.Cleanup(func() { cfg.Verify = originalVerify })
+	originalVerifySessionEdits := verifySessionEdits
+	t.Cleanup(func() { verifySessionEdits = originalVerifySessionEdits })
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		reports  []string
+		wantRuns int
+	}{
+		{name: "disabled", enabled: false, reports: []string{"errors"}, wantRuns: 1},
+		{name: "no errors", enabled: true, reports: []string{""}, wantRuns: 1},
+		{name: "errors fixed", enabled: true, reports: []string{"errors 1", "errors 2", ""}, wantRuns: 3},
+		{name: "errors left after the last attempt", enabled: true, reports: []string{"errors 1", "errors 2", "errors 3"}, wantRuns: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Verify = config.VerifyConfig{Enabled: tt.enabled, MaxAttempts: 3}
+			verified := 0
+			verifySessionEdits = func(ctx context.Context, sessionID string, lsps map[string]*lsp.Client) string {
+				report := tt.reports[verified]
+				verified++
+				return report
+			}
+
+			p := &endTurnProvider{}
+			a := &agent{
+				Broker:     pubsub.NewBroker[AgentEvent](),
+				sessions:   &memorySessions{},
+				messages:   &memoryMessages{},
+				lspClients: map[string]*lsp.Client{"test": {}},
+				provider:   p,
+			}
+			result := a.processGeneration(context.Background(), "session", "fix the build", nil)
+			require.NoError(t, result.Error)
+			assert.Equal(t, AgentEventTypeResponse, result.Type)
+
+			wantVerified := len(tt.reports)
+			if !tt.enabled {
+				wantVerified = 0
+			}
+			assert.Equal(t, wantVerified, verified)
+			require.Len(t, p.histories, tt.wantRuns)
+			for i, history := range p.histories[1:] {
+				// Each retry is sent the previous answer and the errors it introduced
+				last := history[len(history)-1]
+				assert.Equal(t, message.User, last.Role)
+				assert.Equal(t, tt.reports[i], last.Content().Text)
+				assert.Equal(t, message.Assistant, history[len(history)-2].Role)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/models"
+	"github.com/opencode-ai/opencode/internal/llm/prompt"
+	"github.com/opencode-ai/opencode/internal/llm/provider"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+const (
+	// The title agent is limited to a few tokens, commit messages may have a body.
+	commitMessageMaxTokens = 500
+	maxCommitDiffLength    = 30000
+)
+
+// GenerateCommitMessage asks the title agent's model to draft a commit message
+// for diff. recentCommits holds the subjects of recent commits so the message
+// follows the repository's conventions.
+func GenerateCommitMessage(ctx context.Context, diff, recentCommits string) (string, error) {
+	if strings.TrimSpace(diff) == "" {
+		return "", errors.New("no changes to commit")
+	}
+	model := models.SupportedModels[config.Get().Agents[config.AgentTitle].Model]
+	commitProvider, err := createAgentProvider(
+		config.AgentTitle,
+		provider.WithSystemMessage(prompt.CommitMessagePrompt(model.Provider)),
+		provider.WithMaxTokens(commitMessageMaxTokens),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if len(diff) > maxCommitDiffLength {
+		diff = diff[:maxCommitDiffLength] + "\n... [diff truncated]"
+	}
+	var content strings.Builder
+	if recentCommits != "" {
+		fmt.Fprintf(&content, "Recent commit messages:\n%s\n\n", recentCommits)
+	}
+	fmt.Fprintf(&content, "Diff:\n%s", diff)
+
+	response, err := commitProvider.SendMessages(
+		ctx,
+		[]message.Message{
+			{
+				Role:  message.User,
+				Parts: []message.ContentPart{message.TextContent{Text: content.String()}},
+			},
+		},
+		make([]tools.BaseTool, 0),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	commitMessage := strings.TrimSpace(response.Content)
+	commitMessage = strings.TrimPrefix(commitMessage, "```")
+	commitMessage = strings.TrimSuffix(commitMessage, "```")
+	commitMessage = strings.TrimSpace(commitMessage)
+	if commitMessage == "" {
+		return "", errors.New("the model returned an empty commit message")
+	}
+	return commitMessage, nil
+}
@@ -1,37 +1,29 @@
 package agent
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/llm/tools"
-	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/mcpclient"
 	"github.com/opencode-ai/opencode/internal/permission"
-	"github.com/opencode-ai/opencode/internal/version"
 
-	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 type mcpTool struct {
 	mcpName     string
 	tool        mcp.Tool
-	mcpConfig   config.MCPServer
+	mcpClients  mcpclient.Service
 	permissions permission.Service
 }
 
-type MCPClient interface {
-	Initialize(
-		ctx context.Context,
-		request mcp.InitializeRequest,
-	) (*mcp.InitializeResult, error)
-	ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error)
-	CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
-	Close() error
-}
-
 func (b *mcpTool) Info() tools.ToolInfo {
 	required := b.tool.InputSchema.Required
 	if required == nil {
@@ -45,157 +37,143 @@ func (b *mcpTool) Info() tools.ToolInfo {
 	}
 }
 
-func runTool(ctx context.Context, c MCPClient, toolName string, input string) (tools.ToolResponse, error) {
-	defer c.Close()
-	initRequest := mcp.InitializeRequest{}
-	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
-	initRequest.Params.ClientInfo = mcp.Implementation{
-		Name:    "OpenCode",
-		Version: version.Version,
+func (b *mcpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolResponse, error) {
+	sessionID, messageID := tools.GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return tools.ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
-
-	_, err := c.Initialize(ctx, initRequest)
-	if err != nil {
-		return tools.NewTextErrorResponse(err.Error()), nil
+	if !mcpclient.ToolTrusted(config.Get().MCPServers[b.mcpName], b.tool.Name) {
+		input := params.Input
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(params.Input), "", "  "); err == nil {
+			input = indented.String()
+		}
+		permissionDescription := fmt.Sprintf("execute `%s` with the following parameters:\n\n```json\n%s\n```", b.Info().Name, input)
+		p := b.permissions.Request(
+			permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        config.WorkingDirectory(),
+				ToolName:    b.Info().Name,
+				Action:      "execute",
+				Description: permissionDescription,
+				Params:      params.Input,
+			},
+		)
+		if !p {
+			return tools.NewTextErrorResponse("permission denied"), nil
+		}
 	}
 
-	toolRequest := mcp.CallToolRequest{}
-	toolRequest.Params.Name = toolName
 	var args map[string]any
-	if err = json.Unmarshal([]byte(input), &args); err != nil {
+	if err := json.Unmarshal([]byte(params.Input), &args); err != nil {
 		return tools.NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
 	}
-	toolRequest.Params.Arguments = args
-	result, err := c.CallTool(ctx, toolRequest)
+	result, err := b.mcpClients.CallTool(ctx, b.mcpName, b.tool.Name, args)
 	if err != nil {
 		return tools.NewTextErrorResponse(err.Error()), nil
 	}
 
-	output := ""
-	for _, v := range result.Content {
-		if v, ok := v.(mcp.TextContent); ok {
-			output = v.Text
-		} else {
-			output = fmt.Sprintf("%v", v)
+	return mcpToolResponse(result), nil
+}
+
+// mcpToolResponse joins the content blocks of a result. Embedded text
+// resources are inlined, images are returned for the models that can see
+// them, and other binary contents are described.
+func mcpToolResponse(result *mcp.CallToolResult) tools.ToolResponse {
+	var parts []string
+	var images []tools.ToolImage
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			parts = append(parts, c.Text)
+		case mcp.ImageContent:
+			parts = append(parts, fmt.Sprintf("[image: %s, %d bytes]", c.MIMEType, base64.StdEncoding.DecodedLen(len(c.Data))))
+			if image, ok := mcpImage(c); ok {
+				images = append(images, image)
+			}
+		case mcp.AudioContent:
+			parts = append(parts, fmt.Sprintf("[audio: %s, %d bytes]", c.MIMEType, base64.StdEncoding.DecodedLen(len(c.Data))))
+		case mcp.EmbeddedResource:
+			switch r := c.Resource.(type) {
+			case mcp.TextResourceContents:
+				parts = append(parts, fmt.Sprintf("<resource uri=%q>\n%s\n</resource>", r.URI, r.Text))
+			case mcp.BlobResourceContents:
+				parts = append(parts, fmt.Sprintf("[resource %s: %s, %d bytes]", r.URI, r.MIMEType, base64.StdEncoding.DecodedLen(len(r.Blob))))
+			}
+		case mcp.ResourceLink:
+			parts = append(parts, fmt.Sprintf("[resource %s: %s]", c.URI, c.Name))
+		}
+	}
+	if len(parts) == 0 && result.StructuredContent != nil {
+		if data, err := json.Marshal(result.StructuredContent); err == nil {
+			parts = append(parts, string(data))
 		}
 	}
 
-	return tools.NewTextResponse(output), nil
+	output := strings.Join(parts, "\n\n")
+	if result.IsError {
+		return tools.NewTextErrorResponse(output)
+	}
+	if len(images) > 0 {
+		return tools.NewImageResponse(output, images...)
+	}
+	return tools.NewTextResponse(output)
 }
 
-func (b *mcpTool) Run(ctx context.Context, params tools.ToolCall) (tools.ToolResponse, error) {
-	sessionID, messageID := tools.GetContextValues(ctx)
-	if sessionID == "" || messageID == "" {
-		return tools.ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
+// mcpImage decodes an image of a result, when it is small enough and of a
+// type the models can see
+func mcpImage(content mcp.ImageContent) (tools.ToolImage, bool) {
+	data, err := base64.StdEncoding.DecodeString(content.Data)
+	if err != nil || len(data) > tools.MaxImageSize {
+		return tools.ToolImage{}, false
 	}
-	permissionDescription := fmt.Sprintf("execute %s with the following parameters: %s", b.Info().Name, params.Input)
-	p := b.permissions.Request(
-		permission.CreatePermissionRequest{
-			SessionID:   sessionID,
-			Path:        config.WorkingDirectory(),
-			ToolName:    b.Info().Name,
-			Action:      "execute",
-			Description: permissionDescription,
-			Params:      params.Input,
-		},
-	)
-	if !p {
-		return tools.NewTextErrorResponse("permission denied"), nil
-	}
-
-	switch b.mcpConfig.Type {
-	case config.MCPStdio:
-		c, err := client.NewStdioMCPClient(
-			b.mcpConfig.Command,
-			b.mcpConfig.Env,
-			b.mcpConfig.Args...,
-		)
-		if err != nil {
-			return tools.NewTextErrorResponse(err.Error()), nil
-		}
-		return runTool(ctx, c, b.tool.Name, params.Input)
-	case config.MCPSse:
-		c, err := client.NewSSEMCPClient(
-			b.mcpConfig.URL,
-			client.WithHeaders(b.mcpConfig.Headers),
-		)
-		if err != nil {
-			return tools.NewTextErrorResponse(err.Error()), nil
-		}
-		return runTool(ctx, c, b.tool.Name, params.Input)
+	mimeType := tools.ImageMIMEType(data)
+	if mimeType == "" {
+		return tools.ToolImage{}, false
 	}
-
-	return tools.NewTextErrorResponse("invalid mcp type"), nil
+	return tools.ToolImage{MIMEType: mimeType, Data: data}, true
 }
 
-func NewMcpTool(name string, tool mcp.Tool, permissions permission.Service, mcpConfig config.MCPServer) tools.BaseTool {
+func NewMcpTool(name string, tool mcp.Tool, permissions permission.Service, mcpClients mcpclient.Service) tools.BaseTool {
 	return &mcpTool{
 		mcpName:     name,
 		tool:        tool,
-		mcpConfig:   mcpConfig,
+		mcpClients:  mcpClients,
 		permissions: permissions,
 	}
 }
 
-var mcpTools []tools.BaseTool
+// McpTools offers the tools of the connected MCP servers. The tools are read
+// at each request, to follow the changes of the tool lists of the servers.
+type McpTools struct {
+	permissions permission.Service
+	mcpClients  mcpclient.Service
+}
 
-func getTools(ctx context.Context, name string, m config.MCPServer, permissions permission.Service, c MCPClient) []tools.BaseTool {
-	var stdioTools []tools.BaseTool
-	initRequest := mcp.InitializeRequest{}
-	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
-	initRequest.Params.ClientInfo = mcp.Implementation{
-		Name:    "OpenCode",
-		Version: version.Version,
+func NewMcpTools(permissions permission.Service, mcpClients mcpclient.Service) *McpTools {
+	return &McpTools{
+		permissions: permissions,
+		mcpClients:  mcpClients,
 	}
+}
 
-	_, err := c.Initialize(ctx, initRequest)
-	if err != nil {
-		logging.Error("error initializing mcp client", "error", err)
-		return stdioTools
-	}
-	toolsRequest := mcp.ListToolsRequest{}
-	tools, err := c.ListTools(ctx, toolsRequest)
-	if err != nil {
-		logging.Error("error listing tools", "error", err)
-		return stdioTools
+// Tools returns the current tools of the servers
+func (m *McpTools) Tools() []tools.BaseTool {
+	if m == nil || m.mcpClients == nil {
+		return nil
 	}
-	for _, t := range tools.Tools {
-		stdioTools = append(stdioTools, NewMcpTool(name, t, permissions, m))
+	servers := m.mcpClients.Tools()
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
 	}
-	defer c.Close()
-	return stdioTools
-}
-
-func GetMcpTools(ctx context.Context, permissions permission.Service) []tools.BaseTool {
-	if len(mcpTools) > 0 {
-		return mcpTools
-	}
-	for name, m := range config.Get().MCPServers {
-		switch m.Type {
-		case config.MCPStdio:
-			c, err := client.NewStdioMCPClient(
-				m.Command,
-				m.Env,
-				m.Args...,
-			)
-			if err != nil {
-				logging.Error("error creating mcp client", "error", err)
-				continue
-			}
+	sort.Strings(names)
 
-			mcpTools = append(mcpTools, getTools(ctx, name, m, permissions, c)...)
-		case config.MCPSse:
-			c, err := client.NewSSEMCPClient(
-				m.URL,
-				client.WithHeaders(m.Headers),
-			)
-			if err != nil {
-				logging.Error("error creating mcp client", "error", err)
-				continue
-			}
-			mcpTools = append(mcpTools, getTools(ctx, name, m, permissions, c)...)
+	var mcpTools []tools.BaseTool
+	for _, name := range names {
+		for _, t := range servers[name] {
+			mcpTools = append(mcpTools, NewMcpTool(name, t, m.permissions, m.mcpClients))
 		}
 	}
-
 	return mcpTools
 }
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMcpToolResponse(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+	pngData := buf.Bytes()
+	pngBase64 := base64.StdEncoding.EncodeToString(pngData)
+	// Sizes are estimated from the length of the base64 data
+	imageText := fmt.Sprintf("[image: image/png, %d bytes]", base64.StdEncoding.DecodedLen(len(pngBase64)))
+
+	tests := []struct {
+		name   string
+		result *mcp.CallToolResult
+		want   tools.ToolResponse
+	}{
+		{
+			name: "text blocks",
+			result: &mcp.CallToolResult{Content: []mcp.Content{
+				mcp.NewTextContent("first"),
+				mcp.NewTextContent("second"),
+			}},
+			want: tools.NewTextResponse("first\n\nsecond"),
+		},
+		{
+			name: "image",
+			result: &mcp.CallToolResult{Content: []mcp.Content{
+				mcp.NewTextContent("a screenshot"),
+				mcp.NewImageContent(pngBase64, "image/png"),
+			}},
+			want: tools.NewImageResponse(
+				"a screenshot\n\n"+imageText,
+				tools.ToolImage{MIMEType: "image/png", Data: pngData},
+			),
+		},
+		{
+			name: "image that is not one",
+			result: &mcp.CallToolResult{Content: []mcp.Content{
+				mcp.NewImageContent(base64.StdEncoding.EncodeToString([]byte("hello")), "image/png"),
+			}},
+			want: tools.NewTextResponse("[image: image/png, 6 bytes]"),
+		},
+		{
+			name: "audio",
+			result: &mcp.CallToolResult{Content: []mcp.Content{
+				mcp.NewAudioContent(base64.StdEncoding.EncodeToString([]byte("abc")), "audio/wav"),
+			}},
+			want: tools.NewTextResponse("[audio: audio/wav, 3 bytes]"),
+		},
+		{
+			name: "embedded text resource",
+			result: &mcp.CallToolResult{Content: []mcp.Content{
+				mcp.NewEmbeddedResource(mcp.TextResourceContents{URI: "file:///notes.txt", MIMEType: "text/plain", Text: "notes"}),
+			}},
+			want: tools.NewTextResponse("<resource uri=\"file:///notes.txt\">\nnotes\n</resource>"),
+		},
+		{
+			name: "embedded blob resource",
+			result: &mcp.CallToolResult{Content: []mcp.Content{
+				mcp.NewEmbeddedResource(mcp.BlobResourceContents{URI: "file:///data.bin", MIMEType: "application/octet-stream", Blob: base64.StdEncoding.EncodeToString([]byte("abc"))}),
+			}},
+			want: tools.NewTextResponse("[resource file:///data.bin: application/octet-stream, 3 bytes]"),
+		},
+		{
+			name: "resource link",
+			result: &mcp.CallToolResult{Content: []mcp.Content{
+				mcp.NewResourceLink("file:///report.pdf", "report", "", "application/pdf"),
+			}},
+			want: tools.NewTextResponse("[resource file:///report.pdf: report]"),
+		},
+		{
+			name: "structured content",
+			result: &mcp.CallToolResult{
+				StructuredContent: map[string]any{"count": 2},
+			},
+			want: tools.NewTextResponse(`{"count":2}`),
+		},
+		{
+			name: "structured content with text",
+			result: &mcp.CallToolResult{
+				Content:           []mcp.Content{mcp.NewTextContent("two")},
+				StructuredContent: map[string]any{"count": 2},
+			},
+			want: tools.NewTextResponse("two"),
+		},
+		{
+			name: "error",
+			result: &mcp.CallToolResult{
+				Content: []mcp.Content{mcp.NewTextContent("not found")},
+				IsError: true,
+			},
+			want: tools.NewTextErrorResponse("not found"),
+		},
+		{
+			name: "error with image",
+			result: &mcp.CallToolResult{
+				Content: []mcp.Content{mcp.NewImageContent(pngBase64, "image/png")},
+				IsError: true,
+			},
+			want: tools.NewTextErrorResponse(imageText),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mcpToolResponse(tt.result))
+		})
+	}
+}
+
+func TestMcpImage(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+	pngData := buf.Bytes()
+
+	// The type is read from the data rather than from the declared type
+	img, ok := mcpImage(mcp.NewImageContent(base64.StdEncoding.EncodeToString(pngData), "image/jpeg"))
+	assert.True(t, ok)
+	assert.Equal(t, tools.ToolImage{MIMEType: "image/png", Data: pngData}, img)
+
+	oversized := append(bytes.Clone(pngData), make([]byte, tools.MaxImageSize)...)
+	_, ok = mcpImage(mcp.NewImageContent(base64.StdEncoding.EncodeToString(oversized), "image/png"))
+	assert.False(t, ok)
+
+	_, ok = mcpImage(mcp.NewImageContent(base64.StdEncoding.EncodeToString([]byte("%PDF-1.7")), "image/png"))
+	assert.False(t, ok)
+
+	_, ok = mcpImage(mcp.NewImageContent("not base64!", "image/png"))
+	assert.False(t, ok)
+}
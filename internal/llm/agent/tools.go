@@ -1,10 +1,10 @@
 package agent
 
 import (
-	"context"
-
+	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/history"
 	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/llm/tools/shell"
 	"github.com/opencode-ai/opencode/internal/lsp"
 	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/permission"
@@ -17,23 +17,48 @@ func CoderAgentTools(
 	messages message.Service,
 	history history.Service,
 	lspClients map[string]*lsp.Client,
+	backgroundJobs shell.BackgroundService,
 ) []tools.BaseTool {
-	ctx := context.Background()
-	otherTools := GetMcpTools(ctx, permissions)
+	var otherTools []tools.BaseTool
 	if len(lspClients) > 0 {
 		otherTools = append(otherTools, tools.NewDiagnosticsTool(lspClients))
 	}
+	// Clients start in the background, so check the configuration rather
+	// than the clients that are already running.
+	if len(config.Get().LSP) > 0 {
+		otherTools = append(otherTools,
+			tools.NewDefinitionTool(lspClients),
+			tools.NewReferencesTool(lspClients),
+			tools.NewHoverTool(lspClients),
+			tools.NewCallHierarchyTool(lspClients),
+			tools.NewWorkspaceSymbolsTool(lspClients),
+			tools.NewRenameSymbolTool(lspClients, permissions, history),
+			tools.NewCodeActionTool(lspClients, permissions, history),
+		)
+	}
+	if cfg := config.Get().WebSearch; cfg.Backend != "" {
+		otherTools = append(otherTools, tools.NewWebSearchTool(permissions, cfg))
+	}
 	return append(
 		[]tools.BaseTool{
-			tools.NewBashTool(permissions),
+			tools.NewBashTool(permissions, backgroundJobs),
+			tools.NewBashOutputTool(backgroundJobs),
+			tools.NewBashInputTool(permissions, backgroundJobs),
+			tools.NewBashKillTool(backgroundJobs),
 			tools.NewEditTool(lspClients, permissions, history),
 			tools.NewFetchTool(permissions),
+			tools.NewGitStatusTool(),
+			tools.NewGitDiffTool(),
+			tools.NewGitLogTool(),
+			tools.NewGitCommitTool(permissions),
+			tools.NewGitBranchTool(permissions),
 			tools.NewGlobTool(),
 			tools.NewGrepTool(),
 			tools.NewLsTool(),
 			tools.NewSourcegraphTool(),
 			tools.NewViewTool(lspClients),
 			tools.NewPatchTool(lspClients, permissions, history),
+			tools.NewRepoMapTool(lspClients),
 			tools.NewWriteTool(lspClients, permissions, history),
 			NewAgentTool(sessions, messages, lspClients),
 		}, otherTools...,
@@ -41,11 +66,60 @@ func CoderAgentTools(
 }
 
 func TaskAgentTools(lspClients map[string]*lsp.Client) []tools.BaseTool {
-	return []tools.BaseTool{
+	taskTools := []tools.BaseTool{
 		tools.NewGlobTool(),
 		tools.NewGrepTool(),
 		tools.NewLsTool(),
+		tools.NewRepoMapTool(lspClients),
 		tools.NewSourcegraphTool(),
 		tools.NewViewTool(lspClients),
 	}
+	if len(config.Get().LSP) > 0 {
+		taskTools = append(taskTools,
+			tools.NewDefinitionTool(lspClients),
+			tools.NewReferencesTool(lspClients),
+			tools.NewHoverTool(lspClients),
+			tools.NewCallHierarchyTool(lspClients),
+			tools.NewWorkspaceSymbolsTool(lspClients),
+		)
+	}
+	return taskTools
+}
+
+// ServerTools are the tools offered to other agents by `opencode mcp`. The
+// read-only set leaves out the tools that change files.
+func ServerTools(
+	permissions permission.Service,
+	history history.Service,
+	lspClients map[string]*lsp.Client,
+	readOnly bool,
+) []tools.BaseTool {
+	serverTools := []tools.BaseTool{
+		tools.NewViewTool(lspClients),
+		tools.NewGrepTool(),
+		tools.NewGlobTool(),
+	}
+	if !readOnly {
+		serverTools = append(serverTools,
+			tools.NewEditTool(lspClients, permissions, history),
+			tools.NewPatchTool(lspClients, permissions, history),
+		)
+	}
+	if len(config.Get().LSP) > 0 {
+		serverTools = append(serverTools,
+			tools.NewDiagnosticsTool(lspClients),
+			tools.NewDefinitionTool(lspClients),
+			tools.NewReferencesTool(lspClients),
+			tools.NewHoverTool(lspClients),
+			tools.NewCallHierarchyTool(lspClients),
+			tools.NewWorkspaceSymbolsTool(lspClients),
+		)
+		if !readOnly {
+			serverTools = append(serverTools,
+				tools.NewRenameSymbolTool(lspClients, permissions, history),
+				tools.NewCodeActionTool(lspClients, permissions, history),
+			)
+		}
+	}
+	return serverTools
 }
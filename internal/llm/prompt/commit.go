@@ -0,0 +1,13 @@
+package prompt
+
+import "github.com/opencode-ai/opencode/internal/llm/models"
+
+func CommitMessagePrompt(_ models.ModelProvider) string {
+	return `you will write a git commit message for the diff the user gives you
+- the first line is a summary of the change in the imperative mood, not more than 72 characters long
+- if the change needs more explanation, add a blank line followed by a short body wrapped at 72 characters
+- the body explains why the change was made rather than repeating the diff
+- if recent commit messages are given, follow their conventions (prefixes, capitalization, tense)
+- do not use markdown, quotes or code fences
+- the entire text you return will be used as the commit message`
+}
@@ -1,15 +1,19 @@
 package prompt
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/llm/models"
 	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/repomap"
 )
 
 func GetAgentPrompt(agentName config.AgentName, provider models.ModelProvider) string {
@@ -38,6 +42,60 @@ func GetAgentPrompt(agentName config.AgentName, provider models.ModelProvider) s
 	return basePrompt
 }
 
+// GetAgentSystemMessage returns the function building the system message of
+// an agent. The prompts of the coder and task agents gain the repository map
+// once it is built.
+func GetAgentSystemMessage(agentName config.AgentName, provider models.ModelProvider) func() string {
+	basePrompt := GetAgentPrompt(agentName, provider)
+	if agentName != config.AgentCoder && agentName != config.AgentTask {
+		return func() string { return basePrompt }
+	}
+
+	startRepoMap()
+	return func() string {
+		repoMap := repoMapContent.Load()
+		if repoMap == nil || *repoMap == "" {
+			return basePrompt
+		}
+		return fmt.Sprintf("%s\n\n# Repository Map\nThe most referenced top-level symbols of the repository, by file. Use it to find where to look, and the repo_map tool for other directories or more detail.\n%s", basePrompt, *repoMap)
+	}
+}
+
+// repoMapTimeout bounds the time spent building the repository map of the
+// prompt, the prompt has no map when it is exceeded
+const repoMapTimeout = 30 * time.Second
+
+var (
+	onceRepoMap    sync.Once
+	repoMapContent atomic.Pointer[string]
+)
+
+// startRepoMap builds the map of the repository in the background when it is
+// enabled, so that it doesn't delay the start. It is built once, with the
+// built-in extractors since the language servers may not be ready yet.
+func startRepoMap() {
+	onceRepoMap.Do(func() {
+		cfg := config.Get()
+		if !cfg.RepoMap.Enabled {
+			return
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), repoMapTimeout)
+			defer cancel()
+			content, err := repomap.Generate(ctx, repomap.Options{
+				Root:      cfg.WorkingDir,
+				MaxTokens: cfg.RepoMap.MaxTokens,
+			})
+			if err != nil {
+				logging.Warn("Failed to build the repository map", "error", err)
+				return
+			}
+			repoMapContent.Store(&content)
+		}()
+	})
+}
+
 var (
 	onceContext    sync.Once
 	contextContent string
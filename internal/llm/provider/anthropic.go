@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -65,7 +66,7 @@ func (a *anthropicClient) convertMessages(messages []message.Message) (anthropic
 		}
 		switch msg.Role {
 		case message.User:
-			content := anthropic.NewTextBlock(msg.Content().String())
+			content := anthropic.NewTextBlock(msg.PromptText())
 			if cache && !a.options.disableCache {
 				content.OfText.CacheControl = anthropic.CacheControlEphemeralParam{
 					Type: "ephemeral",
@@ -73,11 +74,18 @@ func (a *anthropicClient) convertMessages(messages []message.Message) (anthropic
 			}
 			var contentBlocks []anthropic.ContentBlockParamUnion
 			contentBlocks = append(contentBlocks, content)
-			for _, binaryContent := range msg.BinaryContent() {
+			for _, binaryContent := range msg.ImageContent() {
 				base64Image := binaryContent.String(models.ProviderAnthropic)
 				imageBlock := anthropic.NewImageBlockBase64(binaryContent.MIMEType, base64Image)
 				contentBlocks = append(contentBlocks, imageBlock)
 			}
+			for _, binaryContent := range msg.DocumentContent() {
+				documentBlock := anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{
+					Data: binaryContent.String(models.ProviderAnthropic),
+				})
+				documentBlock.OfDocument.Title = anthropic.String(filepath.Base(binaryContent.Path))
+				contentBlocks = append(contentBlocks, documentBlock)
+			}
 			anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(contentBlocks...))
 
 		case message.Assistant:
@@ -111,6 +119,15 @@ func (a *anthropicClient) convertMessages(messages []message.Message) (anthropic
 			results := make([]anthropic.ContentBlockParamUnion, len(msg.ToolResults()))
 			for i, toolResult := range msg.ToolResults() {
 				results[i] = anthropic.NewToolResultBlock(toolResult.ToolCallID, toolResult.Content, toolResult.IsError)
+				if !a.providerOptions.model.SupportsAttachments {
+					continue
+				}
+				for _, binaryContent := range toolResult.Images {
+					base64Image := binaryContent.String(models.ProviderAnthropic)
+					imageBlock := anthropic.NewImageBlockBase64(binaryContent.MIMEType, base64Image)
+					results[i].OfToolResult.Content = append(results[i].OfToolResult.Content,
+						anthropic.ToolResultBlockParamContentUnion{OfImage: imageBlock.OfImage})
+				}
 			}
 			anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(results...))
 		}
@@ -186,7 +203,7 @@ func (a *anthropicClient) preparedMessages(messages []anthropic.MessageParam, to
 		Thinking:    thinkingParam,
 		System: []anthropic.TextBlockParam{
 			{
-				Text: a.providerOptions.systemMessage,
+				Text: a.providerOptions.systemMessage(),
 				CacheControl: anthropic.CacheControlEphemeralParam{
 					Type: "ephemeral",
 				},
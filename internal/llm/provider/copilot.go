@@ -188,16 +188,17 @@ func newCopilotClient(opts providerClientOptions) CopilotClient {
 
 func (c *copilotClient) convertMessages(messages []message.Message) (copilotMessages []openai.ChatCompletionMessageParamUnion) {
 	// Add system message first
-	copilotMessages = append(copilotMessages, openai.SystemMessage(c.providerOptions.systemMessage))
+	copilotMessages = append(copilotMessages, openai.SystemMessage(c.providerOptions.systemMessage()))
 
 	for _, msg := range messages {
 		switch msg.Role {
 		case message.User:
 			var content []openai.ChatCompletionContentPartUnionParam
-			textBlock := openai.ChatCompletionContentPartTextParam{Text: msg.Content().String()}
+			// PDF documents are sent as their text
+			textBlock := openai.ChatCompletionContentPartTextParam{Text: msg.PromptText() + msg.DocumentText()}
 			content = append(content, openai.ChatCompletionContentPartUnionParam{OfText: &textBlock})
 
-			for _, binaryContent := range msg.BinaryContent() {
+			for _, binaryContent := range msg.ImageContent() {
 				imageURL := openai.ChatCompletionContentPartImageImageURLParam{URL: binaryContent.String(models.ProviderCopilot)}
 				imageBlock := openai.ChatCompletionContentPartImageParam{ImageURL: imageURL}
 				content = append(content, openai.ChatCompletionContentPartUnionParam{OfImageURL: &imageBlock})
@@ -240,6 +241,11 @@ func (c *copilotClient) convertMessages(messages []message.Message) (copilotMess
 					openai.ToolMessage(result.Content, result.ToolCallID),
 				)
 			}
+			if c.providerOptions.model.SupportsAttachments {
+				if images, ok := openaiToolImagesMessage(msg.ToolResults()); ok {
+					copilotMessages = append(copilotMessages, images)
+				}
+			}
 		}
 	}
 
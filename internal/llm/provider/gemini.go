@@ -56,11 +56,13 @@ func (g *geminiClient) convertMessages(messages []message.Message) []*genai.Cont
 		switch msg.Role {
 		case message.User:
 			var parts []*genai.Part
-			parts = append(parts, &genai.Part{Text: msg.Content().String()})
-			for _, binaryContent := range msg.BinaryContent() {
-				imageFormat := strings.Split(binaryContent.MIMEType, "/")
+			parts = append(parts, &genai.Part{Text: msg.PromptText()})
+			for _, binaryContent := range msg.ImageContent() {
+				parts = append(parts, geminiImagePart(binaryContent))
+			}
+			for _, binaryContent := range msg.DocumentContent() {
 				parts = append(parts, &genai.Part{InlineData: &genai.Blob{
-					MIMEType: imageFormat[1],
+					MIMEType: message.PDFMIMEType,
 					Data:     binaryContent.Data,
 				}})
 			}
@@ -126,12 +128,40 @@ func (g *geminiClient) convertMessages(messages []message.Message) []*genai.Cont
 					Role: "function",
 				})
 			}
+			if g.providerOptions.model.SupportsAttachments {
+				// Function responses can't hold images, they follow in a
+				// user message
+				var parts []*genai.Part
+				for _, result := range msg.ToolResults() {
+					if len(result.Images) == 0 {
+						continue
+					}
+					parts = append(parts, &genai.Part{Text: toolImagesLabel(result)})
+					for _, binaryContent := range result.Images {
+						parts = append(parts, geminiImagePart(binaryContent))
+					}
+				}
+				if len(parts) > 0 {
+					history = append(history, &genai.Content{
+						Parts: parts,
+						Role:  "user",
+					})
+				}
+			}
 		}
 	}
 
 	return history
 }
 
+func geminiImagePart(binaryContent message.BinaryContent) *genai.Part {
+	imageFormat := strings.Split(binaryContent.MIMEType, "/")
+	return &genai.Part{InlineData: &genai.Blob{
+		MIMEType: imageFormat[1],
+		Data:     binaryContent.Data,
+	}}
+}
+
 func (g *geminiClient) convertTools(tools []tools.BaseTool) []*genai.Tool {
 	geminiTool := &genai.Tool{}
 	geminiTool.FunctionDeclarations = make([]*genai.FunctionDeclaration, 0, len(tools))
@@ -180,7 +210,7 @@ func (g *geminiClient) send(ctx context.Context, messages []message.Message, too
 	config := &genai.GenerateContentConfig{
 		MaxOutputTokens: int32(g.providerOptions.maxTokens),
 		SystemInstruction: &genai.Content{
-			Parts: []*genai.Part{{Text: g.providerOptions.systemMessage}},
+			Parts: []*genai.Part{{Text: g.providerOptions.systemMessage()}},
 		},
 	}
 	if len(tools) > 0 {
@@ -268,7 +298,7 @@ func (g *geminiClient) stream(ctx context.Context, messages []message.Message, t
 	config := &genai.GenerateContentConfig{
 		MaxOutputTokens: int32(g.providerOptions.maxTokens),
 		SystemInstruction: &genai.Content{
-			Parts: []*genai.Part{{Text: g.providerOptions.systemMessage}},
+			Parts: []*genai.Part{{Text: g.providerOptions.systemMessage()}},
 		},
 	}
 	if len(tools) > 0 {
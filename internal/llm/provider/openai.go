@@ -67,15 +67,16 @@ func newOpenAIClient(opts providerClientOptions) OpenAIClient {
 
 func (o *openaiClient) convertMessages(messages []message.Message) (openaiMessages []openai.ChatCompletionMessageParamUnion) {
 	// Add system message first
-	openaiMessages = append(openaiMessages, openai.SystemMessage(o.providerOptions.systemMessage))
+	openaiMessages = append(openaiMessages, openai.SystemMessage(o.providerOptions.systemMessage()))
 
 	for _, msg := range messages {
 		switch msg.Role {
 		case message.User:
 			var content []openai.ChatCompletionContentPartUnionParam
-			textBlock := openai.ChatCompletionContentPartTextParam{Text: msg.Content().String()}
+			// PDF documents are sent as their text
+			textBlock := openai.ChatCompletionContentPartTextParam{Text: msg.PromptText() + msg.DocumentText()}
 			content = append(content, openai.ChatCompletionContentPartUnionParam{OfText: &textBlock})
-			for _, binaryContent := range msg.BinaryContent() {
+			for _, binaryContent := range msg.ImageContent() {
 				imageURL := openai.ChatCompletionContentPartImageImageURLParam{URL: binaryContent.String(models.ProviderOpenAI)}
 				imageBlock := openai.ChatCompletionContentPartImageParam{ImageURL: imageURL}
 
@@ -119,12 +120,39 @@ func (o *openaiClient) convertMessages(messages []message.Message) (openaiMessag
 					openai.ToolMessage(result.Content, result.ToolCallID),
 				)
 			}
+			if o.providerOptions.model.SupportsAttachments {
+				if images, ok := openaiToolImagesMessage(msg.ToolResults()); ok {
+					openaiMessages = append(openaiMessages, images)
+				}
+			}
 		}
 	}
 
 	return
 }
 
+// openaiToolImagesMessage is a user message with the images returned by the
+// tools, which tool messages can't hold
+func openaiToolImagesMessage(results []message.ToolResult) (openai.ChatCompletionMessageParamUnion, bool) {
+	var content []openai.ChatCompletionContentPartUnionParam
+	for _, result := range results {
+		if len(result.Images) == 0 {
+			continue
+		}
+		textBlock := openai.ChatCompletionContentPartTextParam{Text: toolImagesLabel(result)}
+		content = append(content, openai.ChatCompletionContentPartUnionParam{OfText: &textBlock})
+		for _, binaryContent := range result.Images {
+			imageURL := openai.ChatCompletionContentPartImageImageURLParam{URL: binaryContent.String(models.ProviderOpenAI)}
+			imageBlock := openai.ChatCompletionContentPartImageParam{ImageURL: imageURL}
+			content = append(content, openai.ChatCompletionContentPartUnionParam{OfImageURL: &imageBlock})
+		}
+	}
+	if len(content) == 0 {
+		return openai.ChatCompletionMessageParamUnion{}, false
+	}
+	return openai.UserMessage(content), true
+}
+
 func (o *openaiClient) convertTools(tools []tools.BaseTool) []openai.ChatCompletionToolParam {
 	openaiTools := make([]openai.ChatCompletionToolParam, len(tools))
 
@@ -62,7 +62,7 @@ type providerClientOptions struct {
 	apiKey        string
 	model         models.Model
 	maxTokens     int64
-	systemMessage string
+	systemMessage func() string
 
 	anthropicOptions []AnthropicOption
 	openaiOptions    []OpenAIOption
@@ -84,7 +84,9 @@ type baseProvider[C ProviderClient] struct {
 }
 
 func NewProvider(providerName models.ModelProvider, opts ...ProviderClientOption) (Provider, error) {
-	clientOptions := providerClientOptions{}
+	clientOptions := providerClientOptions{
+		systemMessage: func() string { return "" },
+	}
 	for _, o := range opts {
 		o(&clientOptions)
 	}
@@ -178,6 +180,12 @@ func (p *baseProvider[C]) cleanMessages(messages []message.Message) (cleaned []m
 	return
 }
 
+// toolImagesLabel introduces the images of a tool result, for the providers
+// that send them in a user message after the tool results
+func toolImagesLabel(result message.ToolResult) string {
+	return fmt.Sprintf("Images returned by tool call %s:", result.ToolCallID)
+}
+
 func (p *baseProvider[C]) SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
 	messages = p.cleanMessages(messages)
 	return p.client.send(ctx, messages, tools)
@@ -211,6 +219,13 @@ func WithMaxTokens(maxTokens int64) ProviderClientOption {
 }
 
 func WithSystemMessage(systemMessage string) ProviderClientOption {
+	return WithSystemMessageFunc(func() string { return systemMessage })
+}
+
+// WithSystemMessageFunc sets the function building the system message of
+// each request, for messages with parts that are ready after the provider
+// is created
+func WithSystemMessageFunc(systemMessage func() string) ProviderClientOption {
 	return func(options *providerClientOptions) {
 		options.systemMessage = systemMessage
 	}
@@ -13,21 +13,26 @@ import (
 )
 
 type BashParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command         string `json:"command"`
+	Timeout         int    `json:"timeout"`
+	RunInBackground bool   `json:"run_in_background"`
 }
 
 type BashPermissionsParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command         string   `json:"command"`
+	Commands        []string `json:"commands"`
+	Timeout         int      `json:"timeout"`
+	RunInBackground bool     `json:"run_in_background"`
 }
 
 type BashResponseMetadata struct {
-	StartTime int64 `json:"start_time"`
-	EndTime   int64 `json:"end_time"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+	JobID     string `json:"job_id,omitempty"`
 }
 type bashTool struct {
 	permissions permission.Service
+	jobs        shell.BackgroundService
 }
 
 const (
@@ -56,7 +61,7 @@ var safeReadOnlyCommands = []string{
 
 func bashDescription() string {
 	bannedCommandsStr := strings.Join(bannedCommands, ", ")
-	return fmt.Sprintf(`Executes a given bash command in a persistent shell session with optional timeout, ensuring proper handling and security measures.
+	description := fmt.Sprintf(`Executes a given bash command in a persistent shell session with optional timeout, ensuring proper handling and security measures.
 
 Before executing the command, please follow these steps:
 
@@ -74,6 +79,7 @@ Before executing the command, please follow these steps:
 
 4. Output Processing:
  - If the output exceeds %d characters, output will be truncated before being returned to you.
+ - If the command writes a PNG, JPEG, GIF or WebP image to stdout, such as a screenshot taken with 'grim -' or 'import -window root png:-', the image is returned to you instead of the text.
  - Prepare the output for display to the user.
 
 5. Return Result:
@@ -84,6 +90,7 @@ Usage notes:
 - The command argument is required.
 - You can specify an optional timeout in milliseconds (up to 600000ms / 10 minutes). If not specified, commands will timeout after 30 minutes.
 - VERY IMPORTANT: You MUST avoid using search commands like 'find' and 'grep'. Instead use Grep, Glob, or Agent tools to search. You MUST avoid read tools like 'cat', 'head', 'tail', and 'ls', and use FileRead and LS tools to read files.
+- Set run_in_background to true for long-running processes that don't exit on their own, such as dev servers, watchers or interactive programs. The command starts as a background job and the tool returns its job ID immediately. Use the bash_output tool to read the job's output, bash_input to write to its stdin and bash_kill to stop it. Background jobs are not affected by the timeout and are stopped when OpenCode exits.
 - When issuing multiple commands, use the ';' or '&&' operator to separate them. DO NOT use newlines (newlines are ok in quoted strings).
 - IMPORTANT: All commands share the same shell session. Shell state (environment variables, virtual environments, current directory, etc.) persist between commands. For example, if you set an environment variable as part of a command, the environment variable will persist for subsequent commands.
 - Try to maintain your current working directory throughout the session by using absolute paths and avoiding usage of 'cd'. You may use 'cd' if the User explicitly requests it.
@@ -201,11 +208,21 @@ EOF
 Important:
 - Return an empty response - the user will see the gh output directly
 - Never update git config`, bannedCommandsStr, MaxOutputLength)
+
+	if shell.SandboxEnabled() {
+		description += `
+
+# Sandbox
+
+Commands run in a sandbox. They can only write inside the working directory, the temp directory and paths explicitly allowed by the user. Network access is blocked unless the user allowed it. If a command fails because of these restrictions, explain this to the User instead of trying to work around the sandbox.`
+	}
+	return description
 }
 
-func NewBashTool(permission permission.Service) BaseTool {
+func NewBashTool(permission permission.Service, jobs shell.BackgroundService) BaseTool {
 	return &bashTool{
 		permissions: permission,
+		jobs:        jobs,
 	}
 }
 
@@ -222,6 +239,10 @@ func (b *bashTool) Info() ToolInfo {
 				"type":        "number",
 				"description": "Optional timeout in milliseconds (max 600000)",
 			},
+			"run_in_background": map[string]any{
+				"type":        "boolean",
+				"description": "Run the command as a background job and return its job ID without waiting for it to finish",
+			},
 		},
 		Required: []string{"command"},
 	}
@@ -243,30 +264,18 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		return NewTextErrorResponse("missing command"), nil
 	}
 
-	baseCmd := strings.Fields(params.Command)[0]
-	for _, banned := range bannedCommands {
-		if strings.EqualFold(baseCmd, banned) {
-			return NewTextErrorResponse(fmt.Sprintf("command '%s' is not allowed", baseCmd)), nil
-		}
-	}
-
-	isSafeReadOnly := false
-	cmdLower := strings.ToLower(params.Command)
-
-	for _, safe := range safeReadOnlyCommands {
-		if strings.HasPrefix(cmdLower, strings.ToLower(safe)) {
-			if len(cmdLower) == len(safe) || cmdLower[len(safe)] == ' ' || cmdLower[len(safe)] == '-' {
-				isSafeReadOnly = true
-				break
-			}
-		}
+	commandInfo := analyzeBashCommand(params.Command)
+	if commandInfo.Banned != "" {
+		return NewTextErrorResponse(fmt.Sprintf("command '%s' is not allowed", commandInfo.Banned)), nil
 	}
 
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
-	if !isSafeReadOnly {
+	// Commands confined by the sandbox can be approved automatically, e.g. in CI.
+	autoApprove := shell.SandboxEnabled() && config.Get().Sandbox.AutoApprove
+	if !commandInfo.ReadOnly && !autoApprove {
 		p := b.permissions.Request(
 			permission.CreatePermissionRequest{
 				SessionID:   sessionID,
@@ -275,7 +284,9 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 				Action:      "execute",
 				Description: fmt.Sprintf("Execute command: %s", params.Command),
 				Params: BashPermissionsParams{
-					Command: params.Command,
+					Command:         params.Command,
+					Commands:        commandInfo.Commands,
+					RunInBackground: params.RunInBackground,
 				},
 			},
 		)
@@ -284,12 +295,25 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		}
 	}
 	startTime := time.Now()
+	if params.RunInBackground {
+		return b.runInBackground(params.Command, startTime)
+	}
+
 	shell := shell.GetPersistentShell(config.WorkingDirectory())
+	if shell == nil {
+		return NewTextErrorResponse("failed to start shell, check the shell and sandbox configuration"), nil
+	}
 	stdout, stderr, exitCode, interrupted, err := shell.Exec(ctx, params.Command, params.Timeout)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error executing command: %w", err)
 	}
 
+	// An image written to stdout is returned as an image rather than text
+	image, isImage := outputImage(stdout, exitCode, interrupted)
+	if isImage {
+		stdout = ""
+	}
+
 	stdout = truncateOutput(stdout)
 	stderr = truncateOutput(stderr)
 
@@ -320,12 +344,50 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		StartTime: startTime.UnixMilli(),
 		EndTime:   time.Now().UnixMilli(),
 	}
+	if isImage {
+		content := describeImage("The output of the command", image)
+		if stdout != "" {
+			content += "\n" + stdout
+		}
+		return WithResponseMetadata(NewImageResponse(content, image), metadata), nil
+	}
 	if stdout == "" {
 		return WithResponseMetadata(NewTextResponse("no output"), metadata), nil
 	}
 	return WithResponseMetadata(NewTextResponse(stdout), metadata), nil
 }
 
+// outputImage returns the output of a command that completed when it is an
+// image the models can see
+func outputImage(stdout string, exitCode int, interrupted bool) (ToolImage, bool) {
+	if interrupted || exitCode != 0 || len(stdout) > MaxImageSize {
+		return ToolImage{}, false
+	}
+	data := []byte(stdout)
+	mimeType := ImageMIMEType(data)
+	if mimeType == "" {
+		return ToolImage{}, false
+	}
+	return ToolImage{MIMEType: mimeType, Data: data}, true
+}
+
+func (b *bashTool) runInBackground(command string, startTime time.Time) (ToolResponse, error) {
+	job, err := b.jobs.Start(command, config.WorkingDirectory())
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	metadata := BashResponseMetadata{
+		StartTime: startTime.UnixMilli(),
+		EndTime:   time.Now().UnixMilli(),
+		JobID:     job.ID,
+	}
+	return WithResponseMetadata(
+		NewTextResponse(fmt.Sprintf("Started background job %s (pid %d). Use %s with job_id %q to read its output.", job.ID, job.PID, BashOutputToolName, job.ID)),
+		metadata,
+	), nil
+}
+
 func truncateOutput(content string) string {
 	if len(content) <= MaxOutputLength {
 		return content
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// maxCommandParseDepth limits how deep commands passed to "sh -c" or "eval"
+// are parsed.
+const maxCommandParseDepth = 3
+
+// commandWrappers run their arguments as another command.
+var commandWrappers = map[string]bool{
+	"env": true, "time": true, "timeout": true, "nice": true, "nohup": true, "sudo": true, "doas": true,
+	"xargs": true, "command": true, "builtin": true, "exec": true, "stdbuf": true, "watch": true,
+}
+
+// commandShells execute the script given with -c.
+var commandShells = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true,
+}
+
+// bashCommandInfo is the result of analyzing a bash tool command line.
+type bashCommandInfo struct {
+	// Commands holds every simple command found in the command line,
+	// including those in pipelines, lists, subshells and substitutions.
+	Commands []string
+	// Banned is the first banned command found, if any.
+	Banned string
+	// ReadOnly is true when every command is a safe read-only command and
+	// no output is redirected to a file.
+	ReadOnly bool
+}
+
+type commandAnalyzer struct {
+	info    bashCommandInfo
+	printer *syntax.Printer
+}
+
+// analyzeBashCommand parses command with a shell parser and checks every
+// simple command it contains against the banned and safe read-only commands.
+// Commands that can't be analyzed statically are never considered read-only.
+func analyzeBashCommand(command string) bashCommandInfo {
+	a := &commandAnalyzer{
+		info:    bashCommandInfo{ReadOnly: true},
+		printer: syntax.NewPrinter(),
+	}
+	a.parse(command, 0)
+	return a.info
+}
+
+func (a *commandAnalyzer) parse(command string, depth int) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil || depth > maxCommandParseDepth {
+		a.info.ReadOnly = false
+		a.info.Commands = append(a.info.Commands, command)
+		if fields := strings.Fields(command); len(fields) > 0 {
+			a.checkBanned(fields[0])
+		}
+		return
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Stmt:
+			for _, redirect := range n.Redirs {
+				if redirectWritesFile(redirect) {
+					a.info.ReadOnly = false
+				}
+			}
+		case *syntax.CallExpr:
+			a.call(n, depth)
+		}
+		return true
+	})
+}
+
+func (a *commandAnalyzer) call(call *syntax.CallExpr, depth int) {
+	// Plain variable assignments don't run anything.
+	if len(call.Args) == 0 {
+		return
+	}
+
+	var sb strings.Builder
+	if err := a.printer.Print(&sb, call); err == nil {
+		a.info.Commands = append(a.info.Commands, sb.String())
+	}
+
+	args := make([]string, 0, len(call.Args))
+	for i, word := range call.Args {
+		lit, ok := wordLiteral(word)
+		if !ok {
+			// The command to run is only known at runtime.
+			if i == 0 {
+				a.info.ReadOnly = false
+				return
+			}
+			lit = a.print(word)
+		}
+		args = append(args, lit)
+	}
+
+	for len(args) > 0 {
+		name := filepath.Base(args[0])
+		a.checkBanned(name)
+
+		switch {
+		case name == "eval":
+			a.parse(strings.Join(args[1:], " "), depth+1)
+			return
+		case commandShells[name]:
+			for i, arg := range args[1:] {
+				if arg == "-c" && i+2 < len(args) {
+					a.parse(args[i+2], depth+1)
+					return
+				}
+			}
+			// Scripts and interactive shells can do anything.
+			a.info.ReadOnly = false
+			return
+		}
+
+		if !isSafeReadOnlyCommand(args) {
+			a.info.ReadOnly = false
+		}
+		if !commandWrappers[name] {
+			return
+		}
+		// Options of wrappers may take values that look like commands, and
+		// some wrappers take the command as a single string, so every
+		// argument is checked against the banned commands.
+		for _, arg := range args[1:] {
+			if fields := strings.Fields(arg); len(fields) > 0 {
+				a.checkBanned(filepath.Base(fields[0]))
+			}
+		}
+		args = unwrapCommand(args)
+	}
+}
+
+func (a *commandAnalyzer) checkBanned(name string) {
+	if a.info.Banned != "" {
+		return
+	}
+	for _, banned := range bannedCommands {
+		if strings.EqualFold(name, banned) {
+			a.info.Banned = name
+			return
+		}
+	}
+}
+
+func (a *commandAnalyzer) print(node syntax.Node) string {
+	var sb strings.Builder
+	a.printer.Print(&sb, node)
+	return sb.String()
+}
+
+// unwrapCommand returns the command run by a wrapper like env or timeout,
+// skipping the wrapper's options, variable assignments and durations.
+func unwrapCommand(args []string) []string {
+	rest := args[1:]
+	for len(rest) > 0 {
+		arg := rest[0]
+		if strings.HasPrefix(arg, "-") || strings.Contains(arg, "=") || (arg != "" && arg[0] >= '0' && arg[0] <= '9') {
+			rest = rest[1:]
+			continue
+		}
+		break
+	}
+	return rest
+}
+
+func isSafeReadOnlyCommand(args []string) bool {
+	args = append([]string{filepath.Base(args[0])}, args[1:]...)
+	cmdLower := strings.ToLower(strings.Join(args, " "))
+	for _, safe := range safeReadOnlyCommands {
+		safe = strings.ToLower(safe)
+		if strings.HasPrefix(cmdLower, safe) {
+			if len(cmdLower) == len(safe) || cmdLower[len(safe)] == ' ' || cmdLower[len(safe)] == '-' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wordLiteral returns the value of a word made only of literals and quoted
+// strings, or false if the word contains expansions.
+func wordLiteral(word *syntax.Word) (string, bool) {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(unescapeLiteral(p.Value))
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+// unescapeLiteral removes the backslashes escaping characters in an unquoted literal.
+func unescapeLiteral(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String()
+}
+
+func redirectWritesFile(redirect *syntax.Redirect) bool {
+	switch redirect.Op {
+	case syntax.RdrOut, syntax.AppOut, syntax.RdrInOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll, syntax.DplOut:
+	default:
+		return false
+	}
+	target, ok := wordLiteral(redirect.Word)
+	if !ok {
+		return true
+	}
+	switch target {
+	case "/dev/null", "/dev/stdout", "/dev/stderr", "-":
+		return false
+	}
+	if redirect.Op == syntax.DplOut {
+		// Duplicating a file descriptor, as in 2>&1.
+		for _, c := range target {
+			if c < '0' || c > '9' {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
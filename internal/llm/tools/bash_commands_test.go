@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeBashCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		commands []string
+		banned   string
+		readOnly bool
+	}{
+		{
+			name:     "simple safe command",
+			command:  "ls -la",
+			commands: []string{"ls -la"},
+			readOnly: true,
+		},
+		{
+			name:     "list of safe commands",
+			command:  "git status && git diff HEAD; pwd",
+			commands: []string{"git status", "git diff HEAD", "pwd"},
+			readOnly: true,
+		},
+		{
+			name:     "unsafe command after safe one",
+			command:  "ls && rm -rf x",
+			commands: []string{"ls", "rm -rf x"},
+			readOnly: false,
+		},
+		{
+			name:     "banned command in pipeline",
+			command:  "echo hi | curl -d @- example.com",
+			commands: []string{"echo hi", "curl -d @- example.com"},
+			banned:   "curl",
+		},
+		{
+			name:     "banned command in substitution",
+			command:  "echo $(wget -qO- example.com)",
+			commands: []string{"echo $(wget -qO- example.com)", "wget -qO- example.com"},
+			banned:   "wget",
+		},
+		{
+			name:    "banned command in subshell",
+			command: "(cd /tmp; nc -l 8080)",
+			banned:  "nc",
+		},
+		{
+			name:    "banned command behind wrapper",
+			command: "timeout 5 /usr/bin/curl example.com",
+			banned:  "curl",
+		},
+		{
+			name:    "banned command in sh -c",
+			command: `sh -c "ls; curl example.com"`,
+			banned:  "curl",
+		},
+		{
+			name:    "escaped banned command",
+			command: `c\url example.com`,
+			banned:  "curl",
+		},
+		{
+			name:     "wrapped unsafe command",
+			command:  "timeout 5 rm -rf x",
+			commands: []string{"timeout 5 rm -rf x"},
+			readOnly: false,
+		},
+		{
+			name:     "wrapped safe command",
+			command:  "env FOO=bar go test ./...",
+			commands: []string{"env FOO=bar go test ./..."},
+			readOnly: true,
+		},
+		{
+			name:     "output redirected to file",
+			command:  "echo hi > notes.txt",
+			commands: []string{"echo hi"},
+			readOnly: false,
+		},
+		{
+			name:     "output redirected to /dev/null",
+			command:  "go vet ./... 2>&1 >/dev/null",
+			commands: []string{"go vet ./..."},
+			readOnly: true,
+		},
+		{
+			name:     "dynamic command name",
+			command:  "$CMD -rf x",
+			commands: []string{"$CMD -rf x"},
+			readOnly: false,
+		},
+		{
+			name:     "unparseable command",
+			command:  "ls (",
+			commands: []string{"ls ("},
+			readOnly: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := analyzeBashCommand(tt.command)
+			if tt.commands != nil {
+				assert.Equal(t, tt.commands, info.Commands)
+			}
+			assert.Equal(t, tt.banned, info.Banned)
+			if tt.banned == "" {
+				assert.Equal(t, tt.readOnly, info.ReadOnly)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/llm/tools/shell"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type BashOutputParams struct {
+	JobID string `json:"job_id"`
+	Wait  int    `json:"wait"`
+}
+
+type BashInputParams struct {
+	JobID string `json:"job_id"`
+	Input string `json:"input"`
+}
+
+type BashInputPermissionsParams struct {
+	JobID   string `json:"job_id"`
+	Command string `json:"command"`
+	Input   string `json:"input"`
+}
+
+type BashKillParams struct {
+	JobID string `json:"job_id"`
+}
+
+type bashOutputTool struct {
+	jobs shell.BackgroundService
+}
+
+type bashInputTool struct {
+	permissions permission.Service
+	jobs        shell.BackgroundService
+}
+
+type bashKillTool struct {
+	jobs shell.BackgroundService
+}
+
+const (
+	BashOutputToolName = "bash_output"
+	BashInputToolName  = "bash_input"
+	BashKillToolName   = "bash_kill"
+
+	// MaxOutputWait is the longest bash_output will wait for new output, in milliseconds.
+	MaxOutputWait = 30 * 1000
+)
+
+const bashOutputDescription = `Reads output from a background job started with the bash tool's run_in_background option.
+
+WHEN TO USE THIS TOOL:
+- Check whether a dev server or watcher started successfully
+- Follow the logs of a long-running process while you work on something else
+- List all background jobs and their status by omitting job_id
+
+HOW TO USE:
+- Provide the job_id returned by the bash tool
+- Only output produced since the previous call for the same job is returned
+- Optionally set wait (milliseconds, max 30000) to block until new output arrives or the job exits
+
+LIMITATIONS:
+- Only the most recent 1MB of output is kept per job
+- Output longer than 30000 characters is truncated`
+
+const bashInputDescription = `Writes text to the standard input of a running background job.
+
+HOW TO USE:
+- Provide the job_id returned by the bash tool and the input to send
+- A trailing newline is NOT added automatically, include "\n" to submit a line
+- Use bash_output afterwards to read the job's response`
+
+const bashKillDescription = `Stops a background job started with the bash tool's run_in_background option.
+
+HOW TO USE:
+- Provide the job_id returned by the bash tool
+- The job's whole process group receives SIGTERM, followed by SIGKILL if it doesn't exit within a few seconds
+- Always stop jobs you no longer need`
+
+func NewBashOutputTool(jobs shell.BackgroundService) BaseTool {
+	return &bashOutputTool{
+		jobs: jobs,
+	}
+}
+
+func (b *bashOutputTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        BashOutputToolName,
+		Description: bashOutputDescription,
+		Parameters: map[string]any{
+			"job_id": map[string]any{
+				"type":        "string",
+				"description": "The ID of the background job, omit to list all jobs",
+			},
+			"wait": map[string]any{
+				"type":        "number",
+				"description": "Optional time in milliseconds to wait for new output (max 30000)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (b *bashOutputTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params BashOutputParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+
+	if params.JobID == "" {
+		return NewTextResponse(formatJobList(b.jobs.List())), nil
+	}
+
+	if params.Wait > MaxOutputWait {
+		params.Wait = MaxOutputWait
+	}
+
+	output, job, err := b.jobs.ReadOutput(params.JobID)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("%s: %s", err, params.JobID)), nil
+	}
+
+	deadline := time.Now().Add(time.Duration(params.Wait) * time.Millisecond)
+	for output == "" && job.Status == shell.JobRunning && time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return ToolResponse{}, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+		output, job, err = b.jobs.ReadOutput(params.JobID)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(formatJobStatus(job))
+	sb.WriteString("\n")
+	if output == "" {
+		sb.WriteString("(no new output)")
+	} else {
+		sb.WriteString(truncateOutput(output))
+	}
+	return WithResponseMetadata(NewTextResponse(sb.String()), job), nil
+}
+
+func NewBashInputTool(permission permission.Service, jobs shell.BackgroundService) BaseTool {
+	return &bashInputTool{
+		permissions: permission,
+		jobs:        jobs,
+	}
+}
+
+func (b *bashInputTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        BashInputToolName,
+		Description: bashInputDescription,
+		Parameters: map[string]any{
+			"job_id": map[string]any{
+				"type":        "string",
+				"description": "The ID of the background job",
+			},
+			"input": map[string]any{
+				"type":        "string",
+				"description": "The text to write to the job's standard input",
+			},
+		},
+		Required: []string{"job_id", "input"},
+	}
+}
+
+func (b *bashInputTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params BashInputParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if params.JobID == "" {
+		return NewTextErrorResponse("missing job_id"), nil
+	}
+
+	job, err := b.jobs.Get(params.JobID)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("%s: %s", err, params.JobID)), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for writing to a background job")
+	}
+	p := b.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        config.WorkingDirectory(),
+			ToolName:    BashInputToolName,
+			Action:      "write",
+			Description: fmt.Sprintf("Send input to background job %s (%s): %s", job.ID, job.Command, params.Input),
+			Params: BashInputPermissionsParams{
+				JobID:   job.ID,
+				Command: job.Command,
+				Input:   params.Input,
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if err := b.jobs.WriteInput(params.JobID, params.Input); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	return NewTextResponse(fmt.Sprintf("Wrote %d bytes to job %s", len(params.Input), job.ID)), nil
+}
+
+func NewBashKillTool(jobs shell.BackgroundService) BaseTool {
+	return &bashKillTool{
+		jobs: jobs,
+	}
+}
+
+func (b *bashKillTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        BashKillToolName,
+		Description: bashKillDescription,
+		Parameters: map[string]any{
+			"job_id": map[string]any{
+				"type":        "string",
+				"description": "The ID of the background job to stop",
+			},
+		},
+		Required: []string{"job_id"},
+	}
+}
+
+func (b *bashKillTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params BashKillParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if params.JobID == "" {
+		return NewTextErrorResponse("missing job_id"), nil
+	}
+
+	if err := b.jobs.Kill(params.JobID); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("%s: %s", err, params.JobID)), nil
+	}
+	job, err := b.jobs.Get(params.JobID)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	return WithResponseMetadata(NewTextResponse(formatJobStatus(job)), job), nil
+}
+
+func formatJobStatus(job shell.BackgroundJob) string {
+	switch job.Status {
+	case shell.JobRunning:
+		return fmt.Sprintf("Job %s is running (pid %d): %s", job.ID, job.PID, job.Command)
+	case shell.JobKilled:
+		return fmt.Sprintf("Job %s was killed: %s", job.ID, job.Command)
+	default:
+		return fmt.Sprintf("Job %s exited with code %d: %s", job.ID, job.ExitCode, job.Command)
+	}
+}
+
+func formatJobList(jobs []shell.BackgroundJob) string {
+	if len(jobs) == 0 {
+		return "No background jobs"
+	}
+	lines := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		lines = append(lines, formatJobStatus(job))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -5,16 +5,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/logging"
 	"github.com/opencode-ai/opencode/internal/lsp"
 	"github.com/opencode-ai/opencode/internal/lsp/protocol"
 )
 
 type DiagnosticsParams struct {
-	FilePath string `json:"file_path"`
+	FilePath    string `json:"file_path"`
+	Severity    string `json:"severity"`
+	PathGlob    string `json:"path_glob"`
+	Limit       int    `json:"limit"`
+	SummaryOnly bool   `json:"summary_only"`
 }
 type diagnosticsTool struct {
 	lspClients map[string]*lsp.Client
@@ -29,7 +38,9 @@ WHEN TO USE THIS TOOL:
 - Good for getting a quick overview of issues in a file or project
 HOW TO USE:
 - Provide a path to a file to get diagnostics for that file
-- Leave the path empty to get diagnostics for the entire project
+- Leave the path empty to get a report of the diagnostics of the entire project, including files that were never opened when the language server supports it
+- Filter the project report with severity (error, warning, info or hint, showing that severity and above) and path_glob (e.g. "internal/**/*.go")
+- Use summary_only to get only the counts per severity
 - Results are displayed in a structured format with severity levels
 FEATURES:
 - Displays errors, warnings, and hints
@@ -37,11 +48,12 @@ FEATURES:
 - Provides detailed information about each diagnostic
 LIMITATIONS:
 - Results are limited to the diagnostics provided by the LSP clients
+- Servers that only publish diagnostics report the files they have analyzed, usually the open ones
 - May not cover all possible issues in the code
 - Does not provide suggestions for fixing issues
 TIPS:
 - Use in conjunction with other tools for a comprehensive code review
-- Combine with the LSP client for real-time diagnostics
+- Check the project for errors with severity "error" after a large change
 `
 )
 
@@ -58,7 +70,24 @@ func (b *diagnosticsTool) Info() ToolInfo {
 		Parameters: map[string]any{
 			"file_path": map[string]any{
 				"type":        "string",
-				"description": "The path to the file to get diagnostics for (leave w empty for project diagnostics)",
+				"description": "The path to the file to get diagnostics for (leave empty for project diagnostics)",
+			},
+			"severity": map[string]any{
+				"type":        "string",
+				"description": "The minimum severity of the project diagnostics to report: error, warning, info or hint (default: hint)",
+				"enum":        []string{"error", "warning", "info", "hint"},
+			},
+			"path_glob": map[string]any{
+				"type":        "string",
+				"description": "Only report project diagnostics for files matching this glob, relative to the working directory",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "The maximum number of project diagnostics to list (default: 100)",
+			},
+			"summary_only": map[string]any{
+				"type":        "boolean",
+				"description": "Only report the number of project diagnostics per severity",
 			},
 		},
 		Required: []string{},
@@ -77,11 +106,18 @@ func (b *diagnosticsTool) Run(ctx context.Context, call ToolCall) (ToolResponse,
 		return NewTextErrorResponse("no LSP clients available"), nil
 	}
 
-	if params.FilePath != "" {
-		notifyLspOpenFile(ctx, params.FilePath, lsps)
-		waitForLspDiagnostics(ctx, params.FilePath, lsps)
+	if params.FilePath == "" {
+		filter, err := newDiagnosticsFilter(params, config.WorkingDirectory())
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		pullWorkspaceDiagnostics(ctx, lsps)
+		return NewTextResponse(workspaceDiagnosticsReport(lsps, filter)), nil
 	}
 
+	notifyLspOpenFile(ctx, params.FilePath, lsps)
+	waitForLspDiagnostics(ctx, params.FilePath, lsps)
+
 	output := getDiagnostics(params.FilePath, lsps)
 
 	return NewTextResponse(output), nil
@@ -89,6 +125,9 @@ func (b *diagnosticsTool) Run(ctx context.Context, call ToolCall) (ToolResponse,
 
 func notifyLspOpenFile(ctx context.Context, filePath string, lsps map[string]*lsp.Client) {
 	for _, client := range lsps {
+		if !client.HandlesFile(filePath) {
+			continue
+		}
 		err := client.OpenFile(ctx, filePath)
 		if err != nil {
 			continue
@@ -103,7 +142,23 @@ func waitForLspDiagnostics(ctx context.Context, filePath string, lsps map[string
 
 	diagChan := make(chan struct{}, 1)
 
+	waiting := false
 	for _, client := range lsps {
+		if !client.HandlesFile(filePath) {
+			continue
+		}
+
+		// Servers supporting the pull model are asked for the diagnostics directly
+		if pull, _ := client.SupportsPullDiagnostics(); pull {
+			if err := syncLspFile(ctx, client, filePath); err == nil {
+				if err := client.PullDiagnostics(ctx, filePath); err == nil {
+					continue
+				}
+				logging.Debug("Failed to pull diagnostics", "file", filePath, "error", err)
+			}
+		}
+		waiting = true
+
 		originalDiags := make(map[protocol.DocumentUri][]protocol.Diagnostic)
 		maps.Copy(originalDiags, client.GetDiagnostics())
 
@@ -137,6 +192,10 @@ func waitForLspDiagnostics(ctx context.Context, filePath string, lsps map[string
 		}
 	}
 
+	if !waiting {
+		return
+	}
+
 	select {
 	case <-diagChan:
 	case <-time.After(5 * time.Second):
@@ -144,6 +203,15 @@ func waitForLspDiagnostics(ctx context.Context, filePath string, lsps map[string
 	}
 }
 
+// syncLspFile opens the file in the client, or sends its current content
+// when it is already open
+func syncLspFile(ctx context.Context, client *lsp.Client, filePath string) error {
+	if client.IsFileOpen(filePath) {
+		return client.NotifyChange(ctx, filePath)
+	}
+	return client.OpenFile(ctx, filePath)
+}
+
 func hasDiagnosticsChanged(current, original map[protocol.DocumentUri][]protocol.Diagnostic) bool {
 	for uri, diags := range current {
 		origDiags, exists := original[uri]
@@ -158,56 +226,6 @@ func getDiagnostics(filePath string, lsps map[string]*lsp.Client) string {
 	fileDiagnostics := []string{}
 	projectDiagnostics := []string{}
 
-	formatDiagnostic := func(pth string, diagnostic protocol.Diagnostic, source string) string {
-		severity := "Info"
-		switch diagnostic.Severity {
-		case protocol.SeverityError:
-			severity = "Error"
-		case protocol.SeverityWarning:
-			severity = "Warn"
-		case protocol.SeverityHint:
-			severity = "Hint"
-		}
-
-		location := fmt.Sprintf("%s:%d:%d", pth, diagnostic.Range.Start.Line+1, diagnostic.Range.Start.Character+1)
-
-		sourceInfo := ""
-		if diagnostic.Source != "" {
-			sourceInfo = diagnostic.Source
-		} else if source != "" {
-			sourceInfo = source
-		}
-
-		codeInfo := ""
-		if diagnostic.Code != nil {
-			codeInfo = fmt.Sprintf("[%v]", diagnostic.Code)
-		}
-
-		tagsInfo := ""
-		if len(diagnostic.Tags) > 0 {
-			tags := []string{}
-			for _, tag := range diagnostic.Tags {
-				switch tag {
-				case protocol.Unnecessary:
-					tags = append(tags, "unnecessary")
-				case protocol.Deprecated:
-					tags = append(tags, "deprecated")
-				}
-			}
-			if len(tags) > 0 {
-				tagsInfo = fmt.Sprintf(" (%s)", strings.Join(tags, ", "))
-			}
-		}
-
-		return fmt.Sprintf("%s: %s [%s]%s%s %s",
-			severity,
-			location,
-			sourceInfo,
-			codeInfo,
-			tagsInfo,
-			diagnostic.Message)
-	}
-
 	for lspName, client := range lsps {
 		diagnostics := client.GetDiagnostics()
 		if len(diagnostics) > 0 {
@@ -284,6 +302,220 @@ func getDiagnostics(filePath string, lsps map[string]*lsp.Client) string {
 	return output
 }
 
+func formatDiagnostic(pth string, diagnostic protocol.Diagnostic, source string) string {
+	severity := "Info"
+	switch diagnostic.Severity {
+	case protocol.SeverityError:
+		severity = "Error"
+	case protocol.SeverityWarning:
+		severity = "Warn"
+	case protocol.SeverityHint:
+		severity = "Hint"
+	}
+
+	location := fmt.Sprintf("%s:%d:%d", pth, diagnostic.Range.Start.Line+1, diagnostic.Range.Start.Character+1)
+
+	sourceInfo := ""
+	if diagnostic.Source != "" {
+		sourceInfo = diagnostic.Source
+	} else if source != "" {
+		sourceInfo = source
+	}
+
+	codeInfo := ""
+	if diagnostic.Code != nil {
+		codeInfo = fmt.Sprintf("[%v]", diagnostic.Code)
+	}
+
+	tagsInfo := ""
+	if len(diagnostic.Tags) > 0 {
+		tags := []string{}
+		for _, tag := range diagnostic.Tags {
+			switch tag {
+			case protocol.Unnecessary:
+				tags = append(tags, "unnecessary")
+			case protocol.Deprecated:
+				tags = append(tags, "deprecated")
+			}
+		}
+		if len(tags) > 0 {
+			tagsInfo = fmt.Sprintf(" (%s)", strings.Join(tags, ", "))
+		}
+	}
+
+	return fmt.Sprintf("%s: %s [%s]%s%s %s",
+		severity,
+		location,
+		sourceInfo,
+		codeInfo,
+		tagsInfo,
+		diagnostic.Message)
+}
+
+// diagnosticsFilter selects the diagnostics of the project report
+type diagnosticsFilter struct {
+	// severity is the least severe diagnostic reported, severities decrease
+	// from SeverityError to SeverityHint
+	severity protocol.DiagnosticSeverity
+	// pathGlob matches the paths relative to root, or the absolute paths
+	pathGlob    string
+	root        string
+	limit       int
+	summaryOnly bool
+}
+
+func newDiagnosticsFilter(params DiagnosticsParams, root string) (diagnosticsFilter, error) {
+	filter := diagnosticsFilter{
+		severity:    protocol.SeverityHint,
+		pathGlob:    params.PathGlob,
+		root:        root,
+		limit:       params.Limit,
+		summaryOnly: params.SummaryOnly,
+	}
+	switch strings.ToLower(params.Severity) {
+	case "", "hint":
+	case "error":
+		filter.severity = protocol.SeverityError
+	case "warning", "warn":
+		filter.severity = protocol.SeverityWarning
+	case "info", "information":
+		filter.severity = protocol.SeverityInformation
+	default:
+		return filter, fmt.Errorf("invalid severity %q, use error, warning, info or hint", params.Severity)
+	}
+	if filter.pathGlob != "" && !doublestar.ValidatePattern(filter.pathGlob) {
+		return filter, fmt.Errorf("invalid path_glob %q", filter.pathGlob)
+	}
+	if filter.limit <= 0 {
+		filter.limit = 100
+	}
+	return filter, nil
+}
+
+func (f diagnosticsFilter) matches(path string, diagnostic protocol.Diagnostic) bool {
+	if diagnosticSeverity(diagnostic) > f.severity {
+		return false
+	}
+	if f.pathGlob == "" {
+		return true
+	}
+	if rel, err := filepath.Rel(f.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		if ok, _ := doublestar.Match(f.pathGlob, rel); ok {
+			return true
+		}
+	}
+	ok, _ := doublestar.Match(f.pathGlob, path)
+	return ok
+}
+
+// diagnosticSeverity returns the severity of a diagnostic, diagnostics without
+// one are reported as information like in formatDiagnostic
+func diagnosticSeverity(diagnostic protocol.Diagnostic) protocol.DiagnosticSeverity {
+	if diagnostic.Severity == 0 {
+		return protocol.SeverityInformation
+	}
+	return diagnostic.Severity
+}
+
+// pullWorkspaceDiagnostics asks the servers supporting it for the diagnostics
+// of the whole workspace
+func pullWorkspaceDiagnostics(ctx context.Context, lsps map[string]*lsp.Client) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for name, client := range lsps {
+		if _, workspace := client.SupportsPullDiagnostics(); !workspace {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.PullWorkspaceDiagnostics(ctx); err != nil {
+				logging.Debug("Failed to pull workspace diagnostics", "client", name, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// workspaceDiagnosticsReport lists the diagnostics of all files known to the
+// clients, most severe first, followed by the number of diagnostics per severity
+func workspaceDiagnosticsReport(lsps map[string]*lsp.Client, filter diagnosticsFilter) string {
+	type entry struct {
+		severity protocol.DiagnosticSeverity
+		path     string
+		line     uint32
+		text     string
+	}
+
+	var entries []entry
+	counts := make(map[protocol.DiagnosticSeverity]int)
+	files := make(map[string]bool)
+	for lspName, client := range lsps {
+		for uri, diags := range client.GetDiagnostics() {
+			path := uri.Path()
+			for _, diag := range diags {
+				if !filter.matches(path, diag) {
+					continue
+				}
+				severity := diagnosticSeverity(diag)
+				counts[severity]++
+				files[path] = true
+				entries = append(entries, entry{
+					severity: severity,
+					path:     path,
+					line:     diag.Range.Start.Line,
+					text:     formatDiagnostic(path, diag, lspName),
+				})
+			}
+		}
+	}
+
+	if len(entries) == 0 {
+		if filter.pathGlob != "" || filter.severity != protocol.SeverityHint {
+			return "No diagnostics matching the filters"
+		}
+		return "No diagnostics"
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].severity != entries[j].severity {
+			return entries[i].severity < entries[j].severity
+		}
+		if entries[i].path != entries[j].path {
+			return entries[i].path < entries[j].path
+		}
+		if entries[i].line != entries[j].line {
+			return entries[i].line < entries[j].line
+		}
+		return entries[i].text < entries[j].text
+	})
+
+	output := ""
+	if !filter.summaryOnly {
+		output += "<project_diagnostics>\n"
+		for i, e := range entries {
+			if i == filter.limit {
+				output += fmt.Sprintf("... and %d more diagnostics\n", len(entries)-filter.limit)
+				break
+			}
+			output += e.text + "\n"
+		}
+		output += "</project_diagnostics>\n"
+	}
+
+	output += "<diagnostic_summary>\n"
+	output += fmt.Sprintf("Project: %d errors, %d warnings, %d info, %d hints in %d files\n",
+		counts[protocol.SeverityError],
+		counts[protocol.SeverityWarning],
+		counts[protocol.SeverityInformation],
+		counts[protocol.SeverityHint],
+		len(files))
+	output += "</diagnostic_summary>\n"
+	return output
+}
+
 func countSeverity(diagnostics []string, severity string) int {
 	count := 0
 	for _, diag := range diagnostics {
@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnosticsFilter(t *testing.T) {
+	dir := t.TempDir()
+
+	errorDiag := protocol.Diagnostic{Severity: protocol.SeverityError}
+	warnDiag := protocol.Diagnostic{Severity: protocol.SeverityWarning}
+	noSeverity := protocol.Diagnostic{}
+	hintDiag := protocol.Diagnostic{Severity: protocol.SeverityHint}
+	goFile := filepath.Join(dir, "internal", "app", "app.go")
+	tsFile := filepath.Join(dir, "web", "index.ts")
+
+	filter, err := newDiagnosticsFilter(DiagnosticsParams{}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, 100, filter.limit)
+	assert.True(t, filter.matches(goFile, hintDiag))
+
+	filter, err = newDiagnosticsFilter(DiagnosticsParams{Severity: "warning", PathGlob: "internal/**/*.go"}, dir)
+	require.NoError(t, err)
+	assert.True(t, filter.matches(goFile, errorDiag))
+	assert.True(t, filter.matches(goFile, warnDiag))
+	assert.False(t, filter.matches(goFile, noSeverity), "diagnostics without severity are information")
+	assert.False(t, filter.matches(goFile, hintDiag))
+	assert.False(t, filter.matches(tsFile, errorDiag))
+
+	_, err = newDiagnosticsFilter(DiagnosticsParams{Severity: "fatal"}, dir)
+	assert.Error(t, err)
+	_, err = newDiagnosticsFilter(DiagnosticsParams{PathGlob: "internal/[*.go"}, dir)
+	assert.Error(t, err)
+}
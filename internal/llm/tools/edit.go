@@ -218,11 +218,19 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string)
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	recordEditBaseline(ctx, filePath, e.lspClients)
 	err = os.WriteFile(filePath, []byte(content), 0o644)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	result := "File created: " + filePath
+	if formatted, ok := formatWrittenFile(ctx, filePath, "", content, e.lspClients); ok {
+		content = formatted.Content
+		diff, additions, removals = formatted.Diff, formatted.Additions, formatted.Removals
+		result += fmt.Sprintf(" (formatted with %s)", formatted.Formatter)
+	}
+
 	// File can't be in the history so we create a new file history
 	_, err = e.files.Create(ctx, sessionID, filePath, "")
 	if err != nil {
@@ -241,7 +249,7 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string)
 	recordFileRead(filePath)
 
 	return WithResponseMetadata(
-		NewTextResponse("File created: "+filePath),
+		NewTextResponse(result),
 		EditResponseMetadata{
 			Diff:      diff,
 			Additions: additions,
@@ -329,6 +337,7 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	recordEditBaseline(ctx, filePath, e.lspClients)
 	err = os.WriteFile(filePath, []byte(newContent), 0o644)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
@@ -449,11 +458,19 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	recordEditBaseline(ctx, filePath, e.lspClients)
 	err = os.WriteFile(filePath, []byte(newContent), 0o644)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	result := "Content replaced in file: " + filePath
+	if formatted, ok := formatWrittenFile(ctx, filePath, oldContent, newContent, e.lspClients); ok {
+		newContent = formatted.Content
+		diff, additions, removals = formatted.Diff, formatted.Additions, formatted.Removals
+		result += fmt.Sprintf(" (formatted with %s)", formatted.Formatter)
+	}
+
 	// Check if file exists in history
 	file, err := e.files.GetByPathAndSession(ctx, filePath, sessionID)
 	if err != nil {
@@ -480,7 +497,7 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 	recordFileRead(filePath)
 
 	return WithResponseMetadata(
-		NewTextResponse("Content replaced in file: "+filePath),
+		NewTextResponse(result),
 		EditResponseMetadata{
 			Diff:      diff,
 			Additions: additions,
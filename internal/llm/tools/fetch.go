@@ -3,15 +3,20 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/logging"
 	"github.com/opencode-ai/opencode/internal/permission"
 )
 
@@ -19,12 +24,14 @@ type FetchParams struct {
 	URL     string `json:"url"`
 	Format  string `json:"format"`
 	Timeout int    `json:"timeout,omitempty"`
+	Offset  int    `json:"offset,omitempty"`
 }
 
 type FetchPermissionsParams struct {
 	URL     string `json:"url"`
 	Format  string `json:"format"`
 	Timeout int    `json:"timeout,omitempty"`
+	Offset  int    `json:"offset,omitempty"`
 }
 
 type fetchTool struct {
@@ -45,9 +52,11 @@ HOW TO USE:
 - Provide the URL to fetch content from
 - Specify the desired output format (text, markdown, or html)
 - Optionally set a timeout for the request
+- When the content is longer than 50000 bytes, only a part of it is returned, with the offset to read the next part from
 
 FEATURES:
 - Supports three output formats: text, markdown, and html
+- The text and markdown formats only keep the main content of HTML pages, without navigation, sidebars and footers
 - Automatically handles HTTP redirects
 - Sets reasonable timeouts to prevent hanging
 - Validates input parameters before making requests
@@ -55,14 +64,18 @@ FEATURES:
 LIMITATIONS:
 - Maximum response size is 5MB
 - Only supports HTTP and HTTPS protocols
-- Cannot handle authentication or cookies
+- Cannot handle authentication or cookies, except for the headers configured by the user
+- The user may restrict the domains that can be fetched
 - Some websites may block automated requests
 
 TIPS:
 - Use text format for plain text content or simple API responses
 - Use markdown format for content that should be rendered with formatting
-- Use html format when you need the raw HTML structure
+- Use html format when you need the raw HTML structure, or when the main content of a page was missed
 - Set appropriate timeouts for potentially slow websites`
+
+	// maxFetchPageLength is the length of the parts of long contents
+	maxFetchPageLength = 50000
 )
 
 func NewFetchTool(permissions permission.Service) BaseTool {
@@ -92,6 +105,10 @@ func (t *fetchTool) Info() ToolInfo {
 				"type":        "number",
 				"description": "Optional timeout in seconds (max 120)",
 			},
+			"offset": map[string]any{
+				"type":        "number",
+				"description": "Optional offset to start reading the content from, for contents returned in parts",
+			},
 		},
 		Required: []string{"url", "format"},
 	}
@@ -116,6 +133,19 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return NewTextErrorResponse("URL must start with http:// or https://"), nil
 	}
 
+	if params.Offset < 0 {
+		return NewTextErrorResponse("Offset must not be negative"), nil
+	}
+
+	u, err := url.Parse(params.URL)
+	if err != nil {
+		return NewTextErrorResponse("Invalid URL: " + err.Error()), nil
+	}
+	cfg := config.Get().Fetch
+	if err := checkFetchDomain(cfg, u.Hostname()); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
@@ -136,15 +166,14 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
-	client := t.client
+	client := *t.client
+	client.CheckRedirect = fetchRedirectPolicy(cfg)
 	if params.Timeout > 0 {
 		maxTimeout := 120 // 2 minutes
 		if params.Timeout > maxTimeout {
 			params.Timeout = maxTimeout
 		}
-		client = &http.Client{
-			Timeout: time.Duration(params.Timeout) * time.Second,
-		}
+		client.Timeout = time.Duration(params.Timeout) * time.Second
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", params.URL, nil)
@@ -153,6 +182,17 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	}
 
 	req.Header.Set("User-Agent", "opencode/1.0")
+	setFetchHeaders(req, cfg.Headers)
+
+	var cache *fetchCache
+	var cached fetchCacheEntry
+	var isCached bool
+	if cfg.Cache {
+		cache = newFetchCache(filepath.Join(config.Get().Data.Directory, "fetch"))
+		if cached, isCached = cache.get(params.URL); isCached {
+			cached.setValidators(req)
+		}
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -160,46 +200,150 @@ func (t *fetchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	var body []byte
+	var contentType string
+	switch {
+	case resp.StatusCode == http.StatusNotModified && isCached:
+		body = cached.Body
+		contentType = cached.ContentType
+	case resp.StatusCode != http.StatusOK:
 		return NewTextErrorResponse(fmt.Sprintf("Request failed with status code: %d", resp.StatusCode)), nil
+	default:
+		maxSize := int64(5 * 1024 * 1024) // 5MB
+		body, err = io.ReadAll(io.LimitReader(resp.Body, maxSize))
+		if err != nil {
+			return NewTextErrorResponse("Failed to read response body: " + err.Error()), nil
+		}
+		contentType = resp.Header.Get("Content-Type")
+		if cache != nil {
+			if err := cache.put(params.URL, resp.Header, body); err != nil {
+				logging.Warn("Failed to cache the fetched content", "url", params.URL, "error", err)
+			}
+		}
 	}
 
-	maxSize := int64(5 * 1024 * 1024) // 5MB
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
-	if err != nil {
-		return NewTextErrorResponse("Failed to read response body: " + err.Error()), nil
+	content := string(body)
+	isHTML := strings.Contains(contentType, "text/html")
+	if isHTML && format != "html" {
+		content, err = extractMainContent(content)
+		if err != nil {
+			return NewTextErrorResponse("Failed to parse HTML: " + err.Error()), nil
+		}
 	}
 
-	content := string(body)
-	contentType := resp.Header.Get("Content-Type")
-
-	switch format {
-	case "text":
-		if strings.Contains(contentType, "text/html") {
-			text, err := extractTextFromHTML(content)
-			if err != nil {
-				return NewTextErrorResponse("Failed to extract text from HTML: " + err.Error()), nil
-			}
-			return NewTextResponse(text), nil
+	switch {
+	case format == "text" && isHTML:
+		content, err = extractTextFromHTML(content)
+		if err != nil {
+			return NewTextErrorResponse("Failed to extract text from HTML: " + err.Error()), nil
 		}
-		return NewTextResponse(content), nil
+	case format == "markdown" && isHTML:
+		content, err = convertHTMLToMarkdown(content)
+		if err != nil {
+			return NewTextErrorResponse("Failed to convert HTML to Markdown: " + err.Error()), nil
+		}
+	}
 
-	case "markdown":
-		if strings.Contains(contentType, "text/html") {
-			markdown, err := convertHTMLToMarkdown(content)
-			if err != nil {
-				return NewTextErrorResponse("Failed to convert HTML to Markdown: " + err.Error()), nil
-			}
-			return NewTextResponse(markdown), nil
+	page, err := paginateContent(content, params.Offset)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if format == "markdown" && !isHTML {
+		page.content = "```\n" + page.content + "\n```"
+	}
+	return NewTextResponse(page.content + page.note), nil
+}
+
+// fetchPage is a part of a long content
+type fetchPage struct {
+	content string
+	// note tells how to read the rest of the content
+	note string
+}
+
+// paginateContent returns the part of the content starting at offset
+func paginateContent(content string, offset int) (fetchPage, error) {
+	if offset > 0 && offset >= len(content) {
+		return fetchPage{}, fmt.Errorf("offset %d is beyond the end of the content (%d bytes)", offset, len(content))
+	}
+	// Don't split UTF-8 sequences
+	for offset > 0 && offset < len(content) && !utf8.RuneStart(content[offset]) {
+		offset++
+	}
+	end := min(offset+maxFetchPageLength, len(content))
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end--
+	}
+
+	page := fetchPage{content: content[offset:end]}
+	if offset > 0 || end < len(content) {
+		page.note = fmt.Sprintf("\n\n(Showing bytes %d to %d of %d", offset, end, len(content))
+		if end < len(content) {
+			page.note += fmt.Sprintf(". Fetch the URL again with offset %d to read the rest", end)
 		}
+		page.note += ")"
+	}
+	return page, nil
+}
 
-		return NewTextResponse("```\n" + content + "\n```"), nil
+// domainMatches reports whether the host is the domain or one of its
+// subdomains
+func domainMatches(host, domain string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domain = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(domain, "*"), "."))
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
 
-	case "html":
-		return NewTextResponse(content), nil
+// checkFetchDomain applies the domain lists of the configuration to a host
+func checkFetchDomain(cfg config.FetchConfig, host string) error {
+	for _, domain := range cfg.DeniedDomains {
+		if domainMatches(host, domain) {
+			return fmt.Errorf("fetching from %s is denied by the configuration", host)
+		}
+	}
+	if len(cfg.AllowedDomains) == 0 {
+		return nil
+	}
+	for _, domain := range cfg.AllowedDomains {
+		if domainMatches(host, domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("fetching from %s is not allowed by the configuration, the allowed domains are: %s", host, strings.Join(cfg.AllowedDomains, ", "))
+}
 
-	default:
-		return NewTextResponse(content), nil
+// setFetchHeaders sets the headers configured for the host of the request,
+// and removes the ones of the other domains, which redirects forward
+func setFetchHeaders(req *http.Request, headers []config.FetchHeaders) {
+	host := req.URL.Hostname()
+	for _, h := range headers {
+		if !domainMatches(host, h.Domain) {
+			for name := range h.Headers {
+				req.Header.Del(name)
+			}
+		}
+	}
+	for _, h := range headers {
+		if domainMatches(host, h.Domain) {
+			for name, value := range h.Headers {
+				req.Header.Set(name, value)
+			}
+		}
+	}
+}
+
+// fetchRedirectPolicy applies the domain lists and the headers of the
+// configuration to the redirects
+func fetchRedirectPolicy(cfg config.FetchConfig) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if err := checkFetchDomain(cfg, req.URL.Hostname()); err != nil {
+			return fmt.Errorf("redirected to %s: %w", req.URL, err)
+		}
+		setFetchHeaders(req, cfg.Headers)
+		return nil
 	}
 }
 
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fetchCache keeps the fetched pages on disk, with the validators sent back
+// to the server to check that they didn't change.
+type fetchCache struct {
+	dir string
+}
+
+type fetchCacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	ContentType  string `json:"content_type"`
+	Body         []byte `json:"body"`
+}
+
+func newFetchCache(dir string) *fetchCache {
+	return &fetchCache{dir: dir}
+}
+
+func (c *fetchCache) path(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// get returns the cached page of the URL, if any
+func (c *fetchCache) get(url string) (fetchCacheEntry, bool) {
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return fetchCacheEntry{}, false
+	}
+	var entry fetchCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return fetchCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// put caches a response, when it can be revalidated and may be stored
+func (c *fetchCache) put(url string, header http.Header, body []byte) error {
+	entry := fetchCacheEntry{
+		URL:          url,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		ContentType:  header.Get("Content-Type"),
+		Body:         body,
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return nil
+	}
+	if strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-store") {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file so that concurrent fetches don't read a
+	// partial entry
+	tmp, err := os.CreateTemp(c.dir, "entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(url))
+}
+
+// setValidators makes the request conditional on the cached page having
+// changed
+func (e fetchCacheEntry) setValidators(req *http.Request) {
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
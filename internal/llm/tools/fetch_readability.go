@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// The main content of a page is found the way readability tools do: the
+// clutter such as navigation, sidebars and footers is removed, and the
+// element holding most of the paragraphs is kept.
+
+// hiddenSelector matches the elements that are never displayed
+const hiddenSelector = "script, style, noscript, template"
+
+// clutterSelector matches the elements that are not part of the content.
+// Forms are kept, as some sites wrap their whole pages in one.
+const clutterSelector = hiddenSelector + ", svg, canvas, iframe, button, input, select, nav, aside, dialog, " +
+	"[role=navigation], [role=banner], [role=contentinfo], [role=complementary], [role=dialog], [aria-hidden=true], [hidden]"
+
+var (
+	// unlikelyCandidate matches the classes and ids of clutter...
+	unlikelyCandidate = regexp.MustCompile(`(?i)\b(nav|navbar|menu|sidebar|footer|header|masthead|breadcrumbs?|cookies?|consent|banner|ads?|advert\w*|sponsor\w*|social|share|sharing|comments?|related|popup|modal|subscribe|newsletter|pagination|skip)\b`)
+	// ...unless they also match a content class
+	maybeCandidate = regexp.MustCompile(`(?i)\b(article|body|column|main|content|post|entry)\b`)
+)
+
+// minContentLength is the length of text under which the extracted content
+// is considered missed, and the whole page is used
+const minContentLength = 200
+
+// extractMainContent returns the HTML of the main content of a page, or of
+// its whole body when no main content is found
+func extractMainContent(page string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return "", err
+	}
+	body := doc.Find("body")
+	// The whole page is used when no main content is found
+	whole := body.Clone()
+	whole.Find(hiddenSelector).Remove()
+
+	body.Find(clutterSelector).Remove()
+	// Headers and footers of articles hold their titles and dates
+	body.Find("header, footer").Not("article header, article footer, main header, main footer").Remove()
+	body.Find("*").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("main, article, pre, code, table, thead, tbody, tr, td, th") {
+			return
+		}
+		attributes := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if unlikelyCandidate.MatchString(attributes) && !maybeCandidate.MatchString(attributes) {
+			s.Remove()
+		}
+	})
+
+	content := mainContent(body)
+	if textLength(content) < minContentLength {
+		content = whole
+	}
+	return content.Html()
+}
+
+// mainContent returns the element marked as the main content of the page,
+// or the one with the best paragraphs
+func mainContent(body *goquery.Selection) *goquery.Selection {
+	if main := body.Find("main, [role=main]").First(); textLength(main) >= minContentLength {
+		return main
+	}
+	var best *goquery.Selection
+	bestLength := 0
+	body.Find("article").Each(func(_ int, s *goquery.Selection) {
+		if length := textLength(s); length > bestLength {
+			best, bestLength = s, length
+		}
+	})
+	if bestLength >= minContentLength {
+		return best
+	}
+
+	// Paragraphs score their parent and, half as much, their grandparent
+	scores := map[*html.Node]float64{}
+	var candidates []*goquery.Selection
+	body.Find("p, pre, td, blockquote").Each(func(_ int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		for i, ancestor := range []*goquery.Selection{s.Parent(), s.Parent().Parent()} {
+			if ancestor.Length() == 0 {
+				break
+			}
+			node := ancestor.Get(0)
+			if _, ok := scores[node]; !ok {
+				candidates = append(candidates, ancestor)
+			}
+			scores[node] += score / float64(i+1)
+		}
+	})
+
+	bestScore := 0.0
+	for _, candidate := range candidates {
+		// Lists of links score less than text
+		score := scores[candidate.Get(0)] * (1 - linkDensity(candidate))
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	if best == nil {
+		return body
+	}
+	return best
+}
+
+func textLength(s *goquery.Selection) int {
+	return len(strings.Join(strings.Fields(s.Text()), " "))
+}
+
+// linkDensity is the share of the text of an element that is in links
+func linkDensity(s *goquery.Selection) float64 {
+	length := textLength(s)
+	if length == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += textLength(a)
+	})
+	return float64(links) / float64(length)
+}
@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractMainContent(t *testing.T) {
+	paragraph := "<p>The context package carries deadlines, cancellation signals, and other request-scoped values across API boundaries, and between processes.</p>"
+	page := `<html><head><title>context</title><script>track()</script></head><body>
+<header><a href="/">Home</a> <a href="/docs">Docs</a></header>
+<nav><ul><li><a href="/a">Link A</a></li><li><a href="/b">Link B</a></li></ul></nav>
+<div class="cookie-banner">We use cookies</div>
+<div id="sidebar"><a href="/c">Related page</a></div>
+<div class="page-content">
+  <h1>Package context</h1>` + strings.Repeat(paragraph, 3) + `
+  <pre>ctx, cancel := context.WithCancel(ctx)</pre>
+</div>
+<footer>Copyright</footer>
+</body></html>`
+
+	content, err := extractMainContent(page)
+	require.NoError(t, err)
+	assert.Contains(t, content, "Package context")
+	assert.Contains(t, content, "request-scoped values")
+	assert.Contains(t, content, "context.WithCancel")
+	for _, clutter := range []string{"track()", "Home", "Link A", "cookies", "Related page", "Copyright"} {
+		assert.NotContains(t, content, clutter)
+	}
+
+	// The main element is used as is, with its header
+	page = `<html><body><nav>Menu</nav><main><header>Title</header>` + strings.Repeat(paragraph, 2) + `</main></body></html>`
+	content, err = extractMainContent(page)
+	require.NoError(t, err)
+	assert.Contains(t, content, "Title")
+	assert.NotContains(t, content, "Menu")
+
+	// Pages wrapped in a form keep their content
+	page = `<html><body><form action="/page" method="post"><div class="content">` + strings.Repeat(paragraph, 4) + `</div></form></body></html>`
+	content, err = extractMainContent(page)
+	require.NoError(t, err)
+	assert.Contains(t, content, "request-scoped values")
+
+	// Short pages are kept whole, without their clutter removed
+	content, err = extractMainContent(`<html><body><nav>Docs</nav><form><div><span>Hello</span></div></form><script>track()</script></body></html>`)
+	require.NoError(t, err)
+	assert.Contains(t, content, "Docs")
+	assert.Contains(t, content, "Hello")
+	assert.NotContains(t, content, "track()")
+
+	// Short pages are kept whole
+	content, err = extractMainContent(`<html><body><div><span>Hello</span></div></body></html>`)
+	require.NoError(t, err)
+	assert.Contains(t, content, "Hello")
+}
+
+func TestPaginateContent(t *testing.T) {
+	page, err := paginateContent("short", 0)
+	require.NoError(t, err)
+	assert.Equal(t, fetchPage{content: "short"}, page)
+
+	content := strings.Repeat("a", maxFetchPageLength-1) + "é" + strings.Repeat("b", 10)
+	page, err = paginateContent(content, 0)
+	require.NoError(t, err)
+	// The page ends before the two bytes of é
+	assert.Len(t, page.content, maxFetchPageLength-1)
+	assert.Contains(t, page.note, fmt.Sprintf("offset %d", maxFetchPageLength-1))
+
+	page, err = paginateContent(content, maxFetchPageLength-1)
+	require.NoError(t, err)
+	assert.Equal(t, "é"+strings.Repeat("b", 10), page.content)
+	assert.NotContains(t, page.note, "offset")
+
+	_, err = paginateContent(content, len(content))
+	assert.Error(t, err)
+}
+
+func TestFetchDomains(t *testing.T) {
+	cfg := config.FetchConfig{
+		AllowedDomains: []string{"go.dev", "docs.internal"},
+		DeniedDomains:  []string{"play.go.dev"},
+		Headers: []config.FetchHeaders{
+			{Domain: "docs.internal", Headers: map[string]string{"Authorization": "Bearer token"}},
+		},
+	}
+	assert.NoError(t, checkFetchDomain(cfg, "go.dev"))
+	assert.NoError(t, checkFetchDomain(cfg, "pkg.go.dev"))
+	assert.Error(t, checkFetchDomain(cfg, "play.go.dev"))
+	assert.Error(t, checkFetchDomain(cfg, "notgo.dev"))
+	assert.Error(t, checkFetchDomain(cfg, "example.com"))
+	assert.NoError(t, checkFetchDomain(config.FetchConfig{}, "example.com"))
+
+	req := httptest.NewRequest("GET", "https://api.docs.internal/page", nil)
+	setFetchHeaders(req, cfg.Headers)
+	assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+
+	// The headers are not forwarded to other domains
+	policy := fetchRedirectPolicy(cfg)
+	redirect := httptest.NewRequest("GET", "https://go.dev/page", nil)
+	redirect.Header = req.Header.Clone()
+	require.NoError(t, policy(redirect, []*http.Request{req}))
+	assert.Empty(t, redirect.Header.Get("Authorization"))
+	assert.Error(t, policy(httptest.NewRequest("GET", "https://example.com", nil), []*http.Request{req}))
+}
+
+func TestFetchCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	cache := newFetchCache(t.TempDir())
+	_, ok := cache.get(server.URL)
+	assert.False(t, ok)
+
+	resp, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.NoError(t, cache.put(server.URL, resp.Header, []byte("content")))
+
+	entry, ok := cache.get(server.URL)
+	require.True(t, ok)
+	assert.Equal(t, []byte("content"), entry.Body)
+	assert.Equal(t, "text/plain", entry.ContentType)
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	require.NoError(t, err)
+	entry.setValidators(req)
+	resp, err = server.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	// Responses without validators or marked no-store are not cached
+	require.NoError(t, cache.put("https://example.com/a", http.Header{}, []byte("a")))
+	_, ok = cache.get("https://example.com/a")
+	assert.False(t, ok)
+	require.NoError(t, cache.put("https://example.com/b", http.Header{"Etag": {`"b"`}, "Cache-Control": {"no-store"}}, []byte("b")))
+	_, ok = cache.get("https://example.com/b")
+	assert.False(t, ok)
+}
@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/diff"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+	"github.com/opencode-ai/opencode/internal/lsp/util"
+)
+
+const formatTimeout = 10 * time.Second
+
+// formattedFile is the result of formatting a file after a tool wrote it
+type formattedFile struct {
+	Content   string
+	Formatter string
+	Diff      string
+	Additions int
+	Removals  int
+}
+
+// formatWrittenFile formats a file the agent just wrote with newContent. An
+// external formatter configured for the file extension takes precedence over
+// the language servers. When formatting changes the content, the file is
+// rewritten and the diff against oldContent is recomputed. Formatting failures
+// are logged and leave the file as written.
+func formatWrittenFile(ctx context.Context, path, oldContent, newContent string, lsps map[string]*lsp.Client) (formattedFile, bool) {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Format.Enabled {
+		return formattedFile{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, formatTimeout)
+	defer cancel()
+
+	var formatted, formatter string
+	var err error
+	if name, fc, ok := formatterFor(cfg.Format.Formatters, path); ok {
+		formatter = name
+		formatted, err = runFormatter(ctx, fc, path, newContent)
+	} else if cfg.Format.LSP && len(lsps) > 0 {
+		formatted, formatter, err = formatWithLSP(ctx, path, newContent, lsps)
+	} else {
+		return formattedFile{}, false
+	}
+	if err != nil {
+		logging.Debug("Failed to format file", "path", path, "formatter", formatter, "error", err)
+		return formattedFile{}, false
+	}
+	if formatted == "" || formatted == newContent {
+		return formattedFile{}, false
+	}
+
+	if err := os.WriteFile(path, []byte(formatted), 0o644); err != nil {
+		logging.Debug("Failed to write formatted file", "path", path, "error", err)
+		return formattedFile{}, false
+	}
+	for _, client := range lsps {
+		if client.IsFileOpen(path) {
+			_ = client.NotifyChange(ctx, path)
+		}
+	}
+
+	fileDiff, additions, removals := diff.GenerateDiff(oldContent, formatted, path)
+	return formattedFile{
+		Content:   formatted,
+		Formatter: formatter,
+		Diff:      fileDiff,
+		Additions: additions,
+		Removals:  removals,
+	}, true
+}
+
+// formatterFor returns the first enabled formatter, by name, configured for the
+// extension of path
+func formatterFor(formatters map[string]config.FormatterConfig, path string) (string, config.FormatterConfig, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return "", config.FormatterConfig{}, false
+	}
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fc := formatters[name]
+		if fc.Disabled || fc.Command == "" {
+			continue
+		}
+		for _, e := range fc.Extensions {
+			if !strings.HasPrefix(e, ".") {
+				e = "." + e
+			}
+			if strings.EqualFold(e, ext) {
+				return name, fc, true
+			}
+		}
+	}
+	return "", config.FormatterConfig{}, false
+}
+
+// runFormatter pipes content through an external formatter
+func runFormatter(ctx context.Context, fc config.FormatterConfig, path, content string) (string, error) {
+	args := make([]string, len(fc.Args))
+	for i, arg := range fc.Args {
+		args[i] = strings.ReplaceAll(arg, "$FILE", path)
+	}
+
+	cmd := exec.CommandContext(ctx, fc.Command, args...)
+	cmd.Dir = config.WorkingDirectory()
+	cmd.Stdin = strings.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w: %s", fc.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// formatWithLSP asks the language servers to format the file and applies the
+// edits of the first one that answers
+func formatWithLSP(ctx context.Context, path, content string, lsps map[string]*lsp.Client) (string, string, error) {
+	options := protocol.FormattingOptions{
+		TabSize:            4,
+		InsertSpaces:       !usesTabIndentation(content),
+		InsertFinalNewline: strings.HasSuffix(content, "\n"),
+	}
+
+	var lastErr error
+	for _, name := range sortedClientNames(lsps) {
+		client := lsps[name]
+		if !client.HandlesFile(path) {
+			continue
+		}
+		if client.IsFileOpen(path) {
+			if err := client.NotifyChange(ctx, path); err != nil {
+				lastErr = err
+				continue
+			}
+		} else if err := client.OpenFile(ctx, path); err != nil {
+			lastErr = err
+			continue
+		}
+
+		edits, err := client.Formatting(ctx, protocol.DocumentFormattingParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentUri("file://" + path)},
+			Options:      options,
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(edits) == 0 {
+			return content, name, nil
+		}
+		formatted, err := util.ApplyTextEditsToContent([]byte(content), edits)
+		if err != nil {
+			return "", name, err
+		}
+		return formatted, name, nil
+	}
+	return "", "lsp", lastErr
+}
+
+// usesTabIndentation reports whether indented lines in content mostly start
+// with a tab
+func usesTabIndentation(content string) bool {
+	tabs, spaces := 0, 0
+	for line := range strings.SplitSeq(content, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			tabs++
+		case strings.HasPrefix(line, " "):
+			spaces++
+		}
+	}
+	return tabs > spaces
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatterFor(t *testing.T) {
+	formatters := map[string]config.FormatterConfig{
+		"gofmt":    {Command: "gofmt", Extensions: []string{".go"}},
+		"prettier": {Command: "prettier", Args: []string{"--stdin-filepath", "$FILE"}, Extensions: []string{"ts", ".TSX"}},
+		"ruff":     {Command: "ruff", Extensions: []string{".py"}, Disabled: true},
+	}
+
+	name, _, ok := formatterFor(formatters, "/src/main.go")
+	assert.True(t, ok)
+	assert.Equal(t, "gofmt", name)
+
+	name, _, ok = formatterFor(formatters, "/src/app.tsx")
+	assert.True(t, ok)
+	assert.Equal(t, "prettier", name)
+
+	_, _, ok = formatterFor(formatters, "/src/app.py")
+	assert.False(t, ok, "disabled formatters are skipped")
+
+	_, _, ok = formatterFor(formatters, "/src/Makefile")
+	assert.False(t, ok)
+}
+
+func TestUsesTabIndentation(t *testing.T) {
+	assert.True(t, usesTabIndentation("func a() {\n\treturn\n}\n"))
+	assert.False(t, usesTabIndentation("def a():\n    return\n"))
+	assert.False(t, usesTabIndentation("a\nb\n"))
+}
+
+func TestFormatWrittenFile(t *testing.T) {
+	cfg := config.Get()
+	originalFormat := cfg.Format
+	t.Cleanup(func() { cfg.Format = originalFormat })
+
+	oldContent := "package main\n\nfunc a() int {\n\treturn 0\n}\n"
+	newContent := "package main\n\nfunc a() int {\n    return 1\n}\n"
+	want := "package main\n\nfunc a() int {\n\treturn 1\n}\n"
+
+	tests := []struct {
+		name          string
+		format        config.FormatConfig
+		lsp           bool
+		wantFormatter string
+	}{
+		{
+			name: "formatter",
+			format: config.FormatConfig{Enabled: true, Formatters: map[string]config.FormatterConfig{
+				"tabs": {Command: "sed", Args: []string{"s/^    /\t/"}, Extensions: []string{".go"}},
+			}},
+			wantFormatter: "tabs",
+		},
+		{
+			name:          "language server",
+			format:        config.FormatConfig{Enabled: true, LSP: true},
+			lsp:           true,
+			wantFormatter: "test",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var lsps map[string]*lsp.Client
+			if tt.lsp {
+				lsps = newTestLSPClient(t, dir)
+			}
+			path := filepath.Join(dir, "main.go")
+			require.NoError(t, os.WriteFile(path, []byte(newContent), 0o644))
+
+			cfg.Format = config.FormatConfig{}
+			_, ok := formatWrittenFile(context.Background(), path, oldContent, newContent, lsps)
+			assert.False(t, ok, "formatting is disabled by default")
+
+			cfg.Format = tt.format
+			formatted, ok := formatWrittenFile(context.Background(), path, oldContent, newContent, lsps)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantFormatter, formatted.Formatter)
+			assert.Equal(t, want, formatted.Content)
+			written, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, want, string(written))
+
+			// The diff is the one of the formatted content
+			assert.Contains(t, formatted.Diff, "-\treturn 0\n+\treturn 1\n")
+			assert.NotContains(t, formatted.Diff, "    return 1")
+			assert.Equal(t, 1, formatted.Additions)
+			assert.Equal(t, 1, formatted.Removals)
+
+			// Content that is already formatted is left alone
+			_, ok = formatWrittenFile(context.Background(), path, oldContent, want, lsps)
+			assert.False(t, ok)
+		})
+	}
+}
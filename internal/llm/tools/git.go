@@ -0,0 +1,474 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/git"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type GitDiffParams struct {
+	Staged bool   `json:"staged"`
+	Ref    string `json:"ref"`
+	Path   string `json:"path"`
+}
+
+type GitLogParams struct {
+	Limit int    `json:"limit"`
+	Ref   string `json:"ref"`
+	Path  string `json:"path"`
+}
+
+type GitCommitParams struct {
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+type GitCommitPermissionsParams struct {
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+	Stat    string   `json:"stat"`
+}
+
+type GitBranchParams struct {
+	Name       string `json:"name"`
+	StartPoint string `json:"start_point"`
+	Checkout   bool   `json:"checkout"`
+}
+
+type GitBranchPermissionsParams struct {
+	Name       string `json:"name"`
+	StartPoint string `json:"start_point"`
+	Checkout   bool   `json:"checkout"`
+}
+
+type gitStatusTool struct{}
+
+type gitDiffTool struct{}
+
+type gitLogTool struct{}
+
+type gitCommitTool struct {
+	permissions permission.Service
+}
+
+type gitBranchTool struct {
+	permissions permission.Service
+}
+
+const (
+	GitStatusToolName = "git_status"
+	GitDiffToolName   = "git_diff"
+	GitLogToolName    = "git_log"
+	GitCommitToolName = "git_commit"
+	GitBranchToolName = "git_branch"
+
+	DefaultGitLogLimit = 10
+	MaxGitLogLimit     = 100
+)
+
+const gitStatusDescription = `Shows the status of the git repository in the working directory.
+
+WHEN TO USE THIS TOOL:
+- Find out which files are staged, modified or untracked
+- Check the current branch and how far it is ahead of or behind its upstream
+
+HOW TO USE:
+- No parameters are needed
+- Each file is shown with a two letter status code, the first for the staged and the second for the unstaged state (M modified, A added, D deleted, R renamed, ?? untracked)
+
+LIMITATIONS:
+- Only works inside a git repository`
+
+const gitDiffDescription = `Shows changes in the git repository in the working directory as a unified diff.
+
+WHEN TO USE THIS TOOL:
+- Review unstaged changes before staging or committing them
+- Review what is staged for the next commit
+- Compare the working tree with another commit or branch
+
+HOW TO USE:
+- By default shows unstaged changes of tracked files
+- Set staged to true to show the changes staged for the next commit
+- Set ref to compare against a commit, branch or tag instead (e.g. "HEAD", "main", "HEAD~3")
+- Optionally limit the diff to a file or directory with path
+
+LIMITATIONS:
+- Untracked files are not included, use git_status to find them
+- Output longer than 30000 characters is truncated`
+
+const gitLogDescription = `Shows the commit history of the git repository in the working directory.
+
+WHEN TO USE THIS TOOL:
+- Find recent commits and their authors
+- See the history of a specific file or directory
+- Follow the repository's commit message conventions when committing
+
+HOW TO USE:
+- Optionally set limit to the number of commits to show (default 10, max 100)
+- Optionally set ref to start from another branch or commit
+- Optionally set path to only show commits touching that file or directory`
+
+const gitCommitDescription = `Creates a git commit in the repository in the working directory.
+
+WHEN TO USE THIS TOOL:
+- Only when the user explicitly asks you to commit changes
+
+HOW TO USE:
+- Provide a concise commit message that follows the repository's conventions, check git_log first
+- Provide files to commit exactly those files, untracked files are added automatically
+- Omit files to commit what is currently staged
+- The user is asked to approve every commit
+
+LIMITATIONS:
+- Never amends commits, rewrites history or pushes
+- Commit hooks run as usual, if a hook fails the commit is not created`
+
+const gitBranchDescription = `Lists or creates branches in the git repository in the working directory.
+
+WHEN TO USE THIS TOOL:
+- List the local branches by omitting name
+- Create a branch for new work when the user asks for it
+
+HOW TO USE:
+- Provide name to create a branch, optionally starting from start_point (defaults to HEAD)
+- Set checkout to true to switch to the new branch, uncommitted changes are kept
+- The user is asked to approve creating branches
+
+LIMITATIONS:
+- Never deletes, renames or force-updates existing branches`
+
+func gitRun(ctx context.Context, args ...string) (string, error) {
+	return git.Run(ctx, config.WorkingDirectory(), args...)
+}
+
+// gitErrorResponse turns errors reported by git into responses the model can act on.
+func gitErrorResponse(err error) (ToolResponse, error) {
+	if errors.Is(err, git.ErrNotRepository) {
+		return NewTextErrorResponse("the working directory is not a git repository"), nil
+	}
+	return NewTextErrorResponse(err.Error()), nil
+}
+
+func NewGitStatusTool() BaseTool {
+	return &gitStatusTool{}
+}
+
+func (g *gitStatusTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        GitStatusToolName,
+		Description: gitStatusDescription,
+		Parameters:  map[string]any{},
+		Required:    []string{},
+	}
+}
+
+func (g *gitStatusTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	out, err := gitRun(ctx, "status", "--short", "--branch")
+	if err != nil {
+		return gitErrorResponse(err)
+	}
+	return NewTextResponse(truncateOutput(out)), nil
+}
+
+func NewGitDiffTool() BaseTool {
+	return &gitDiffTool{}
+}
+
+func (g *gitDiffTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        GitDiffToolName,
+		Description: gitDiffDescription,
+		Parameters: map[string]any{
+			"staged": map[string]any{
+				"type":        "boolean",
+				"description": "Show the changes staged for the next commit instead of unstaged changes",
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Optional commit, branch or tag to compare against",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional file or directory to limit the diff to",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (g *gitDiffTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params GitDiffParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if strings.HasPrefix(params.Ref, "-") {
+		return NewTextErrorResponse(fmt.Sprintf("invalid ref: %s", params.Ref)), nil
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if params.Staged {
+		args = append(args, "--cached")
+	}
+	if params.Ref != "" {
+		args = append(args, params.Ref)
+	}
+	if params.Path != "" {
+		args = append(args, "--", params.Path)
+	}
+	out, err := gitRun(ctx, args...)
+	if err != nil {
+		return gitErrorResponse(err)
+	}
+	if out == "" {
+		return NewTextResponse("No changes"), nil
+	}
+	return NewTextResponse(truncateOutput(out)), nil
+}
+
+func NewGitLogTool() BaseTool {
+	return &gitLogTool{}
+}
+
+func (g *gitLogTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        GitLogToolName,
+		Description: gitLogDescription,
+		Parameters: map[string]any{
+			"limit": map[string]any{
+				"type":        "number",
+				"description": "The number of commits to show (default 10, max 100)",
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Optional branch or commit to start from",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional file or directory to show the history of",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (g *gitLogTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params GitLogParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if strings.HasPrefix(params.Ref, "-") {
+		return NewTextErrorResponse(fmt.Sprintf("invalid ref: %s", params.Ref)), nil
+	}
+	if params.Limit <= 0 {
+		params.Limit = DefaultGitLogLimit
+	} else if params.Limit > MaxGitLogLimit {
+		params.Limit = MaxGitLogLimit
+	}
+
+	args := []string{"log", "--no-color", fmt.Sprintf("--max-count=%d", params.Limit), "--date=short", "--format=%h %ad %an%d%n    %s"}
+	if params.Ref != "" {
+		args = append(args, params.Ref)
+	}
+	if params.Path != "" {
+		args = append(args, "--", params.Path)
+	}
+	out, err := gitRun(ctx, args...)
+	if err != nil {
+		return gitErrorResponse(err)
+	}
+	if out == "" {
+		return NewTextResponse("No commits"), nil
+	}
+	return NewTextResponse(truncateOutput(out)), nil
+}
+
+func NewGitCommitTool(permissions permission.Service) BaseTool {
+	return &gitCommitTool{
+		permissions: permissions,
+	}
+}
+
+func (g *gitCommitTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        GitCommitToolName,
+		Description: gitCommitDescription,
+		Parameters: map[string]any{
+			"message": map[string]any{
+				"type":        "string",
+				"description": "The commit message",
+			},
+			"files": map[string]any{
+				"type":        "array",
+				"description": "Optional files to commit, relative to the working directory. Omit to commit the staged changes",
+				"items": map[string]any{
+					"type": "string",
+				},
+			},
+		},
+		Required: []string{"message"},
+	}
+}
+
+func (g *gitCommitTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params GitCommitParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+	if strings.TrimSpace(params.Message) == "" {
+		return NewTextErrorResponse("missing commit message"), nil
+	}
+
+	// Preview what is going to be committed. New files don't show up here
+	// until they are added.
+	statArgs := []string{"diff", "--no-color", "--stat", "--cached"}
+	if len(params.Files) > 0 {
+		statArgs = append([]string{"diff", "--no-color", "--stat", "HEAD", "--"}, params.Files...)
+	}
+	stat, err := gitRun(ctx, statArgs...)
+	if err != nil && errors.Is(err, git.ErrNotRepository) {
+		return gitErrorResponse(err)
+	}
+	if len(params.Files) == 0 && stat == "" {
+		return NewTextErrorResponse("nothing is staged, provide the files to commit"), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a commit")
+	}
+
+	var description strings.Builder
+	fmt.Fprintf(&description, "Commit changes with message:\n\n```\n%s\n```\n", params.Message)
+	if len(params.Files) > 0 {
+		description.WriteString("\nFiles:\n")
+		for _, file := range params.Files {
+			fmt.Fprintf(&description, "- `%s`\n", file)
+		}
+	} else {
+		description.WriteString("\nCommits the staged changes.\n")
+	}
+	if stat != "" {
+		fmt.Fprintf(&description, "\n```\n%s\n```\n", stat)
+	}
+
+	p := g.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        config.WorkingDirectory(),
+			ToolName:    GitCommitToolName,
+			Action:      "commit",
+			Description: description.String(),
+			Params: GitCommitPermissionsParams{
+				Message: params.Message,
+				Files:   params.Files,
+				Stat:    stat,
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	hash, err := git.Commit(ctx, config.WorkingDirectory(), params.Message, params.Files)
+	if err != nil {
+		return gitErrorResponse(err)
+	}
+	return NewTextResponse(fmt.Sprintf("Created commit %s", hash)), nil
+}
+
+func NewGitBranchTool(permissions permission.Service) BaseTool {
+	return &gitBranchTool{
+		permissions: permissions,
+	}
+}
+
+func (g *gitBranchTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        GitBranchToolName,
+		Description: gitBranchDescription,
+		Parameters: map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"description": "The name of the branch to create, omit to list branches",
+			},
+			"start_point": map[string]any{
+				"type":        "string",
+				"description": "Optional commit or branch the new branch starts from, defaults to HEAD",
+			},
+			"checkout": map[string]any{
+				"type":        "boolean",
+				"description": "Switch to the new branch after creating it",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (g *gitBranchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params GitBranchParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("invalid parameters"), nil
+	}
+
+	if params.Name == "" {
+		out, err := gitRun(ctx, "branch", "--no-color", "--list", "-vv")
+		if err != nil {
+			return gitErrorResponse(err)
+		}
+		return NewTextResponse(truncateOutput(out)), nil
+	}
+	if strings.HasPrefix(params.Name, "-") || strings.HasPrefix(params.StartPoint, "-") {
+		return NewTextErrorResponse("invalid branch name or start point"), nil
+	}
+	if _, err := gitRun(ctx, "check-ref-format", "--branch", params.Name); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("invalid branch name: %s", params.Name)), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a branch")
+	}
+
+	description := fmt.Sprintf("Create branch `%s`", params.Name)
+	if params.StartPoint != "" {
+		description += fmt.Sprintf(" from `%s`", params.StartPoint)
+	}
+	if params.Checkout {
+		description += " and switch to it"
+	}
+	p := g.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        config.WorkingDirectory(),
+			ToolName:    GitBranchToolName,
+			Action:      "write",
+			Description: description,
+			Params:      GitBranchPermissionsParams(params),
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	args := []string{"branch", params.Name}
+	if params.Checkout {
+		args = []string{"switch", "--create", params.Name}
+	}
+	if params.StartPoint != "" {
+		args = append(args, params.StartPoint)
+	}
+	if _, err := gitRun(ctx, args...); err != nil {
+		return gitErrorResponse(err)
+	}
+
+	if params.Checkout {
+		return NewTextResponse(fmt.Sprintf("Created branch %s and switched to it", params.Name)), nil
+	}
+	return NewTextResponse(fmt.Sprintf("Created branch %s", params.Name)), nil
+}
@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Arguments starting with "-" would be read by git as options, so they are
+// rejected before git runs
+func TestGitToolsRejectOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		tool  BaseTool
+		input string
+		want  string
+	}{
+		{"diff ref", NewGitDiffTool(), `{"ref": "--output=/tmp/x"}`, "invalid ref: --output=/tmp/x"},
+		{"log ref", NewGitLogTool(), `{"ref": "-p"}`, "invalid ref: -p"},
+		{"branch name", NewGitBranchTool(nil), `{"name": "-D"}`, "invalid branch name or start point"},
+		{"branch start point", NewGitBranchTool(nil), `{"name": "feature", "start_point": "--force"}`, "invalid branch name or start point"},
+		{"commit message", NewGitCommitTool(nil), `{"message": "  "}`, "missing commit message"},
+		{"invalid parameters", NewGitLogTool(), `{"limit": "ten"}`, "invalid parameters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := tt.tool.Run(context.Background(), ToolCall{Input: tt.input})
+			require.NoError(t, err)
+			assert.True(t, response.IsError)
+			assert.Equal(t, tt.want, response.Content)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// MaxImageSize is the size of the largest image a tool returns. Anthropic
+// limits images to 5MB once base64 encoded, which is 3.75MB of data.
+const MaxImageSize = 5 * 1024 * 1024 / 4 * 3
+
+// ImageMIMEType returns the MIME type of data when it is an image the models
+// can see, and "" otherwise
+func ImageMIMEType(data []byte) string {
+	switch mimeType := http.DetectContentType(data); mimeType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return mimeType
+	}
+	return ""
+}
+
+// describeImage is the text of the response of an image, for the models that
+// can't see it
+func describeImage(name string, image ToolImage) string {
+	return fmt.Sprintf("%s is an image of type %s (%d bytes)", name, image.MIMEType, len(image.Data))
+}
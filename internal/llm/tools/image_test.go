@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+	return buf.Bytes()
+}
+
+func TestReadImageFile(t *testing.T) {
+	dir := t.TempDir()
+	data := pngImage(t)
+	path := filepath.Join(dir, "chart.png")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	response, err := readImageFile(path, int64(len(data)), "PNG")
+	require.NoError(t, err)
+	assert.Equal(t, ToolResponseTypeImage, response.Type)
+	assert.False(t, response.IsError)
+	assert.Contains(t, response.Content, "image/png")
+	assert.Equal(t, []ToolImage{{MIMEType: "image/png", Data: data}}, response.Images)
+
+	// The models can't see BMP images
+	path = filepath.Join(dir, "icon.bmp")
+	require.NoError(t, os.WriteFile(path, []byte("BM\x00\x00"), 0o644))
+	response, err = readImageFile(path, 4, "BMP")
+	require.NoError(t, err)
+	assert.True(t, response.IsError)
+	assert.Empty(t, response.Images)
+
+	response, err = readImageFile(path, MaxImageSize+1, "PNG")
+	require.NoError(t, err)
+	assert.True(t, response.IsError)
+}
+
+func TestOutputImage(t *testing.T) {
+	data := pngImage(t)
+
+	image, ok := outputImage(string(data), 0, false)
+	require.True(t, ok)
+	assert.Equal(t, ToolImage{MIMEType: "image/png", Data: data}, image)
+
+	_, ok = outputImage("hello", 0, false)
+	assert.False(t, ok)
+	_, ok = outputImage(string(data), 1, false)
+	assert.False(t, ok)
+	_, ok = outputImage(string(data), 0, true)
+	assert.False(t, ok)
+}
@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// Relative paths are resolved against the working directory of the
+	// config, the temp directory holding the test directories
+	if _, err := config.Load(os.TempDir(), false); err != nil {
+		os.Exit(2)
+	}
+	if os.Getenv("OPENCODE_TEST_LSP_SERVER") == "1" {
+		serveTestLSP()
+	}
+	os.Exit(m.Run())
+}
+
+// newTestLSPClient starts the test binary as a language server for dir
+func newTestLSPClient(t *testing.T, dir string) map[string]*lsp.Client {
+	t.Setenv("OPENCODE_TEST_LSP_SERVER", "1")
+	ctx := context.Background()
+	client, err := lsp.NewClient(ctx, os.Args[0])
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	_, err = client.InitializeLSPClient(ctx, dir)
+	require.NoError(t, err)
+	return map[string]*lsp.Client{"test": client}
+}
+
+// serveTestLSP lets the test binary act as a minimal language server. It
+// reports an error for every line of an open file containing "ERROR" through
+// pull diagnostics, and formats files by indenting with tabs. Navigation requests are answered relative to the
+// requested position: the definition is the position itself, the references
+// are the position and the start of the file, and "Target" is the function
+// declared on the second line of the file. Requests without a document are
+// answered for the last opened file.
+func serveTestLSP() {
+	reader := bufio.NewReader(os.Stdin)
+	documents := make(map[string]string)
+	lastURI := ""
+	for {
+		msg, err := lsp.ReadMessage(reader)
+		if err != nil {
+			os.Exit(0)
+		}
+
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+			Position json.RawMessage `json:"position"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		uri := params.TextDocument.URI
+		if uri == "" {
+			uri = lastURI
+		}
+		switch msg.Method {
+		case "textDocument/didOpen":
+			documents[uri] = params.TextDocument.Text
+			lastURI = uri
+		case "textDocument/didChange":
+			for _, change := range params.ContentChanges {
+				documents[uri] = change.Text
+			}
+		}
+		if msg.ID == 0 {
+			continue
+		}
+
+		location := func(position string) map[string]any {
+			return map[string]any{
+				"uri":   uri,
+				"range": json.RawMessage(`{"start":` + position + `,"end":` + position + `}`),
+			}
+		}
+		target := map[string]any{
+			"name":           "Target",
+			"kind":           12,
+			"uri":            uri,
+			"range":          json.RawMessage(`{"start":{"line":1,"character":0},"end":{"line":1,"character":13}}`),
+			"selectionRange": json.RawMessage(`{"start":{"line":1,"character":5},"end":{"line":1,"character":11}}`),
+		}
+		var result any
+		switch msg.Method {
+		case "initialize":
+			result = json.RawMessage(`{"capabilities":{"diagnosticProvider":{"interFileDependencies":false,"workspaceDiagnostics":false}}}`)
+		case "textDocument/diagnostic":
+			items := []any{}
+			for i, line := range strings.Split(documents[uri], "\n") {
+				if strings.Contains(line, "ERROR") {
+					items = append(items, map[string]any{
+						"range":    map[string]any{"start": map[string]int{"line": i}, "end": map[string]int{"line": i}},
+						"severity": 1,
+						"message":  strings.TrimSpace(line),
+					})
+				}
+			}
+			result = map[string]any{"kind": "full", "items": items}
+		case "textDocument/definition":
+			result = location(string(params.Position))
+		case "textDocument/references":
+			result = []any{location(string(params.Position)), location(`{"line":0,"character":0}`)}
+		case "textDocument/hover":
+			result = map[string]any{"contents": map[string]string{"kind": "markdown", "value": "func Target()"}}
+		case "textDocument/prepareCallHierarchy":
+			result = []any{target}
+		case "callHierarchy/incomingCalls":
+			result = []any{map[string]any{
+				"from":       map[string]any{"name": "main", "kind": 12, "uri": uri, "range": target["range"], "selectionRange": target["range"]},
+				"fromRanges": []any{json.RawMessage(`{"start":{"line":2,"character":1},"end":{"line":2,"character":7}}`)},
+			}}
+		case "callHierarchy/outgoingCalls":
+			result = []any{}
+		case "workspace/symbol":
+			result = []any{map[string]any{"name": "Target", "kind": 12, "location": location(`{"line":1,"character":0}`)}}
+		case "textDocument/formatting":
+			edits := []any{}
+			for i, line := range strings.Split(documents[uri], "\n") {
+				if strings.HasPrefix(line, "    ") {
+					edits = append(edits, map[string]any{
+						"range":   map[string]any{"start": map[string]int{"line": i}, "end": map[string]int{"line": i, "character": 4}},
+						"newText": "\t",
+					})
+				}
+			}
+			result = edits
+		case "textDocument/documentSymbol":
+			result = []any{map[string]any{"name": "Target", "kind": 12, "range": target["range"], "selectionRange": target["selectionRange"]}}
+		}
+		raw, _ := json.Marshal(result)
+		if err := lsp.WriteMessage(os.Stdout, &lsp.Message{JSONRPC: "2.0", ID: msg.ID, Result: raw}); err != nil {
+			os.Exit(0)
+		}
+	}
+}
@@ -0,0 +1,802 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+)
+
+// NavigationParams locates a symbol either by position or by name.
+type NavigationParams struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Symbol   string `json:"symbol"`
+}
+
+type ReferencesParams struct {
+	NavigationParams
+	IncludeDeclaration bool `json:"include_declaration"`
+}
+
+type CallHierarchyParams struct {
+	NavigationParams
+	Direction string `json:"direction"`
+}
+
+type WorkspaceSymbolsParams struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+}
+
+type definitionTool struct {
+	lspClients map[string]*lsp.Client
+}
+
+type referencesTool struct {
+	lspClients map[string]*lsp.Client
+}
+
+type hoverTool struct {
+	lspClients map[string]*lsp.Client
+}
+
+type callHierarchyTool struct {
+	lspClients map[string]*lsp.Client
+}
+
+type workspaceSymbolsTool struct {
+	lspClients map[string]*lsp.Client
+}
+
+const (
+	DefinitionToolName       = "definition"
+	ReferencesToolName       = "references"
+	HoverToolName            = "hover"
+	CallHierarchyToolName    = "call_hierarchy"
+	WorkspaceSymbolsToolName = "workspace_symbols"
+
+	maxReferences           = 100
+	DefaultWorkspaceSymbols = 30
+	MaxWorkspaceSymbols     = 200
+
+	navigationLocationHelp = `HOW TO USE:
+- Either give file_path with the 1-based line and column of the symbol, or give the symbol name
+- Positions can be taken from view, grep or diagnostics output
+- Symbol names are looked up with workspace symbol search, qualify them to disambiguate (e.g. "Client.OpenFile")
+`
+
+	definitionDescription = `Finds where a symbol is defined using the language server.
+WHEN TO USE THIS TOOL:
+- Use when you need to jump from a usage of a function, type, variable or field to its definition
+- Much more precise than grep, it resolves imports, methods and shadowed names
+` + navigationLocationHelp + `LIMITATIONS:
+- Requires a language server configured for the file's language
+- Symbols in dependencies are only found if the language server indexes them
+`
+
+	referencesDescription = `Finds all references to a symbol using the language server.
+WHEN TO USE THIS TOOL:
+- Use before changing a function signature, renaming or deleting a symbol to find every usage
+- Use to understand how a type or function is used across the codebase
+` + navigationLocationHelp + `- Set include_declaration to also list the declaration itself
+LIMITATIONS:
+- Requires a language server configured for the file's language
+- At most 100 references are listed
+`
+
+	hoverDescription = `Shows type information and documentation for a symbol using the language server.
+WHEN TO USE THIS TOOL:
+- Use to see the signature, type or documentation of a symbol without opening its definition
+- Use to find out the inferred type of a variable or expression
+` + navigationLocationHelp + `LIMITATIONS:
+- Requires a language server configured for the file's language
+- The amount of documentation depends on the language server
+`
+
+	callHierarchyDescription = `Lists the callers or callees of a function using the language server.
+WHEN TO USE THIS TOOL:
+- Use direction "incoming" to find every function that calls the given function
+- Use direction "outgoing" to find every function the given function calls
+- Use to trace how control flows through the code before changing behavior
+` + navigationLocationHelp + `LIMITATIONS:
+- Requires a language server with call hierarchy support
+- Only direct callers or callees are listed, call the tool again to go further
+`
+
+	workspaceSymbolsDescription = `Searches for symbols (functions, types, methods, variables) across the workspace using the language server.
+WHEN TO USE THIS TOOL:
+- Use when you know the name, or part of the name, of a symbol but not where it is defined
+- Better than grep for finding declarations because it ignores comments, strings and usages
+HOW TO USE:
+- Provide a query, language servers match it fuzzily against symbol names
+- Optionally limit the number of results (default 30, max 200)
+LIMITATIONS:
+- Requires a running language server, results depend on what the server has indexed
+`
+)
+
+func NewDefinitionTool(lspClients map[string]*lsp.Client) BaseTool {
+	return &definitionTool{lspClients}
+}
+
+func NewReferencesTool(lspClients map[string]*lsp.Client) BaseTool {
+	return &referencesTool{lspClients}
+}
+
+func NewHoverTool(lspClients map[string]*lsp.Client) BaseTool {
+	return &hoverTool{lspClients}
+}
+
+func NewCallHierarchyTool(lspClients map[string]*lsp.Client) BaseTool {
+	return &callHierarchyTool{lspClients}
+}
+
+func NewWorkspaceSymbolsTool(lspClients map[string]*lsp.Client) BaseTool {
+	return &workspaceSymbolsTool{lspClients}
+}
+
+func navigationParameters() map[string]any {
+	return map[string]any{
+		"file_path": map[string]any{
+			"type":        "string",
+			"description": "The path of the file containing the symbol",
+		},
+		"line": map[string]any{
+			"type":        "integer",
+			"description": "The 1-based line of the symbol",
+		},
+		"column": map[string]any{
+			"type":        "integer",
+			"description": "The 1-based column of the symbol",
+		},
+		"symbol": map[string]any{
+			"type":        "string",
+			"description": "The name of the symbol, used instead of a position",
+		},
+	}
+}
+
+func (t *definitionTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        DefinitionToolName,
+		Description: definitionDescription,
+		Parameters:  navigationParameters(),
+		Required:    []string{},
+	}
+}
+
+func (t *definitionTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params NavigationParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	target, err := resolveNavigationTarget(ctx, t.lspClients, params)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var locations []protocol.Location
+	err = forEachNavigationClient(ctx, t.lspClients, target.path, func(client *lsp.Client) (bool, error) {
+		result, err := client.Definition(ctx, protocol.DefinitionParams{
+			TextDocumentPositionParams: target.positionParams(),
+		})
+		if err != nil {
+			return false, err
+		}
+		locations = definitionLocations(result.Value)
+		return len(locations) > 0, nil
+	})
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if len(locations) == 0 {
+		return NewTextResponse("No definition found"), nil
+	}
+	return NewTextResponse(formatLocations(locations, maxReferences)), nil
+}
+
+func (t *referencesTool) Info() ToolInfo {
+	parameters := navigationParameters()
+	parameters["include_declaration"] = map[string]any{
+		"type":        "boolean",
+		"description": "Also list the declaration of the symbol (default false)",
+	}
+	return ToolInfo{
+		Name:        ReferencesToolName,
+		Description: referencesDescription,
+		Parameters:  parameters,
+		Required:    []string{},
+	}
+}
+
+func (t *referencesTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params ReferencesParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	target, err := resolveNavigationTarget(ctx, t.lspClients, params.NavigationParams)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var locations []protocol.Location
+	err = forEachNavigationClient(ctx, t.lspClients, target.path, func(client *lsp.Client) (bool, error) {
+		result, err := client.References(ctx, protocol.ReferenceParams{
+			TextDocumentPositionParams: target.positionParams(),
+			Context: protocol.ReferenceContext{
+				IncludeDeclaration: params.IncludeDeclaration,
+			},
+		})
+		if err != nil {
+			return false, err
+		}
+		locations = result
+		return len(locations) > 0, nil
+	})
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if len(locations) == 0 {
+		return NewTextResponse("No references found"), nil
+	}
+	output := fmt.Sprintf("%d references\n%s", len(locations), formatLocations(locations, maxReferences))
+	return NewTextResponse(output), nil
+}
+
+func (t *hoverTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        HoverToolName,
+		Description: hoverDescription,
+		Parameters:  navigationParameters(),
+		Required:    []string{},
+	}
+}
+
+func (t *hoverTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params NavigationParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	target, err := resolveNavigationTarget(ctx, t.lspClients, params)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var contents string
+	err = forEachNavigationClient(ctx, t.lspClients, target.path, func(client *lsp.Client) (bool, error) {
+		result, err := client.Hover(ctx, protocol.HoverParams{
+			TextDocumentPositionParams: target.positionParams(),
+		})
+		if err != nil {
+			return false, err
+		}
+		contents = strings.TrimSpace(result.Contents.Value)
+		return contents != "", nil
+	})
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if contents == "" {
+		return NewTextResponse("No information available for this position"), nil
+	}
+	return NewTextResponse(truncateOutput(contents)), nil
+}
+
+func (t *callHierarchyTool) Info() ToolInfo {
+	parameters := navigationParameters()
+	parameters["direction"] = map[string]any{
+		"type":        "string",
+		"description": "\"incoming\" to list callers, \"outgoing\" to list callees",
+		"enum":        []string{"incoming", "outgoing"},
+	}
+	return ToolInfo{
+		Name:        CallHierarchyToolName,
+		Description: callHierarchyDescription,
+		Parameters:  parameters,
+		Required:    []string{"direction"},
+	}
+}
+
+func (t *callHierarchyTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params CallHierarchyParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Direction != "incoming" && params.Direction != "outgoing" {
+		return NewTextErrorResponse("direction must be \"incoming\" or \"outgoing\""), nil
+	}
+	target, err := resolveNavigationTarget(ctx, t.lspClients, params.NavigationParams)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var item protocol.CallHierarchyItem
+	var calls []string
+	err = forEachNavigationClient(ctx, t.lspClients, target.path, func(client *lsp.Client) (bool, error) {
+		items, err := client.PrepareCallHierarchy(ctx, protocol.CallHierarchyPrepareParams{
+			TextDocumentPositionParams: target.positionParams(),
+		})
+		if err != nil {
+			return false, err
+		}
+		if len(items) == 0 {
+			return false, nil
+		}
+		item = items[0]
+		calls = calls[:0]
+		if params.Direction == "incoming" {
+			incoming, err := client.IncomingCalls(ctx, protocol.CallHierarchyIncomingCallsParams{Item: item})
+			if err != nil {
+				return false, err
+			}
+			for _, c := range incoming {
+				calls = append(calls, formatCallHierarchyItem(c.From, c.FromRanges))
+			}
+		} else {
+			outgoing, err := client.OutgoingCalls(ctx, protocol.CallHierarchyOutgoingCallsParams{Item: item})
+			if err != nil {
+				return false, err
+			}
+			for _, c := range outgoing {
+				calls = append(calls, formatCallHierarchyItem(c.To, nil))
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if item.Name == "" {
+		return NewTextResponse("No function found at this position"), nil
+	}
+
+	var output strings.Builder
+	if params.Direction == "incoming" {
+		fmt.Fprintf(&output, "Callers of %s (%d):\n", formatCallHierarchyItem(item, nil), len(calls))
+	} else {
+		fmt.Fprintf(&output, "Calls made by %s (%d):\n", formatCallHierarchyItem(item, nil), len(calls))
+	}
+	if len(calls) == 0 {
+		output.WriteString("none")
+	}
+	for i, c := range calls {
+		if i == maxReferences {
+			fmt.Fprintf(&output, "... and %d more\n", len(calls)-maxReferences)
+			break
+		}
+		output.WriteString(c + "\n")
+	}
+	return NewTextResponse(strings.TrimSpace(output.String())), nil
+}
+
+func (t *workspaceSymbolsTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        WorkspaceSymbolsToolName,
+		Description: workspaceSymbolsDescription,
+		Parameters: map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "The symbol name or part of it",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "The maximum number of results (default 30, max 200)",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+func (t *workspaceSymbolsTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params WorkspaceSymbolsParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Query == "" {
+		return NewTextErrorResponse("query is required"), nil
+	}
+	if params.Limit <= 0 {
+		params.Limit = DefaultWorkspaceSymbols
+	}
+	params.Limit = min(params.Limit, MaxWorkspaceSymbols)
+
+	symbols, err := workspaceSymbols(ctx, t.lspClients, params.Query)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if len(symbols) == 0 {
+		return NewTextResponse("No symbols found"), nil
+	}
+
+	var output strings.Builder
+	for i, s := range symbols {
+		if i == params.Limit {
+			fmt.Fprintf(&output, "... and %d more, refine the query to narrow the results\n", len(symbols)-params.Limit)
+			break
+		}
+		name := s.Name
+		if s.ContainerName != "" && !strings.Contains(name, ".") {
+			name = s.ContainerName + "." + name
+		}
+		// Drop the import path of package qualified names.
+		if idx := strings.LastIndex(name, "/"); idx >= 0 {
+			name = name[idx+1:]
+		}
+		fmt.Fprintf(&output, "%s %s %s\n", symbolKindName(s.Kind), name, formatPosition(s.Location.URI.Path(), s.Location.Range.Start))
+	}
+	return NewTextResponse(strings.TrimSpace(output.String())), nil
+}
+
+// navigationTarget is a resolved position in a file.
+type navigationTarget struct {
+	path     string
+	position protocol.Position
+}
+
+func (t navigationTarget) positionParams() protocol.TextDocumentPositionParams {
+	return protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentUri("file://" + t.path)},
+		Position:     t.position,
+	}
+}
+
+// resolveNavigationTarget turns a file position or a symbol name into a
+// language server position.
+func resolveNavigationTarget(ctx context.Context, lsps map[string]*lsp.Client, params NavigationParams) (navigationTarget, error) {
+	if len(lsps) == 0 {
+		return navigationTarget{}, errors.New("no LSP clients available")
+	}
+
+	if params.FilePath == "" {
+		if params.Symbol == "" {
+			return navigationTarget{}, errors.New("either file_path with line and column, or symbol is required")
+		}
+		return resolveSymbol(ctx, lsps, params.Symbol)
+	}
+
+	path := params.FilePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.WorkingDirectory(), path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return navigationTarget{}, fmt.Errorf("error reading file: %w", err)
+	}
+	lines := strings.Split(string(content), "\n")
+
+	if params.Line <= 0 && params.Symbol != "" {
+		// Only the name is known, find its declaration in the file.
+		if position, ok := documentSymbolPosition(ctx, lsps, path, params.Symbol); ok {
+			return navigationTarget{path: path, position: position}, nil
+		}
+		return navigationTarget{}, fmt.Errorf("symbol %s not found in %s", params.Symbol, params.FilePath)
+	}
+	if params.Line <= 0 || params.Line > len(lines) {
+		return navigationTarget{}, fmt.Errorf("line %d is out of range, the file has %d lines", params.Line, len(lines))
+	}
+	line := lines[params.Line-1]
+	column := max(params.Column, 1)
+	runes := []rune(line)
+	if column > len(runes)+1 {
+		column = len(runes) + 1
+	}
+	return navigationTarget{
+		path: path,
+		position: protocol.Position{
+			Line:      uint32(params.Line - 1),
+			Character: uint32(len(utf16.Encode(runes[:column-1]))),
+		},
+	}, nil
+}
+
+// resolveSymbol finds the declaration of a named symbol with workspace symbol
+// search and returns the position of its name.
+func resolveSymbol(ctx context.Context, lsps map[string]*lsp.Client, symbol string) (navigationTarget, error) {
+	symbols, err := workspaceSymbols(ctx, lsps, symbolBaseName(symbol))
+	if err != nil {
+		return navigationTarget{}, err
+	}
+
+	for _, s := range symbols {
+		if symbolMatches(s.Name, s.ContainerName, symbol) {
+			path := s.Location.URI.Path()
+			return navigationTarget{path: path, position: namePosition(path, s.Location.Range.Start, symbol)}, nil
+		}
+	}
+	return navigationTarget{}, fmt.Errorf("symbol %s not found, use workspace_symbols to search for it", symbol)
+}
+
+// documentSymbolPosition finds the declaration of symbol in path.
+func documentSymbolPosition(ctx context.Context, lsps map[string]*lsp.Client, path, symbol string) (protocol.Position, bool) {
+	var position protocol.Position
+	found := false
+	var walk func(symbols []protocol.DocumentSymbol, container string)
+	walk = func(symbols []protocol.DocumentSymbol, container string) {
+		for _, s := range symbols {
+			if found {
+				return
+			}
+			if symbolMatches(s.Name, container, symbol) {
+				position, found = s.SelectionRange.Start, true
+				return
+			}
+			qualified := symbolNameReplacer.Replace(s.Name)
+			if container != "" {
+				qualified = container + "." + qualified
+			}
+			walk(s.Children, qualified)
+		}
+	}
+	forEachNavigationClient(ctx, lsps, path, func(client *lsp.Client) (bool, error) {
+		result, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentUri("file://" + path)},
+		})
+		if err != nil {
+			return false, err
+		}
+		switch v := result.Value.(type) {
+		case []protocol.DocumentSymbol:
+			walk(v, "")
+		case []protocol.SymbolInformation:
+			for _, s := range v {
+				if symbolMatches(s.Name, s.ContainerName, symbol) {
+					position, found = namePosition(path, s.Location.Range.Start, symbol), true
+					break
+				}
+			}
+		}
+		return found, nil
+	})
+	return position, found
+}
+
+// symbolNameReplacer removes the receiver decoration some servers add to
+// method names, e.g. "(*Client).Open".
+var symbolNameReplacer = strings.NewReplacer("(*", "", "(", "", ")", "")
+
+// symbolMatches reports whether a symbol reported by a server is the one the
+// model asked for. Names may be qualified with a type or package path.
+func symbolMatches(name, container, symbol string) bool {
+	qualified := symbolNameReplacer.Replace(name)
+	if container != "" {
+		qualified = symbolNameReplacer.Replace(container) + "." + qualified
+	}
+	if symbolBaseName(qualified) != symbolBaseName(symbol) {
+		return false
+	}
+	return !strings.Contains(symbol, ".") ||
+		qualified == symbol ||
+		strings.HasSuffix(qualified, "."+symbol) ||
+		strings.HasSuffix(qualified, "/"+symbol)
+}
+
+// namePosition moves position to the symbol's name. Symbol locations may
+// start at modifiers or keywords that precede the name.
+func namePosition(path string, position protocol.Position, symbol string) protocol.Position {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return position
+	}
+	lines := strings.Split(string(content), "\n")
+	if int(position.Line) >= len(lines) {
+		return position
+	}
+	line := lines[position.Line]
+	runes := []rune(line)
+	start := len(string(runes[:utf16Offset(runes, position.Character)]))
+	if idx := strings.Index(line[start:], symbolBaseName(symbol)); idx >= 0 {
+		position.Character = uint32(len(utf16.Encode([]rune(line[:start+idx]))))
+	}
+	return position
+}
+
+// workspaceSymbols queries all clients and merges their results.
+func workspaceSymbols(ctx context.Context, lsps map[string]*lsp.Client, query string) ([]protocol.SymbolInformation, error) {
+	if len(lsps) == 0 {
+		return nil, errors.New("no LSP clients available")
+	}
+	var symbols []protocol.SymbolInformation
+	var lastErr error
+	for _, name := range sortedClientNames(lsps) {
+		result, err := lsps[name].Symbol(ctx, protocol.WorkspaceSymbolParams{Query: query})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		switch v := result.Value.(type) {
+		case []protocol.SymbolInformation:
+			symbols = append(symbols, v...)
+		case []protocol.WorkspaceSymbol:
+			for _, s := range v {
+				location, ok := s.Location.Value.(protocol.Location)
+				if !ok {
+					if uriOnly, ok := s.Location.Value.(protocol.LocationUriOnly); ok {
+						location = protocol.Location{URI: uriOnly.URI}
+					}
+				}
+				symbols = append(symbols, protocol.SymbolInformation{
+					Name:          s.Name,
+					Kind:          s.Kind,
+					ContainerName: s.ContainerName,
+					Location:      location,
+				})
+			}
+		}
+	}
+	if len(symbols) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("workspace symbol search failed: %w", lastErr)
+	}
+	return symbols, nil
+}
+
+// forEachNavigationClient opens path in each client handling it and runs fn
+// until it reports a result. Errors are only reported when every client failed.
+func forEachNavigationClient(ctx context.Context, lsps map[string]*lsp.Client, path string, fn func(*lsp.Client) (bool, error)) error {
+	var lastErr error
+	answered := false
+	for _, name := range sortedClientNames(lsps) {
+		client := lsps[name]
+		if !client.HandlesFile(path) {
+			continue
+		}
+		if err := client.OpenFileOnDemand(ctx, path); err != nil {
+			lastErr = err
+			continue
+		}
+		found, err := fn(client)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if found {
+			return nil
+		}
+		answered = true
+	}
+	if lastErr != nil && !answered {
+		return fmt.Errorf("language server request failed: %w", lastErr)
+	}
+	return nil
+}
+
+func sortedClientNames(lsps map[string]*lsp.Client) []string {
+	names := make([]string, 0, len(lsps))
+	for name := range lsps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func definitionLocations(value any) []protocol.Location {
+	switch v := value.(type) {
+	case protocol.Definition:
+		return definitionLocations(v.Value)
+	case protocol.Location:
+		return []protocol.Location{v}
+	case []protocol.Location:
+		return v
+	case []protocol.DefinitionLink:
+		locations := make([]protocol.Location, 0, len(v))
+		for _, link := range v {
+			locations = append(locations, protocol.Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+		}
+		return locations
+	}
+	return nil
+}
+
+// formatLocations renders locations as path:line:column followed by the
+// source line, so the model rarely needs to view the file.
+func formatLocations(locations []protocol.Location, limit int) string {
+	files := make(map[string][]string)
+	var output strings.Builder
+	for i, location := range locations {
+		if i == limit {
+			fmt.Fprintf(&output, "... and %d more\n", len(locations)-limit)
+			break
+		}
+		path := location.URI.Path()
+		lines, ok := files[path]
+		if !ok {
+			if content, err := os.ReadFile(path); err == nil {
+				lines = strings.Split(string(content), "\n")
+			}
+			files[path] = lines
+		}
+		output.WriteString(formatPosition(path, location.Range.Start))
+		if line := int(location.Range.Start.Line); line < len(lines) {
+			output.WriteString("  " + strings.TrimSpace(lines[line]))
+		}
+		output.WriteString("\n")
+	}
+	return strings.TrimSpace(output.String())
+}
+
+func formatCallHierarchyItem(item protocol.CallHierarchyItem, ranges []protocol.Range) string {
+	position := item.SelectionRange.Start
+	if len(ranges) > 0 {
+		position = ranges[0].Start
+	}
+	result := fmt.Sprintf("%s %s %s", symbolKindName(item.Kind), item.Name, formatPosition(item.URI.Path(), position))
+	if item.Detail != "" {
+		result += "  " + item.Detail
+	}
+	return result
+}
+
+// formatPosition returns path:line:column with a path relative to the working
+// directory and a 1-based line and column.
+func formatPosition(path string, position protocol.Position) string {
+	return fmt.Sprintf("%s:%d:%d", relativeToWorkingDir(path), position.Line+1, position.Character+1)
+}
+
+// utf16Offset converts a UTF-16 character offset into a rune index.
+func utf16Offset(line []rune, character uint32) uint32 {
+	var units uint32
+	for i, r := range line {
+		if units >= character {
+			return uint32(i)
+		}
+		units += uint32(len(utf16.Encode([]rune{r})))
+	}
+	return uint32(len(line))
+}
+
+func symbolBaseName(symbol string) string {
+	if idx := strings.LastIndex(symbol, "."); idx >= 0 {
+		return symbol[idx+1:]
+	}
+	return symbol
+}
+
+func symbolKindName(kind protocol.SymbolKind) string {
+	switch kind {
+	case protocol.File:
+		return "file"
+	case protocol.Module:
+		return "module"
+	case protocol.Namespace:
+		return "namespace"
+	case protocol.Package:
+		return "package"
+	case protocol.Class:
+		return "class"
+	case protocol.Method:
+		return "method"
+	case protocol.Property:
+		return "property"
+	case protocol.Field:
+		return "field"
+	case protocol.Constructor:
+		return "constructor"
+	case protocol.Enum:
+		return "enum"
+	case protocol.Interface:
+		return "interface"
+	case protocol.Function:
+		return "func"
+	case protocol.Variable:
+		return "var"
+	case protocol.Constant:
+		return "const"
+	case protocol.Struct:
+		return "struct"
+	case protocol.EnumMember:
+		return "enum member"
+	case protocol.TypeParameter:
+		return "type parameter"
+	}
+	return "symbol"
+}
@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		container string
+		symbol    string
+		want      bool
+	}{
+		{"Commit", "", "Commit", true},
+		{"CommitDraft", "", "Commit", false},
+		{"github.com/opencode-ai/opencode/internal/git.Commit", "", "git.Commit", true},
+		{"github.com/opencode-ai/opencode/internal/git.Commit", "", "app.Commit", false},
+		{"(*Client).OpenFile", "", "Client.OpenFile", true},
+		{"OpenFile", "Client", "Client.OpenFile", true},
+		{"OpenFile", "Server", "Client.OpenFile", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol+" "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, symbolMatches(tt.name, tt.container, tt.symbol))
+		})
+	}
+}
+
+func TestUTF16Offset(t *testing.T) {
+	line := []rune("s := \"ä😀b\"")
+	assert.Equal(t, uint32(0), utf16Offset(line, 0))
+	assert.Equal(t, uint32(7), utf16Offset(line, 7))
+	// The emoji takes two UTF-16 code units
+	assert.Equal(t, uint32(8), utf16Offset(line, 9))
+	assert.Equal(t, uint32(len(line)), utf16Offset(line, 100))
+}
+
+func TestNavigationTools(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\nfunc Target() {}\nvar s = \"😀\" + Target()\n"), 0o644))
+	rel := relativeToWorkingDir(path)
+
+	lsps := newTestLSPClient(t, dir)
+	// Workspace symbols are found in the last opened file
+	require.NoError(t, lsps["test"].OpenFile(context.Background(), path))
+
+	tests := []struct {
+		name    string
+		tool    BaseTool
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "no LSP clients",
+			tool:    NewDefinitionTool(nil),
+			input:   `{"symbol": "Target"}`,
+			want:    "no LSP clients available",
+			wantErr: true,
+		},
+		{
+			name:    "invalid parameters",
+			tool:    NewHoverTool(lsps),
+			input:   `{"line": "3"}`,
+			want:    "error parsing parameters",
+			wantErr: true,
+		},
+		{
+			name:    "no target",
+			tool:    NewHoverTool(lsps),
+			input:   `{}`,
+			want:    "either file_path with line and column, or symbol is required",
+			wantErr: true,
+		},
+		{
+			name:    "line out of range",
+			tool:    NewDefinitionTool(lsps),
+			input:   `{"file_path": "` + path + `", "line": 9, "column": 1}`,
+			want:    "line 9 is out of range, the file has 4 lines",
+			wantErr: true,
+		},
+		{
+			name:    "invalid direction",
+			tool:    NewCallHierarchyTool(lsps),
+			input:   `{"symbol": "Target", "direction": "up"}`,
+			want:    `direction must be "incoming" or "outgoing"`,
+			wantErr: true,
+		},
+		{
+			name:    "missing query",
+			tool:    NewWorkspaceSymbolsTool(lsps),
+			input:   `{}`,
+			want:    "query is required",
+			wantErr: true,
+		},
+		{
+			// The emoji before the column takes two UTF-16 code units
+			name:  "definition by position",
+			tool:  NewDefinitionTool(lsps),
+			input: `{"file_path": "` + rel + `", "line": 3, "column": 15}`,
+			want:  rel + ":3:16  var s = \"😀\" + Target()",
+		},
+		{
+			name:  "definition by symbol",
+			tool:  NewDefinitionTool(lsps),
+			input: `{"symbol": "Target"}`,
+			want:  rel + ":2:6  func Target() {}",
+		},
+		{
+			name:  "definition by symbol in a file",
+			tool:  NewDefinitionTool(lsps),
+			input: `{"file_path": "` + path + `", "symbol": "Target"}`,
+			want:  rel + ":2:6  func Target() {}",
+		},
+		{
+			name:    "unknown symbol",
+			tool:    NewDefinitionTool(lsps),
+			input:   `{"symbol": "Missing"}`,
+			want:    "symbol Missing not found, use workspace_symbols to search for it",
+			wantErr: true,
+		},
+		{
+			name:  "references",
+			tool:  NewReferencesTool(lsps),
+			input: `{"file_path": "` + path + `", "line": 3, "column": 15}`,
+			want:  "2 references\n" + rel + ":3:16  var s = \"😀\" + Target()\n" + rel + ":1:1  package main",
+		},
+		{
+			name:  "hover",
+			tool:  NewHoverTool(lsps),
+			input: `{"symbol": "Target"}`,
+			want:  "func Target()",
+		},
+		{
+			name:  "incoming calls",
+			tool:  NewCallHierarchyTool(lsps),
+			input: `{"symbol": "Target", "direction": "incoming"}`,
+			want:  "Callers of func Target " + rel + ":2:6 (1):\nfunc main " + rel + ":3:2",
+		},
+		{
+			name:  "outgoing calls",
+			tool:  NewCallHierarchyTool(lsps),
+			input: `{"symbol": "Target", "direction": "outgoing"}`,
+			want:  "Calls made by func Target " + rel + ":2:6 (0):\nnone",
+		},
+		{
+			name:  "workspace symbols",
+			tool:  NewWorkspaceSymbolsTool(lsps),
+			input: `{"query": "Tar"}`,
+			want:  "func Target " + rel + ":2:1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := tt.tool.Run(context.Background(), ToolCall{Input: tt.input})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantErr, response.IsError)
+			if tt.wantErr {
+				assert.Contains(t, response.Content, tt.want)
+			} else {
+				assert.Equal(t, tt.want, response.Content)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/opencode-ai/opencode/internal/config"
@@ -257,6 +259,19 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		}
 	}
 
+	for path, change := range commit.Changes {
+		paths := []string{path}
+		if change.MovePath != nil {
+			paths = append(paths, *change.MovePath)
+		}
+		for _, changedPath := range paths {
+			if !filepath.IsAbs(changedPath) {
+				changedPath = filepath.Join(config.WorkingDirectory(), changedPath)
+			}
+			recordEditBaseline(ctx, changedPath, p.lspClients)
+		}
+	}
+
 	// Apply the changes to the filesystem
 	err = diff.ApplyCommit(commit, func(path string, content string) error {
 		absPath := path
@@ -288,6 +303,7 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	changedFiles := []string{}
 	totalAdditions := 0
 	totalRemovals := 0
+	formattedFiles := []string{}
 
 	for path, change := range commit.Changes {
 		absPath := path
@@ -307,6 +323,20 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 			newContent = *change.NewContent
 		}
 
+		if change.Type != diff.ActionDelete {
+			writtenPath := absPath
+			if change.MovePath != nil {
+				writtenPath = *change.MovePath
+				if !filepath.IsAbs(writtenPath) {
+					writtenPath = filepath.Join(config.WorkingDirectory(), writtenPath)
+				}
+			}
+			if formatted, ok := formatWrittenFile(ctx, writtenPath, oldContent, newContent, p.lspClients); ok {
+				newContent = formatted.Content
+				formattedFiles = append(formattedFiles, fmt.Sprintf("%s (%s)", path, formatted.Formatter))
+			}
+		}
+
 		// Calculate diff statistics
 		_, additions, removals := diff.GenerateDiff(oldContent, newContent, path)
 		totalAdditions += additions
@@ -352,6 +382,10 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 
 	result := fmt.Sprintf("Patch applied successfully. %d files changed, %d additions, %d removals",
 		len(changedFiles), totalAdditions, totalRemovals)
+	if len(formattedFiles) > 0 {
+		slices.Sort(formattedFiles)
+		result += "\nFormatted: " + strings.Join(formattedFiles, ", ")
+	}
 
 	diagnosticsText := ""
 	for _, filePath := range changedFiles {
@@ -0,0 +1,477 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/diff"
+	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+	"github.com/opencode-ai/opencode/internal/lsp/util"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type RenameSymbolParams struct {
+	NavigationParams
+	NewName string `json:"new_name"`
+}
+
+type CodeActionParams struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	EndLine  int    `json:"end_line"`
+	Kind     string `json:"kind"`
+	Title    string `json:"title"`
+}
+
+// WorkspaceEditPermissionsParams describes a language server edit that may
+// span many files.
+type WorkspaceEditPermissionsParams struct {
+	Summary string          `json:"summary"`
+	Files   []diff.FileDiff `json:"files"`
+}
+
+type WorkspaceEditResponseMetadata struct {
+	Files     []diff.FileDiff `json:"files"`
+	Additions int             `json:"additions"`
+	Removals  int             `json:"removals"`
+}
+
+type renameSymbolTool struct {
+	lspClients  map[string]*lsp.Client
+	permissions permission.Service
+	files       history.Service
+}
+
+type codeActionTool struct {
+	lspClients  map[string]*lsp.Client
+	permissions permission.Service
+	files       history.Service
+}
+
+const (
+	RenameSymbolToolName = "rename_symbol"
+	CodeActionToolName   = "apply_code_action"
+
+	renameSymbolDescription = `Renames a symbol and all of its references across the workspace using the language server.
+WHEN TO USE THIS TOOL:
+- Use to rename functions, types, methods, fields, variables or packages
+- Always prefer this over repeated edits, it only changes real references and never touches unrelated text with the same name
+HOW TO USE:
+- Either give file_path with the 1-based line and column of the symbol, or give the symbol name
+- Provide the new name, only the identifier, without qualifiers
+- The user is shown a diff of every affected file before anything is written
+LIMITATIONS:
+- Requires a language server with rename support for the file's language
+- References the language server does not know about (strings, comments, other languages) are not changed
+`
+
+	codeActionDescription = `Lists and applies language server code actions, such as organizing imports or quick fixes for diagnostics.
+WHEN TO USE THIS TOOL:
+- Use to organize imports, apply a suggested fix for a diagnostic or run a refactoring the language server offers
+HOW TO USE:
+- Call it with file_path and optionally line, column and end_line to list the available actions
+- Call it again with the exact title of an action to apply it
+- Give kind (e.g. "source.organizeImports", "quickfix") to only list actions of that kind; when exactly one action of that kind exists it is applied directly
+- Without a line the actions for the whole file are listed
+- The user is shown a diff of every affected file before anything is written
+LIMITATIONS:
+- Requires a language server with code action support for the file's language
+- Actions that only run a server command without a previewable edit are not supported
+`
+)
+
+func NewRenameSymbolTool(lspClients map[string]*lsp.Client, permissions permission.Service, files history.Service) BaseTool {
+	return &renameSymbolTool{
+		lspClients:  lspClients,
+		permissions: permissions,
+		files:       files,
+	}
+}
+
+func NewCodeActionTool(lspClients map[string]*lsp.Client, permissions permission.Service, files history.Service) BaseTool {
+	return &codeActionTool{
+		lspClients:  lspClients,
+		permissions: permissions,
+		files:       files,
+	}
+}
+
+func (t *renameSymbolTool) Info() ToolInfo {
+	parameters := navigationParameters()
+	parameters["new_name"] = map[string]any{
+		"type":        "string",
+		"description": "The new name of the symbol",
+	}
+	return ToolInfo{
+		Name:        RenameSymbolToolName,
+		Description: renameSymbolDescription,
+		Parameters:  parameters,
+		Required:    []string{"new_name"},
+	}
+}
+
+func (t *renameSymbolTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params RenameSymbolParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.NewName == "" {
+		return NewTextErrorResponse("new_name is required"), nil
+	}
+	target, err := resolveNavigationTarget(ctx, t.lspClients, params.NavigationParams)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var edit protocol.WorkspaceEdit
+	err = forEachNavigationClient(ctx, t.lspClients, target.path, func(client *lsp.Client) (bool, error) {
+		result, err := client.Rename(ctx, protocol.RenameParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: target.positionParams().TextDocument.URI},
+			Position:     target.position,
+			NewName:      params.NewName,
+		})
+		if err != nil {
+			return false, err
+		}
+		edit = result
+		return len(edit.Changes) > 0 || len(edit.DocumentChanges) > 0, nil
+	})
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if len(edit.Changes) == 0 && len(edit.DocumentChanges) == 0 {
+		return NewTextErrorResponse("the language server found nothing to rename at this position"), nil
+	}
+
+	name := params.Symbol
+	if name == "" {
+		name = fmt.Sprintf("the symbol at %s", formatPosition(target.path, target.position))
+	}
+	summary := fmt.Sprintf("Rename %s to %s", name, params.NewName)
+	return applyWorkspaceEdit(ctx, RenameSymbolToolName, summary, edit, t.lspClients, t.permissions, t.files)
+}
+
+func (t *codeActionTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        CodeActionToolName,
+		Description: codeActionDescription,
+		Parameters: map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The path of the file",
+			},
+			"line": map[string]any{
+				"type":        "integer",
+				"description": "The 1-based start line of the range, omit for the whole file",
+			},
+			"column": map[string]any{
+				"type":        "integer",
+				"description": "The 1-based start column of the range",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"description": "The 1-based end line of the range (defaults to line)",
+			},
+			"kind": map[string]any{
+				"type":        "string",
+				"description": "Only consider actions of this kind, e.g. \"quickfix\" or \"source.organizeImports\"",
+			},
+			"title": map[string]any{
+				"type":        "string",
+				"description": "The exact title of the action to apply, omit to list the available actions",
+			},
+		},
+		Required: []string{"file_path"},
+	}
+}
+
+func (t *codeActionTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params CodeActionParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.FilePath == "" {
+		return NewTextErrorResponse("file_path is required"), nil
+	}
+	if len(t.lspClients) == 0 {
+		return NewTextErrorResponse("no LSP clients available"), nil
+	}
+
+	path := params.FilePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.WorkingDirectory(), path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error reading file: %s", err)), nil
+	}
+	lines := strings.Split(string(content), "\n")
+
+	actionRange := protocol.Range{
+		End: protocol.Position{Line: uint32(len(lines) - 1), Character: uint32(len(lines[len(lines)-1]))},
+	}
+	if params.Line > 0 {
+		if params.Line > len(lines) {
+			return NewTextErrorResponse(fmt.Sprintf("line %d is out of range, the file has %d lines", params.Line, len(lines))), nil
+		}
+		endLine := max(params.EndLine, params.Line)
+		endLine = min(endLine, len(lines))
+		actionRange = protocol.Range{
+			Start: protocol.Position{Line: uint32(params.Line - 1), Character: uint32(max(params.Column-1, 0))},
+			End:   protocol.Position{Line: uint32(endLine - 1), Character: uint32(len(lines[endLine-1]))},
+		}
+	}
+	var only []protocol.CodeActionKind
+	if params.Kind != "" {
+		only = []protocol.CodeActionKind{protocol.CodeActionKind(params.Kind)}
+	}
+
+	var client *lsp.Client
+	var actions []protocol.CodeAction
+	var commandOnly []string
+	err = forEachNavigationClient(ctx, t.lspClients, path, func(c *lsp.Client) (bool, error) {
+		uri := protocol.DocumentUri("file://" + path)
+		result, err := c.CodeAction(ctx, protocol.CodeActionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Range:        actionRange,
+			Context: protocol.CodeActionContext{
+				Diagnostics: diagnosticsInRange(c.GetFileDiagnostics(uri), actionRange),
+				Only:        only,
+			},
+		})
+		if err != nil {
+			return false, err
+		}
+		actions, commandOnly = nil, nil
+		for _, item := range result {
+			switch v := item.Value.(type) {
+			case protocol.CodeAction:
+				if v.Disabled == nil && (params.Kind == "" || strings.HasPrefix(string(v.Kind), params.Kind)) {
+					actions = append(actions, v)
+				}
+			case protocol.Command:
+				commandOnly = append(commandOnly, v.Title)
+			}
+		}
+		client = c
+		return len(actions) > 0 || len(commandOnly) > 0, nil
+	})
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if len(actions) == 0 && len(commandOnly) == 0 {
+		return NewTextResponse("No code actions available"), nil
+	}
+
+	var selected *protocol.CodeAction
+	for i, action := range actions {
+		if params.Title != "" && action.Title == params.Title {
+			selected = &actions[i]
+			break
+		}
+	}
+	if selected == nil && params.Title == "" && params.Kind != "" && len(actions) == 1 {
+		selected = &actions[0]
+	}
+	if selected == nil {
+		if params.Title != "" && !slices.Contains(commandOnly, params.Title) {
+			return NewTextErrorResponse(fmt.Sprintf("no code action titled %q\n\n%s", params.Title, formatCodeActions(actions, commandOnly))), nil
+		}
+		if params.Title != "" {
+			return NewTextErrorResponse(fmt.Sprintf("%q only runs a server command and cannot be previewed", params.Title)), nil
+		}
+		return NewTextResponse(formatCodeActions(actions, commandOnly)), nil
+	}
+
+	if selected.Edit == nil && selected.Data != nil {
+		resolved, err := client.ResolveCodeAction(ctx, *selected)
+		if err != nil {
+			return NewTextErrorResponse(fmt.Sprintf("failed to resolve code action: %s", err)), nil
+		}
+		selected = &resolved
+	}
+	if selected.Edit == nil || (len(selected.Edit.Changes) == 0 && len(selected.Edit.DocumentChanges) == 0) {
+		return NewTextErrorResponse(fmt.Sprintf("%q only runs a server command and cannot be previewed", selected.Title)), nil
+	}
+
+	return applyWorkspaceEdit(ctx, CodeActionToolName, selected.Title, *selected.Edit, t.lspClients, t.permissions, t.files)
+}
+
+func diagnosticsInRange(diagnostics []protocol.Diagnostic, r protocol.Range) []protocol.Diagnostic {
+	result := []protocol.Diagnostic{}
+	for _, d := range diagnostics {
+		if d.Range.End.Line >= r.Start.Line && d.Range.Start.Line <= r.End.Line {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func formatCodeActions(actions []protocol.CodeAction, commandOnly []string) string {
+	var output strings.Builder
+	output.WriteString("Available code actions, call again with the title to apply one:\n")
+	for _, action := range actions {
+		kind := string(action.Kind)
+		if kind == "" {
+			kind = "action"
+		}
+		preferred := ""
+		if action.IsPreferred {
+			preferred = " (preferred)"
+		}
+		fmt.Fprintf(&output, "- [%s] %s%s\n", kind, action.Title, preferred)
+	}
+	for _, title := range commandOnly {
+		fmt.Fprintf(&output, "- [command, not supported] %s\n", title)
+	}
+	return strings.TrimSpace(output.String())
+}
+
+// applyWorkspaceEdit asks for permission with a diff of every affected file,
+// applies the edit and records the changes in the file history.
+func applyWorkspaceEdit(
+	ctx context.Context,
+	toolName string,
+	summary string,
+	edit protocol.WorkspaceEdit,
+	lspClients map[string]*lsp.Client,
+	permissions permission.Service,
+	files history.Service,
+) (ToolResponse, error) {
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for applying an edit")
+	}
+
+	fileEdits, err := util.PreviewWorkspaceEdit(edit)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to compute the edit: %s", err)), nil
+	}
+
+	var fileDiffs []diff.FileDiff
+	totalAdditions, totalRemovals := 0, 0
+	for _, fe := range fileEdits {
+		fileDiff, additions, removals := diff.GenerateDiff(fe.OldContent, fe.NewContent, fe.Path)
+		totalAdditions += additions
+		totalRemovals += removals
+		path := relativeToWorkingDir(fe.Path)
+		switch {
+		case fe.NewPath != "":
+			path = fmt.Sprintf("%s → %s", path, relativeToWorkingDir(fe.NewPath))
+		case fe.Deleted:
+			path += " (deleted)"
+		case fe.Created:
+			path += " (new)"
+		}
+		fileDiffs = append(fileDiffs, diff.FileDiff{Path: path, Diff: fileDiff})
+	}
+
+	p := permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        config.WorkingDirectory(),
+			ToolName:    toolName,
+			Action:      "write",
+			Description: fmt.Sprintf("%s (%d files)", summary, len(fileEdits)),
+			Params: WorkspaceEditPermissionsParams{
+				Summary: summary,
+				Files:   fileDiffs,
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	for _, fe := range fileEdits {
+		recordEditBaseline(ctx, fe.Path, lspClients)
+		if fe.NewPath != "" {
+			recordEditBaseline(ctx, fe.NewPath, lspClients)
+		}
+	}
+
+	// The previewed contents are written, so the files get the approved changes
+	if err := util.WriteFileEdits(fileEdits); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("failed to apply the edit: %s", err)), nil
+	}
+
+	var changedFiles []string
+	for _, fe := range fileEdits {
+		if fe.NewPath != "" {
+			recordFileHistory(ctx, files, sessionID, fe.Path, fe.OldContent, "")
+			recordFileHistory(ctx, files, sessionID, fe.NewPath, "", fe.NewContent)
+			changedFiles = append(changedFiles, fe.NewPath)
+			continue
+		}
+		recordFileHistory(ctx, files, sessionID, fe.Path, fe.OldContent, fe.NewContent)
+		if !fe.Deleted {
+			changedFiles = append(changedFiles, fe.Path)
+		}
+	}
+
+	// Let the language servers see the new content
+	for _, path := range changedFiles {
+		recordFileWrite(path)
+		recordFileRead(path)
+		for _, client := range lspClients {
+			if client.IsFileOpen(path) {
+				if err := client.NotifyChange(ctx, path); err != nil {
+					logging.Debug("Error notifying LSP of change", "file", path, "error", err)
+				}
+			}
+		}
+	}
+
+	result := fmt.Sprintf("%s: %d files changed, %d additions, %d removals\n", summary, len(fileEdits), totalAdditions, totalRemovals)
+	for _, fd := range fileDiffs {
+		result += "- " + fd.Path + "\n"
+	}
+	if len(changedFiles) > 0 {
+		waitForLspDiagnostics(ctx, changedFiles[0], lspClients)
+		result += getDiagnostics(changedFiles[0], lspClients)
+	}
+	return WithResponseMetadata(
+		NewTextResponse(strings.TrimSpace(result)),
+		WorkspaceEditResponseMetadata{
+			Files:     fileDiffs,
+			Additions: totalAdditions,
+			Removals:  totalRemovals,
+		},
+	), nil
+}
+
+// recordFileHistory stores the change of a file in the session history,
+// keeping an intermediate version when the file changed outside the session.
+func recordFileHistory(ctx context.Context, files history.Service, sessionID, path, oldContent, newContent string) {
+	file, err := files.GetByPathAndSession(ctx, path, sessionID)
+	if err != nil {
+		if _, err := files.Create(ctx, sessionID, path, oldContent); err != nil {
+			logging.Debug("Error creating file history", "error", err)
+			return
+		}
+	} else if file.Content != oldContent {
+		if _, err := files.CreateVersion(ctx, sessionID, path, oldContent); err != nil {
+			logging.Debug("Error creating file history version", "error", err)
+		}
+	}
+	if _, err := files.CreateVersion(ctx, sessionID, path, newContent); err != nil {
+		logging.Debug("Error creating file history version", "error", err)
+	}
+}
+
+func relativeToWorkingDir(path string) string {
+	if rel, err := filepath.Rel(config.WorkingDirectory(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+	"github.com/opencode-ai/opencode/internal/repomap"
+)
+
+type RepoMapParams struct {
+	Path      string `json:"path"`
+	MaxTokens int    `json:"max_tokens"`
+}
+
+type repoMapTool struct {
+	lspClients map[string]*lsp.Client
+}
+
+const (
+	RepoMapToolName    = "repo_map"
+	repoMapDescription = `Summarizes the structure of the repository: the top-level symbols (types, functions, classes...) of its source files, the most referenced first.
+
+WHEN TO USE THIS TOOL:
+- Use at the start of a task in an unfamiliar repository, to find where the important code lives
+- Use to get an overview of a directory before reading its files
+- Helpful to find the central types and functions other code depends on
+
+HOW TO USE:
+- Optionally provide a directory to map, defaults to the whole repository
+- Optionally provide a token budget for the map, defaults to 2048
+- The map lists files by importance, with the declaration line of their most referenced symbols
+
+FEATURES:
+- Symbols come from the language servers when they are available, and from built-in extractors otherwise
+- Symbols are ranked by how much the rest of the repository refers to them
+- Files ignored by .gitignore, hidden files and generated files are skipped
+
+LIMITATIONS:
+- References are found by name, symbols with common names may be ranked too high
+- Only top-level symbols are listed, not fields or nested declarations
+- Only the first 3000 source files are mapped
+
+TIPS:
+- Use the View tool to read the files of the symbols you are interested in
+- Use the Definition and References tools to follow a symbol precisely
+- Map a subdirectory with a larger budget for more detail`
+
+	// repoMapLspFiles is the number of files whose symbols are requested
+	// from the language servers, the others use the built-in extractors
+	repoMapLspFiles = 300
+	// repoMapTimeout bounds the time spent building a map
+	repoMapTimeout = 30 * time.Second
+)
+
+func NewRepoMapTool(lspClients map[string]*lsp.Client) BaseTool {
+	return &repoMapTool{
+		lspClients: lspClients,
+	}
+}
+
+func (r *repoMapTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        RepoMapToolName,
+		Description: repoMapDescription,
+		Parameters: map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The directory to map, defaults to the whole repository",
+			},
+			"max_tokens": map[string]any{
+				"type":        "integer",
+				"description": "The token budget of the map (default 2048)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (r *repoMapTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params RepoMapParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.MaxTokens < 0 {
+		return NewTextErrorResponse("max_tokens must be positive"), nil
+	}
+
+	root := config.WorkingDirectory()
+	dir := params.Path
+	if dir != "" && !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	// The map is of the repository, its symbols are ranked by the references
+	// of the other files
+	if dir != "" {
+		if rel, err := filepath.Rel(root, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return NewTextErrorResponse(fmt.Sprintf("path %s is outside the working directory", params.Path)), nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, repoMapTimeout)
+	defer cancel()
+	output, err := repomap.Generate(ctx, repomap.Options{
+		Root:      root,
+		Dir:       dir,
+		MaxTokens: params.MaxTokens,
+		Source:    lspSymbolSource(r.lspClients),
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return NewTextErrorResponse("timed out building the repository map, map a smaller directory"), nil
+		}
+		return NewTextErrorResponse(fmt.Sprintf("error building the repository map: %s", err)), nil
+	}
+	if output == "" {
+		output = "No symbols found"
+	}
+	return NewTextResponse(output), nil
+}
+
+// lspSymbolSource returns the top-level symbols of files from the ready
+// language servers handling them. Go files are parsed by the built-in
+// extractor, which is exact and faster than a request.
+func lspSymbolSource(lsps map[string]*lsp.Client) repomap.SymbolSource {
+	if len(lsps) == 0 {
+		return nil
+	}
+	var requested atomic.Int32
+	return func(ctx context.Context, path string, _ []byte) ([]repomap.Symbol, bool) {
+		if strings.EqualFold(filepath.Ext(path), ".go") {
+			return nil, false
+		}
+		for _, name := range sortedClientNames(lsps) {
+			client := lsps[name]
+			if client.GetServerState() != lsp.StateReady || !client.HandlesFile(path) {
+				continue
+			}
+			if requested.Add(1) > repoMapLspFiles {
+				return nil, false
+			}
+			if symbols, ok := documentSymbols(ctx, client, path); ok {
+				return symbols, true
+			}
+		}
+		return nil, false
+	}
+}
+
+// documentSymbols requests the top-level symbols of a file, opening it for
+// the request when it isn't open yet
+func documentSymbols(ctx context.Context, client *lsp.Client, path string) ([]repomap.Symbol, bool) {
+	if !client.IsFileOpen(path) {
+		if err := client.OpenFile(ctx, path); err != nil {
+			return nil, false
+		}
+		defer client.CloseFile(ctx, path)
+	}
+
+	result, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentUri("file://" + path)},
+	})
+	if err != nil {
+		return nil, false
+	}
+
+	var symbols []repomap.Symbol
+	switch v := result.Value.(type) {
+	case []protocol.DocumentSymbol:
+		for _, s := range v {
+			symbols = append(symbols, repomap.Symbol{
+				Name: symbolBaseName(symbolNameReplacer.Replace(s.Name)),
+				Kind: symbolKindName(s.Kind),
+				Line: int(s.SelectionRange.Start.Line) + 1,
+			})
+		}
+	case []protocol.SymbolInformation:
+		for _, s := range v {
+			if s.ContainerName != "" {
+				continue
+			}
+			symbols = append(symbols, repomap.Symbol{
+				Name: symbolBaseName(symbolNameReplacer.Replace(s.Name)),
+				Kind: symbolKindName(s.Kind),
+				Line: int(s.Location.Range.Start.Line) + 1,
+			})
+		}
+	default:
+		return nil, false
+	}
+	return symbols, true
+}
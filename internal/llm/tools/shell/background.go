@@ -0,0 +1,328 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/pubsub"
+)
+
+type JobStatus string
+
+const (
+	JobRunning JobStatus = "running"
+	JobExited  JobStatus = "exited"
+	JobKilled  JobStatus = "killed"
+)
+
+const (
+	// MaxBackgroundJobs limits how many jobs can run at the same time.
+	MaxBackgroundJobs = 10
+	// maxJobOutput is the amount of output kept in memory for each job, older
+	// output is discarded once the limit is reached.
+	maxJobOutput = 1024 * 1024
+	// killGracePeriod is how long a job gets to exit after SIGTERM before it
+	// is sent SIGKILL.
+	killGracePeriod = 3 * time.Second
+)
+
+var ErrJobNotFound = errors.New("background job not found")
+
+// BackgroundJob is a snapshot of a command running outside the persistent shell.
+type BackgroundJob struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command"`
+	Cwd       string    `json:"cwd"`
+	PID       int       `json:"pid"`
+	Status    JobStatus `json:"status"`
+	ExitCode  int       `json:"exit_code"`
+	StartedAt int64     `json:"started_at"`
+	EndedAt   int64     `json:"ended_at,omitempty"`
+}
+
+// BackgroundService manages long-running commands such as dev servers or
+// watchers that must keep running while the agent does other work.
+type BackgroundService interface {
+	pubsub.Suscriber[BackgroundJob]
+	Start(command, cwd string) (BackgroundJob, error)
+	Get(id string) (BackgroundJob, error)
+	// ReadOutput returns the output produced since the previous call for the job.
+	ReadOutput(id string) (string, BackgroundJob, error)
+	WriteInput(id, input string) error
+	Kill(id string) error
+	List() []BackgroundJob
+	Shutdown()
+}
+
+type backgroundJob struct {
+	BackgroundJob
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	done  chan struct{}
+
+	mu         sync.Mutex
+	output     []byte
+	readOffset int
+	killed     bool
+}
+
+// Write implements io.Writer so the job can be used as the combined
+// stdout/stderr of the command.
+func (j *backgroundJob) Write(p []byte) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.output = append(j.output, p...)
+	if overflow := len(j.output) - maxJobOutput; overflow > 0 {
+		j.output = j.output[overflow:]
+		j.readOffset = max(0, j.readOffset-overflow)
+	}
+	return len(p), nil
+}
+
+type backgroundService struct {
+	*pubsub.Broker[BackgroundJob]
+
+	mu     sync.RWMutex
+	jobs   map[string]*backgroundJob
+	nextID int
+}
+
+func NewBackgroundService() BackgroundService {
+	return &backgroundService{
+		Broker: pubsub.NewBroker[BackgroundJob](),
+		jobs:   make(map[string]*backgroundJob),
+	}
+}
+
+func (s *backgroundService) Start(command, cwd string) (BackgroundJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	running := 0
+	for _, job := range s.jobs {
+		if job.snapshot().Status == JobRunning {
+			running++
+		}
+	}
+	if running >= MaxBackgroundJobs {
+		return BackgroundJob{}, fmt.Errorf("too many background jobs running (max %d), kill one first", MaxBackgroundJobs)
+	}
+
+	shellPath, shellArgs := shellCommand()
+	args := append(append([]string{}, shellArgs...), "-c", command)
+	cmd := exec.Command(shellPath, args...)
+	cmd.Dir = cwd
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	setProcessGroup(cmd)
+	if err := applySandbox(cmd); err != nil {
+		return BackgroundJob{}, err
+	}
+
+	s.nextID++
+	job := &backgroundJob{
+		BackgroundJob: BackgroundJob{
+			ID:      fmt.Sprintf("bg-%d", s.nextID),
+			Command: command,
+			Cwd:     cwd,
+			Status:  JobRunning,
+		},
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+	cmd.Stdout = job
+	cmd.Stderr = job
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return BackgroundJob{}, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	job.stdin = stdin
+
+	if err := cmd.Start(); err != nil {
+		return BackgroundJob{}, fmt.Errorf("failed to start command: %w", err)
+	}
+	job.PID = cmd.Process.Pid
+	job.StartedAt = time.Now().Unix()
+	s.jobs[job.ID] = job
+
+	go s.wait(job)
+
+	snapshot := job.snapshot()
+	s.Publish(pubsub.CreatedEvent, snapshot)
+	return snapshot, nil
+}
+
+func (s *backgroundService) wait(job *backgroundJob) {
+	defer logging.RecoverPanic("background-job-"+job.ID, nil)
+
+	err := job.cmd.Wait()
+
+	job.mu.Lock()
+	job.EndedAt = time.Now().Unix()
+	job.ExitCode = job.cmd.ProcessState.ExitCode()
+	if job.killed {
+		job.Status = JobKilled
+	} else {
+		job.Status = JobExited
+	}
+	job.mu.Unlock()
+	close(job.done)
+
+	if err != nil {
+		logging.Debug("Background job finished with error", "id", job.ID, "error", err)
+	}
+	s.Publish(pubsub.UpdatedEvent, job.snapshot())
+}
+
+func (s *backgroundService) job(id string) (*backgroundJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (s *backgroundService) Get(id string) (BackgroundJob, error) {
+	job, err := s.job(id)
+	if err != nil {
+		return BackgroundJob{}, err
+	}
+	return job.snapshot(), nil
+}
+
+func (s *backgroundService) ReadOutput(id string) (string, BackgroundJob, error) {
+	job, err := s.job(id)
+	if err != nil {
+		return "", BackgroundJob{}, err
+	}
+
+	job.mu.Lock()
+	output := string(job.output[job.readOffset:])
+	job.readOffset = len(job.output)
+	job.mu.Unlock()
+
+	return output, job.snapshot(), nil
+}
+
+func (s *backgroundService) WriteInput(id, input string) error {
+	job, err := s.job(id)
+	if err != nil {
+		return err
+	}
+	if job.snapshot().Status != JobRunning {
+		return fmt.Errorf("job %s is not running", id)
+	}
+	if _, err := io.WriteString(job.stdin, input); err != nil {
+		return fmt.Errorf("failed to write to job %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *backgroundService) Kill(id string) error {
+	job, err := s.job(id)
+	if err != nil {
+		return err
+	}
+	job.kill()
+	return nil
+}
+
+func (s *backgroundService) List() []BackgroundJob {
+	s.mu.RLock()
+	jobs := make([]BackgroundJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.snapshot())
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt < jobs[j].StartedAt ||
+			(jobs[i].StartedAt == jobs[j].StartedAt && jobs[i].ID < jobs[j].ID)
+	})
+	return jobs
+}
+
+// Shutdown kills every running job and waits for them to exit.
+func (s *backgroundService) Shutdown() {
+	s.mu.RLock()
+	jobs := make([]*backgroundJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	s.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job.kill()
+		}()
+	}
+	wg.Wait()
+	s.Broker.Shutdown()
+}
+
+func (j *backgroundJob) snapshot() BackgroundJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.BackgroundJob
+}
+
+// kill sends SIGTERM to the job's process group, escalating to SIGKILL if it
+// doesn't exit within the grace period.
+func (j *backgroundJob) kill() {
+	select {
+	case <-j.done:
+		return
+	default:
+	}
+
+	j.mu.Lock()
+	j.killed = true
+	j.mu.Unlock()
+
+	terminateProcessGroup(j.cmd)
+	j.stdin.Close()
+
+	select {
+	case <-j.done:
+	case <-time.After(killGracePeriod):
+		killProcessGroup(j.cmd)
+		<-j.done
+	}
+}
+
+// shellCommand returns the shell binary and arguments configured for the bash tool.
+func shellCommand() (string, []string) {
+	cfg := config.Get()
+
+	var shellPath string
+	var shellArgs []string
+	if cfg != nil {
+		shellPath = cfg.Shell.Path
+		shellArgs = cfg.Shell.Args
+	}
+
+	if shellPath == "" {
+		shellPath = os.Getenv("SHELL")
+		if shellPath == "" {
+			shellPath = "/bin/bash"
+		}
+	}
+	if len(shellArgs) == 0 {
+		shellArgs = []string{"-l"}
+	}
+	return shellPath, shellArgs
+}
@@ -0,0 +1,64 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForOutput(t *testing.T, s BackgroundService, id, want string) string {
+	t.Helper()
+	var output string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		chunk, _, err := s.ReadOutput(id)
+		require.NoError(t, err)
+		output += chunk
+		if strings.Contains(output, want) {
+			return output
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %q, got %q", want, output)
+	return ""
+}
+
+func TestBackgroundService(t *testing.T) {
+	s := NewBackgroundService()
+	defer s.Shutdown()
+
+	t.Run("reads incremental output and sends input", func(t *testing.T) {
+		job, err := s.Start(`echo ready; read line; echo "got $line"`, t.TempDir())
+		require.NoError(t, err)
+		assert.Equal(t, JobRunning, job.Status)
+
+		waitForOutput(t, s, job.ID, "ready")
+
+		require.NoError(t, s.WriteInput(job.ID, "ping\n"))
+		output := waitForOutput(t, s, job.ID, "got ping")
+		assert.NotContains(t, output, "ready")
+
+		require.Eventually(t, func() bool {
+			j, err := s.Get(job.ID)
+			return err == nil && j.Status == JobExited
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("kills running jobs", func(t *testing.T) {
+		job, err := s.Start("sleep 60", t.TempDir())
+		require.NoError(t, err)
+
+		require.NoError(t, s.Kill(job.ID))
+		job, err = s.Get(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobKilled, job.Status)
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		_, _, err := s.ReadOutput("bg-missing")
+		assert.ErrorIs(t, err, ErrJobNotFound)
+	})
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package shell
+
+import "os/exec"
+
+// setProcessGroup does nothing on Windows, which has no process groups.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup kills the command, Windows has no SIGTERM.
+func terminateProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
+
+// killProcessGroup kills the command.
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
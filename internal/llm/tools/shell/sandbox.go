@@ -0,0 +1,102 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+)
+
+// Sandbox backends that can be selected with the sandbox.backend config option.
+const (
+	SandboxAuto     = "auto"
+	SandboxBwrap    = "bwrap"
+	SandboxLandlock = "landlock"
+)
+
+// SandboxExecCommand is the hidden opencode subcommand used by the landlock
+// backend to restrict itself before executing the sandboxed shell.
+const SandboxExecCommand = "sandbox-exec"
+
+var ErrSandboxUnavailable = errors.New("sandbox is not available on this system")
+
+// SandboxPolicy describes what a sandboxed command is allowed to do. Reading
+// the filesystem is always allowed, writing is limited to WritablePaths.
+type SandboxPolicy struct {
+	Backend       string
+	WritablePaths []string
+	AllowNetwork  bool
+}
+
+// SandboxEnabled reports whether bash tool commands run inside the sandbox.
+func SandboxEnabled() bool {
+	cfg := config.Get()
+	return cfg != nil && cfg.Sandbox.Enabled
+}
+
+// sandboxPolicy builds the policy for commands started in cwd from the
+// sandbox configuration. It returns nil when the sandbox is disabled.
+func sandboxPolicy(cwd string) *SandboxPolicy {
+	if !SandboxEnabled() {
+		return nil
+	}
+	sandbox := config.Get().Sandbox
+
+	policy := &SandboxPolicy{
+		Backend:      sandbox.Backend,
+		AllowNetwork: sandbox.AllowNetwork,
+	}
+	if policy.Backend == "" {
+		policy.Backend = SandboxAuto
+	}
+
+	// The persistent shell writes command output to files in the temp directory.
+	paths := []string{config.WorkingDirectory(), cwd, os.TempDir()}
+	for _, path := range sandbox.WritablePaths {
+		if strings.HasPrefix(path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, path[2:])
+			}
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(config.WorkingDirectory(), path)
+		}
+		paths = append(paths, path)
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		path = filepath.Clean(path)
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		policy.WritablePaths = append(policy.WritablePaths, path)
+	}
+	return policy
+}
+
+// applySandbox rewrites cmd so that it runs inside the configured sandbox.
+// It must be called before the command is started and fails closed: if the
+// sandbox is enabled but can't be set up, an error is returned and the command
+// must not be run.
+func applySandbox(cmd *exec.Cmd) error {
+	policy := sandboxPolicy(cmd.Dir)
+	if policy == nil {
+		return nil
+	}
+	if err := policy.wrap(cmd); err != nil {
+		return fmt.Errorf("failed to sandbox command: %w", err)
+	}
+	return nil
+}
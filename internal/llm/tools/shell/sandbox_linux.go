@@ -0,0 +1,242 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Landlock ABI versions that introduced the access rights we rely on.
+const (
+	landlockABIRefer    = 2
+	landlockABITruncate = 3
+	landlockABINetwork  = 4
+)
+
+func (p *SandboxPolicy) wrap(cmd *exec.Cmd) error {
+	switch p.Backend {
+	case SandboxBwrap:
+		return p.wrapBwrap(cmd)
+	case SandboxLandlock:
+		return p.wrapLandlock(cmd)
+	case SandboxAuto:
+		if _, err := exec.LookPath("bwrap"); err == nil {
+			return p.wrapBwrap(cmd)
+		}
+		if landlockABI() > 0 {
+			return p.wrapLandlock(cmd)
+		}
+		return ErrSandboxUnavailable
+	default:
+		return fmt.Errorf("unknown sandbox backend: %s", p.Backend)
+	}
+}
+
+func (p *SandboxPolicy) wrapBwrap(cmd *exec.Cmd) error {
+	bwrap, err := exec.LookPath("bwrap")
+	if err != nil {
+		return fmt.Errorf("bwrap not found: %w", err)
+	}
+	cmd.Args = p.bwrapArgs(cmd)
+	cmd.Path = bwrap
+	return nil
+}
+
+// bwrapArgs builds a bubblewrap invocation that mounts the whole filesystem
+// read-only, binds the writable paths on top and, unless network access is
+// allowed, runs the command in an empty network namespace.
+func (p *SandboxPolicy) bwrapArgs(cmd *exec.Cmd) []string {
+	args := []string{
+		"bwrap",
+		"--die-with-parent",
+		"--ro-bind", "/", "/",
+		"--dev-bind", "/dev", "/dev",
+	}
+	for _, path := range p.WritablePaths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		args = append(args, "--bind", path, path)
+	}
+	if !p.AllowNetwork {
+		args = append(args, "--unshare-net")
+	}
+	if cmd.Dir != "" {
+		args = append(args, "--chdir", cmd.Dir)
+	}
+	args = append(args, "--", cmd.Path)
+	return append(args, cmd.Args[1:]...)
+}
+
+// wrapLandlock re-executes opencode through the hidden sandbox-exec command,
+// which restricts itself with landlock and then executes the original command.
+func (p *SandboxPolicy) wrapLandlock(cmd *exec.Cmd) error {
+	abi := landlockABI()
+	if abi <= 0 {
+		return ErrSandboxUnavailable
+	}
+	if !p.AllowNetwork && abi < landlockABINetwork {
+		return fmt.Errorf("landlock ABI %d can't restrict network access, kernel 6.7 or newer is required", abi)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find opencode executable: %w", err)
+	}
+
+	args := []string{exe, SandboxExecCommand}
+	for _, path := range p.WritablePaths {
+		args = append(args, "--write", path)
+	}
+	if p.AllowNetwork {
+		args = append(args, "--allow-network")
+	}
+	args = append(args, "--", cmd.Path)
+
+	cmd.Args = append(args, cmd.Args[1:]...)
+	cmd.Path = exe
+	return nil
+}
+
+// ExecSandboxed implements the sandbox-exec command. It restricts the current
+// process with landlock according to the flags in args and replaces it with
+// the command following "--". It only returns on failure.
+func ExecSandboxed(args []string) error {
+	policy := &SandboxPolicy{Backend: SandboxLandlock}
+	for len(args) > 0 {
+		arg := args[0]
+		args = args[1:]
+		switch arg {
+		case "--write":
+			if len(args) == 0 {
+				return errors.New("--write requires a path")
+			}
+			policy.WritablePaths = append(policy.WritablePaths, args[0])
+			args = args[1:]
+		case "--allow-network":
+			policy.AllowNetwork = true
+		case "--":
+			if len(args) == 0 {
+				return errors.New("no command given")
+			}
+			// Landlock and no_new_privs apply to the calling thread, which must
+			// also be the one that executes the command.
+			runtime.LockOSThread()
+			if err := policy.restrictSelf(); err != nil {
+				return err
+			}
+			return syscall.Exec(args[0], args, os.Environ())
+		default:
+			return fmt.Errorf("unknown argument: %s", arg)
+		}
+	}
+	return errors.New("no command given")
+}
+
+// landlockABI returns the landlock ABI version supported by the kernel, or 0
+// if landlock isn't available.
+func landlockABI() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+func (p *SandboxPolicy) restrictSelf() error {
+	abi := landlockABI()
+	if abi <= 0 {
+		return ErrSandboxUnavailable
+	}
+
+	// Only write access is handled, so reading and executing stay allowed everywhere.
+	var writeAccess uint64 = unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+	var fileAccess uint64 = unix.LANDLOCK_ACCESS_FS_WRITE_FILE
+	if abi >= landlockABIRefer {
+		writeAccess |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= landlockABITruncate {
+		writeAccess |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+		fileAccess |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: writeAccess}
+	if !p.AllowNetwork {
+		if abi < landlockABINetwork {
+			return fmt.Errorf("landlock ABI %d can't restrict network access", abi)
+		}
+		attr.Access_net = unix.LANDLOCK_ACCESS_NET_BIND_TCP | unix.LANDLOCK_ACCESS_NET_CONNECT_TCP
+	}
+
+	rulesetFd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(rulesetFd))
+
+	for _, path := range p.WritablePaths {
+		if err := addLandlockRule(int(rulesetFd), path, writeAccess, fileAccess); err != nil {
+			return err
+		}
+	}
+	// Commands commonly write to /dev/null and the terminal.
+	if err := addLandlockRule(int(rulesetFd), "/dev", fileAccess, fileAccess); err != nil {
+		return err
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, rulesetFd, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+// addLandlockRule allows access beneath path. Files only accept file rights,
+// so fileAccess is used instead of dirAccess when path isn't a directory.
+// Paths that don't exist are skipped.
+func addLandlockRule(rulesetFd int, path string, dirAccess, fileAccess uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	var stat unix.Stat_t
+	if err := unix.Fstat(fd, &stat); err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	access := dirAccess
+	if stat.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access = fileAccess
+	}
+
+	rule := unix.LandlockPathBeneathAttr{
+		Allowed_access: access,
+		Parent_fd:      int32(fd),
+	}
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFd), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to add landlock rule for %s: %w", path, errno)
+	}
+	return nil
+}
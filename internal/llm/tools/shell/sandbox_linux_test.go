@@ -0,0 +1,78 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain lets the test binary act as the sandbox-exec command, which is how
+// the landlock backend re-executes opencode.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == SandboxExecCommand {
+		err := ExecSandboxed(os.Args[2:])
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestBwrapArgs(t *testing.T) {
+	writable := t.TempDir()
+	policy := &SandboxPolicy{
+		Backend:       SandboxBwrap,
+		WritablePaths: []string{writable, filepath.Join(writable, "missing")},
+	}
+	cmd := exec.Command("/bin/sh", "-c", "true")
+	cmd.Dir = writable
+
+	args := policy.bwrapArgs(cmd)
+	assert.Equal(t, []string{
+		"bwrap",
+		"--die-with-parent",
+		"--ro-bind", "/", "/",
+		"--dev-bind", "/dev", "/dev",
+		"--bind", writable, writable,
+		"--unshare-net",
+		"--chdir", writable,
+		"--", "/bin/sh", "-c", "true",
+	}, args)
+
+	policy.AllowNetwork = true
+	assert.NotContains(t, policy.bwrapArgs(cmd), "--unshare-net")
+}
+
+func TestLandlockSandbox(t *testing.T) {
+	abi := landlockABI()
+	if abi < landlockABINetwork {
+		t.Skipf("landlock ABI %d doesn't support the network restrictions", abi)
+	}
+
+	writable := t.TempDir()
+	readOnly := t.TempDir()
+	policy := &SandboxPolicy{
+		Backend:       SandboxLandlock,
+		WritablePaths: []string{writable},
+	}
+
+	run := func(script string) (string, error) {
+		cmd := exec.Command("/bin/sh", "-c", script)
+		cmd.Dir = writable
+		require.NoError(t, policy.wrap(cmd))
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	output, err := run("echo ok > allowed && cat allowed && echo discarded > /dev/null")
+	require.NoError(t, err, output)
+	assert.Equal(t, "ok\n", output)
+
+	_, err = run(fmt.Sprintf("echo no > %s", filepath.Join(readOnly, "denied")))
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(readOnly, "denied"))
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package shell
+
+import "os/exec"
+
+func (p *SandboxPolicy) wrap(cmd *exec.Cmd) error {
+	return ErrSandboxUnavailable
+}
+
+// ExecSandboxed implements the sandbox-exec command, which is only supported on Linux.
+func ExecSandboxed(args []string) error {
+	return ErrSandboxUnavailable
+}
@@ -12,7 +12,7 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/logging"
 )
 
 type PersistentShell struct {
@@ -59,29 +59,7 @@ func GetPersistentShell(workingDir string) *PersistentShell {
 }
 
 func newPersistentShell(cwd string) *PersistentShell {
-	// Get shell configuration from config
-	cfg := config.Get()
-	
-	// Default to environment variable if config is not set or nil
-	var shellPath string
-	var shellArgs []string
-	
-	if cfg != nil {
-		shellPath = cfg.Shell.Path
-		shellArgs = cfg.Shell.Args
-	}
-	
-	if shellPath == "" {
-		shellPath = os.Getenv("SHELL")
-		if shellPath == "" {
-			shellPath = "/bin/bash"
-		}
-	}
-	
-	// Default shell args
-	if len(shellArgs) == 0 {
-		shellArgs = []string{"-l"}
-	}
+	shellPath, shellArgs := shellCommand()
 
 	cmd := exec.Command(shellPath, shellArgs...)
 	cmd.Dir = cwd
@@ -93,6 +71,11 @@ func newPersistentShell(cwd string) *PersistentShell {
 
 	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
 
+	if err := applySandbox(cmd); err != nil {
+		logging.ErrorPersist(err.Error())
+		return nil
+	}
+
 	err = cmd.Start()
 	if err != nil {
 		return nil
@@ -32,6 +32,15 @@ type ToolResponse struct {
 	Content  string           `json:"content"`
 	Metadata string           `json:"metadata,omitempty"`
 	IsError  bool             `json:"is_error"`
+	// Images are sent to the models that support attachments, the content
+	// describes them for the other models
+	Images []ToolImage `json:"images,omitempty"`
+}
+
+// ToolImage is an image returned by a tool
+type ToolImage struct {
+	MIMEType string `json:"mime_type"`
+	Data     []byte `json:"data"`
 }
 
 func NewTextResponse(content string) ToolResponse {
@@ -41,6 +50,14 @@ func NewTextResponse(content string) ToolResponse {
 	}
 }
 
+func NewImageResponse(content string, images ...ToolImage) ToolResponse {
+	return ToolResponse{
+		Type:    ToolResponseTypeImage,
+		Content: content,
+		Images:  images,
+	}
+}
+
 func WithResponseMetadata(response ToolResponse, metadata any) ToolResponse {
 	if metadata != nil {
 		metadataBytes, err := json.Marshal(metadata)
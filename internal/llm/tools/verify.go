@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/lsp"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+)
+
+const (
+	// editBaselineTimeout bounds the wait for the diagnostics of a file before
+	// its first modification
+	editBaselineTimeout = 2 * time.Second
+	// verifyTimeout bounds the wait for the diagnostics of all the files
+	// modified in a turn. Files left once it is spent are checked against the
+	// diagnostics received so far.
+	verifyTimeout = 10 * time.Second
+)
+
+// editBaselines holds, for each session, the diagnostics of the files modified
+// by the agent as they were before their first modification
+var (
+	editBaselines      = make(map[string]map[string][]protocol.Diagnostic)
+	editBaselinesMutex sync.Mutex
+)
+
+// recordEditBaseline records the diagnostics of a file the agent is about to
+// modify, unless it was already modified since the baselines were cleared.
+// Nothing is recorded when verification is disabled.
+func recordEditBaseline(ctx context.Context, filePath string, lsps map[string]*lsp.Client) {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Verify.Enabled || len(lsps) == 0 {
+		return
+	}
+	sessionID, _ := GetContextValues(ctx)
+	if sessionID == "" {
+		return
+	}
+
+	editBaselinesMutex.Lock()
+	_, recorded := editBaselines[sessionID][filePath]
+	editBaselinesMutex.Unlock()
+	if recorded {
+		return
+	}
+
+	// A new file has no diagnostics, an existing one may not have been
+	// analyzed yet
+	baseline := []protocol.Diagnostic{}
+	if _, err := os.Stat(filePath); err == nil {
+		waitCtx, cancel := context.WithTimeout(ctx, editBaselineTimeout)
+		waitForLspDiagnostics(waitCtx, filePath, lsps)
+		cancel()
+		baseline = fileDiagnostics(filePath, lsps)
+	}
+
+	editBaselinesMutex.Lock()
+	defer editBaselinesMutex.Unlock()
+	if editBaselines[sessionID] == nil {
+		editBaselines[sessionID] = make(map[string][]protocol.Diagnostic)
+	}
+	if _, recorded := editBaselines[sessionID][filePath]; !recorded {
+		editBaselines[sessionID][filePath] = baseline
+	}
+}
+
+// ClearEditBaselines forgets the files modified in a session
+func ClearEditBaselines(sessionID string) {
+	editBaselinesMutex.Lock()
+	defer editBaselinesMutex.Unlock()
+	delete(editBaselines, sessionID)
+}
+
+// VerifyEdits collects the errors introduced in the files modified in a
+// session since the baselines were cleared. It returns a report of them, or an
+// empty string when the edits introduced no errors.
+func VerifyEdits(ctx context.Context, sessionID string, lsps map[string]*lsp.Client) string {
+	editBaselinesMutex.Lock()
+	baselines := maps.Clone(editBaselines[sessionID])
+	editBaselinesMutex.Unlock()
+
+	paths := make([]string, 0, len(baselines))
+	for path := range baselines {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	waitCtx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+
+	var errors []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			// Deleted files have no diagnostics
+			continue
+		}
+		if waitCtx.Err() == nil {
+			waitForLspDiagnostics(waitCtx, path, lsps)
+		}
+		for _, diagnostic := range introducedErrors(baselines[path], fileDiagnostics(path, lsps)) {
+			errors = append(errors, formatDiagnostic(path, diagnostic, ""))
+		}
+	}
+	if len(errors) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"Your changes introduced %d errors in the files you modified. Fix them before finishing:\n<introduced_errors>\n%s\n</introduced_errors>",
+		len(errors),
+		strings.Join(errors, "\n"),
+	)
+}
+
+// fileDiagnostics returns the diagnostics of a file from all the servers
+// handling it
+func fileDiagnostics(filePath string, lsps map[string]*lsp.Client) []protocol.Diagnostic {
+	var diagnostics []protocol.Diagnostic
+	for _, name := range sortedClientNames(lsps) {
+		client := lsps[name]
+		if !client.HandlesFile(filePath) {
+			continue
+		}
+		for uri, diags := range client.GetDiagnostics() {
+			if uri.Path() == filePath {
+				diagnostics = append(diagnostics, diags...)
+			}
+		}
+	}
+	return diagnostics
+}
+
+// introducedErrors returns the errors of current that are not in baseline.
+// Diagnostics are compared without their position, which edits shift, so an
+// error is new when it occurs more often than before.
+func introducedErrors(baseline, current []protocol.Diagnostic) []protocol.Diagnostic {
+	known := make(map[string]int)
+	for _, diagnostic := range baseline {
+		if diagnostic.Severity == protocol.SeverityError {
+			known[diagnosticKey(diagnostic)]++
+		}
+	}
+
+	var introduced []protocol.Diagnostic
+	for _, diagnostic := range current {
+		if diagnostic.Severity != protocol.SeverityError {
+			continue
+		}
+		key := diagnosticKey(diagnostic)
+		if known[key] > 0 {
+			known[key]--
+			continue
+		}
+		introduced = append(introduced, diagnostic)
+	}
+	return introduced
+}
+
+func diagnosticKey(diagnostic protocol.Diagnostic) string {
+	return fmt.Sprintf("%s\x00%v\x00%s", diagnostic.Source, diagnostic.Code, diagnostic.Message)
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntroducedErrors(t *testing.T) {
+	diagnostic := func(line uint32, severity protocol.DiagnosticSeverity, msg string) protocol.Diagnostic {
+		return protocol.Diagnostic{
+			Range:    protocol.Range{Start: protocol.Position{Line: line}},
+			Severity: severity,
+			Source:   "compiler",
+			Message:  msg,
+		}
+	}
+
+	baseline := []protocol.Diagnostic{
+		diagnostic(3, protocol.SeverityError, "undefined: foo"),
+		diagnostic(8, protocol.SeverityWarning, "unused variable"),
+	}
+	current := []protocol.Diagnostic{
+		// Moved by the edit, but not new
+		diagnostic(5, protocol.SeverityError, "undefined: foo"),
+		diagnostic(9, protocol.SeverityError, "undefined: foo"),
+		diagnostic(1, protocol.SeverityError, "\"strings\" imported and not used"),
+		diagnostic(12, protocol.SeverityWarning, "unreachable code"),
+	}
+
+	introduced := introducedErrors(baseline, current)
+	assert.Len(t, introduced, 2)
+	assert.Equal(t, uint32(9), introduced[0].Range.Start.Line)
+	assert.Equal(t, "\"strings\" imported and not used", introduced[1].Message)
+
+	assert.Empty(t, introducedErrors(current, baseline))
+}
+
+func TestVerifyEdits(t *testing.T) {
+	dir := t.TempDir()
+	lsps := newTestLSPClient(t, dir)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "session")
+	t.Cleanup(func() { ClearEditBaselines("session") })
+
+	existing := filepath.Join(dir, "existing.go")
+	created := filepath.Join(dir, "created.go")
+	require.NoError(t, os.WriteFile(existing, []byte("package main\nERROR old\n"), 0o644))
+
+	// Nothing is recorded while verification is disabled
+	recordEditBaseline(ctx, existing, lsps)
+	require.NoError(t, os.WriteFile(existing, []byte("package main\nERROR old\nERROR new\n"), 0o644))
+	assert.Empty(t, VerifyEdits(ctx, "session", lsps))
+
+	cfg := config.Get()
+	cfg.Verify.Enabled = true
+	t.Cleanup(func() { cfg.Verify.Enabled = false })
+
+	require.NoError(t, os.WriteFile(existing, []byte("package main\nERROR old\n"), 0o644))
+	recordEditBaseline(ctx, existing, lsps)
+	recordEditBaseline(ctx, created, lsps)
+	assert.Empty(t, VerifyEdits(ctx, "session", lsps))
+
+	// Only the first baseline of a file is kept
+	require.NoError(t, os.WriteFile(existing, []byte("package main\nERROR old\nERROR new\n"), 0o644))
+	recordEditBaseline(ctx, existing, lsps)
+	require.NoError(t, os.WriteFile(created, []byte("package main\n\nERROR created\n"), 0o644))
+	report := VerifyEdits(ctx, "session", lsps)
+	assert.Contains(t, report, "Your changes introduced 2 errors")
+	assert.Contains(t, report, "ERROR new")
+	assert.Contains(t, report, "ERROR created")
+	assert.NotContains(t, report, "ERROR old")
+
+	// Other sessions have their own baselines
+	assert.Empty(t, VerifyEdits(ctx, "other", lsps))
+
+	ClearEditBaselines("session")
+	assert.Empty(t, VerifyEdits(ctx, "session", lsps))
+}
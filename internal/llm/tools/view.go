@@ -41,6 +41,7 @@ WHEN TO USE THIS TOOL:
 - Use when you need to read the contents of a specific file
 - Helpful for examining source code, configuration files, or log files
 - Perfect for looking at text-based file formats
+- Use to look at images such as screenshots, rendered charts or diagrams
 
 HOW TO USE:
 - Provide the path to the file you want to view
@@ -58,8 +59,9 @@ LIMITATIONS:
 - Maximum file size is 250KB
 - Default reading limit is 2000 lines
 - Lines longer than 2000 characters are truncated
-- Cannot display binary files or images
-- Images can be identified but not displayed
+- Cannot display binary files
+- PNG, JPEG, GIF and WebP images up to 5MB are shown to models that support images
+- Other images can be identified but not displayed
 
 TIPS:
 - Use with Glob tool to first find files you want to view
@@ -150,6 +152,13 @@ func (v *viewTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		return NewTextErrorResponse(fmt.Sprintf("Path is a directory, not a file: %s", filePath)), nil
 	}
 
+	// Check if it's an image file, which is returned as an image to the
+	// models that can see it
+	isImage, imageType := isImageFile(filePath)
+	if isImage {
+		return readImageFile(filePath, fileInfo.Size(), imageType)
+	}
+
 	// Check file size
 	if fileInfo.Size() > MaxReadSize {
 		return NewTextErrorResponse(fmt.Sprintf("File is too large (%d bytes). Maximum size is %d bytes",
@@ -161,13 +170,6 @@ func (v *viewTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		params.Limit = DefaultReadLimit
 	}
 
-	// Check if it's an image file
-	isImage, imageType := isImageFile(filePath)
-	// TODO: handle images
-	if isImage {
-		return NewTextErrorResponse(fmt.Sprintf("This is an image file of type: %s\nUse a different tool to process images", imageType)), nil
-	}
-
 	// Read the file content
 	content, lineCount, err := readTextFile(filePath, params.Offset, params.Limit)
 	if err != nil {
@@ -271,6 +273,23 @@ func readTextFile(filePath string, offset, limit int) (string, int, error) {
 	return strings.Join(lines, "\n"), lineCount, nil
 }
 
+func readImageFile(filePath string, size int64, imageType string) (ToolResponse, error) {
+	if size > MaxImageSize {
+		return NewTextErrorResponse(fmt.Sprintf("Image is too large (%d bytes). Maximum size is %d bytes",
+			size, MaxImageSize)), nil
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("error reading file: %w", err)
+	}
+	mimeType := ImageMIMEType(data)
+	if mimeType == "" {
+		return NewTextErrorResponse(fmt.Sprintf("This is an image file of type: %s\nUse a different tool to process images", imageType)), nil
+	}
+	image := ToolImage{MIMEType: mimeType, Data: data}
+	return NewImageResponse(describeImage(filePath, image), image), nil
+}
+
 func isImageFile(filePath string) (bool, string) {
 	ext := strings.ToLower(filepath.Ext(filePath))
 	switch ext {
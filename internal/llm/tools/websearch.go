@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type WebSearchParams struct {
+	Query      string `json:"query"`
+	MaxResults int    `json:"max_results,omitempty"`
+}
+
+type WebSearchPermissionsParams struct {
+	Query      string `json:"query"`
+	MaxResults int    `json:"max_results,omitempty"`
+}
+
+type WebSearchResponseMetadata struct {
+	Backend         string `json:"backend"`
+	NumberOfResults int    `json:"number_of_results"`
+}
+
+type webSearchTool struct {
+	client      *http.Client
+	permissions permission.Service
+	backend     searchBackend
+	maxResults  int
+}
+
+const (
+	WebSearchToolName        = "websearch"
+	webSearchToolDescription = `Searches the web and returns the title, URL and a snippet of the top results.
+
+WHEN TO USE THIS TOOL:
+- Use when you need up-to-date information that is not in the repository, such as documentation, release notes or error messages
+- Helpful for finding the URL of a page when you don't know it
+
+HOW TO USE:
+- Provide a search query, as you would type it in a search engine
+- Optionally set the number of results to return
+- Use the fetch tool to read the pages of the relevant results
+
+LIMITATIONS:
+- Snippets are short extracts chosen by the search engine, not the full page
+- At most 20 results are returned
+
+TIPS:
+- Keep queries short and specific, and include the names and versions of libraries
+- Search again with other words when the results are not relevant`
+
+	maxWebSearchResults = 20
+)
+
+// searchResult is a result returned by a search backend
+type searchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// searchBackend queries a search engine
+type searchBackend interface {
+	name() string
+	search(ctx context.Context, client *http.Client, query string, count int) ([]searchResult, error)
+}
+
+// NewWebSearchTool returns the websearch tool querying the configured backend
+func NewWebSearchTool(permissions permission.Service, cfg config.WebSearchConfig) BaseTool {
+	maxResults := cfg.MaxResults
+	if maxResults <= 0 {
+		maxResults = 5
+	}
+	return &webSearchTool{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		permissions: permissions,
+		backend:     newSearchBackend(cfg),
+		maxResults:  min(maxResults, maxWebSearchResults),
+	}
+}
+
+func newSearchBackend(cfg config.WebSearchConfig) searchBackend {
+	switch cfg.Backend {
+	case "searxng":
+		if cfg.URL == "" {
+			return nil
+		}
+		return &searxngBackend{url: strings.TrimSuffix(cfg.URL, "/") + "/search", apiKey: cfg.APIKey}
+	case "brave":
+		return &braveBackend{url: cmp.Or(cfg.URL, "https://api.search.brave.com/res/v1/web/search"), apiKey: cfg.APIKey}
+	case "tavily":
+		return &tavilyBackend{url: cmp.Or(cfg.URL, "https://api.tavily.com/search"), apiKey: cfg.APIKey}
+	}
+	return nil
+}
+
+func (t *webSearchTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        WebSearchToolName,
+		Description: webSearchToolDescription,
+		Parameters: map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "The search query",
+			},
+			"max_results": map[string]any{
+				"type":        "number",
+				"description": fmt.Sprintf("The number of results to return (default: %d, max: %d)", t.maxResults, maxWebSearchResults),
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+func (t *webSearchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params WebSearchParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse("Failed to parse websearch parameters: " + err.Error()), nil
+	}
+
+	if t.backend == nil {
+		return NewTextErrorResponse("Web search is not configured"), nil
+	}
+
+	params.Query = strings.TrimSpace(params.Query)
+	if params.Query == "" {
+		return NewTextErrorResponse("Query parameter is required"), nil
+	}
+	if params.MaxResults <= 0 {
+		params.MaxResults = t.maxResults
+	}
+	params.MaxResults = min(params.MaxResults, maxWebSearchResults)
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for searching the web")
+	}
+
+	p := t.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        config.WorkingDirectory(),
+			ToolName:    WebSearchToolName,
+			Action:      "search",
+			Description: fmt.Sprintf("Search the web with %s for: %s", t.backend.name(), params.Query),
+			Params:      WebSearchPermissionsParams(params),
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	results, err := t.backend.search(ctx, t.client, params.Query, params.MaxResults)
+	if err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("Search failed: %s", err)), nil
+	}
+	if len(results) > params.MaxResults {
+		results = results[:params.MaxResults]
+	}
+
+	return WithResponseMetadata(
+		NewTextResponse(formatSearchResults(results)),
+		WebSearchResponseMetadata{
+			Backend:         t.backend.name(),
+			NumberOfResults: len(results),
+		},
+	), nil
+}
+
+// formatSearchResults lists the results as a numbered markdown list
+func formatSearchResults(results []searchResult) string {
+	if len(results) == 0 {
+		return "No results found"
+	}
+	var output strings.Builder
+	for i, result := range results {
+		if i > 0 {
+			output.WriteString("\n")
+		}
+		fmt.Fprintf(&output, "%d. [%s](%s)\n", i+1, cmp.Or(result.Title, result.URL), result.URL)
+		if result.Snippet != "" {
+			fmt.Fprintf(&output, "   %s\n", result.Snippet)
+		}
+	}
+	return output.String()
+}
+
+// cleanSnippet removes the HTML tags highlighting the query in snippets and
+// joins their lines
+func cleanSnippet(snippet string) string {
+	if strings.Contains(snippet, "<") {
+		if text, err := extractTextFromHTML(snippet); err == nil {
+			return text
+		}
+	}
+	return strings.Join(strings.Fields(snippet), " ")
+}
+
+// searxngBackend queries the JSON API of a SearXNG instance, which must
+// enable the json format in its settings.
+type searxngBackend struct {
+	url    string
+	apiKey string
+}
+
+func (b *searxngBackend) name() string { return "SearXNG" }
+
+func (b *searxngBackend) search(ctx context.Context, client *http.Client, query string, count int) ([]searchResult, error) {
+	values := url.Values{"q": {query}, "format": {"json"}}
+	req, err := http.NewRequestWithContext(ctx, "GET", b.url+"?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if b.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+b.apiKey)
+	}
+
+	var response struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := doSearchRequest(client, req, &response); err != nil {
+		return nil, err
+	}
+	var results []searchResult
+	for _, r := range response.Results {
+		results = append(results, searchResult{Title: r.Title, URL: r.URL, Snippet: cleanSnippet(r.Content)})
+	}
+	return results, nil
+}
+
+// braveBackend queries the Brave Search API
+type braveBackend struct {
+	url    string
+	apiKey string
+}
+
+func (b *braveBackend) name() string { return "Brave" }
+
+func (b *braveBackend) search(ctx context.Context, client *http.Client, query string, count int) ([]searchResult, error) {
+	values := url.Values{"q": {query}, "count": {fmt.Sprint(count)}}
+	req, err := http.NewRequestWithContext(ctx, "GET", b.url+"?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Subscription-Token", b.apiKey)
+
+	var response struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := doSearchRequest(client, req, &response); err != nil {
+		return nil, err
+	}
+	var results []searchResult
+	for _, r := range response.Web.Results {
+		results = append(results, searchResult{Title: cleanSnippet(r.Title), URL: r.URL, Snippet: cleanSnippet(r.Description)})
+	}
+	return results, nil
+}
+
+// tavilyBackend queries the Tavily search API
+type tavilyBackend struct {
+	url    string
+	apiKey string
+}
+
+func (b *tavilyBackend) name() string { return "Tavily" }
+
+func (b *tavilyBackend) search(ctx context.Context, client *http.Client, query string, count int) ([]searchResult, error) {
+	body, err := json.Marshal(map[string]any{
+		"query":       query,
+		"max_results": count,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", b.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+b.apiKey)
+
+	var response struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := doSearchRequest(client, req, &response); err != nil {
+		return nil, err
+	}
+	var results []searchResult
+	for _, r := range response.Results {
+		results = append(results, searchResult{Title: r.Title, URL: r.URL, Snippet: cleanSnippet(r.Content)})
+	}
+	return results, nil
+}
+
+// doSearchRequest sends a request to a search API and decodes its JSON
+// response
+func doSearchRequest(client *http.Client, req *http.Request, response any) error {
+	req.Header.Set("User-Agent", "opencode/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5*1024*1024))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(string(body))
+		if len(message) > 200 {
+			message = message[:200] + "..."
+		}
+		return fmt.Errorf("request failed with status code %d: %s", resp.StatusCode, message)
+	}
+	if err := json.Unmarshal(body, response); err != nil {
+		return errors.New("the search engine did not return JSON, check its URL and that it enables the JSON format")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchBackends(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /searxng/search", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "go context", r.URL.Query().Get("q"))
+		assert.Equal(t, "json", r.URL.Query().Get("format"))
+		w.Write([]byte(`{"results":[{"title":"Package context","url":"https://pkg.go.dev/context","content":"Package context defines\n the Context type."}]}`))
+	})
+	mux.HandleFunc("GET /brave", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("X-Subscription-Token"))
+		assert.Equal(t, "2", r.URL.Query().Get("count"))
+		w.Write([]byte(`{"web":{"results":[{"title":"Package context","url":"https://pkg.go.dev/context","description":"Package <strong>context</strong> defines the Context type."}]}}`))
+	})
+	mux.HandleFunc("POST /tavily", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]any{"query": "go context", "max_results": 2.0}, body)
+		w.Write([]byte(`{"results":[{"title":"Package context","url":"https://pkg.go.dev/context","content":"Package context defines the Context type."}]}`))
+	})
+	mux.HandleFunc("GET /html/search", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	want := []searchResult{{
+		Title:   "Package context",
+		URL:     "https://pkg.go.dev/context",
+		Snippet: "Package context defines the Context type.",
+	}}
+	for _, cfg := range []config.WebSearchConfig{
+		{Backend: "searxng", URL: server.URL + "/searxng/"},
+		{Backend: "brave", URL: server.URL + "/brave", APIKey: "key"},
+		{Backend: "tavily", URL: server.URL + "/tavily", APIKey: "key"},
+	} {
+		t.Run(cfg.Backend, func(t *testing.T) {
+			backend := newSearchBackend(cfg)
+			require.NotNil(t, backend)
+			results, err := backend.search(context.Background(), server.Client(), "go context", 2)
+			require.NoError(t, err)
+			assert.Equal(t, want, results)
+		})
+	}
+
+	_, err := newSearchBackend(config.WebSearchConfig{Backend: "searxng", URL: server.URL + "/html"}).
+		search(context.Background(), server.Client(), "go context", 2)
+	assert.ErrorContains(t, err, "did not return JSON")
+	_, err = newSearchBackend(config.WebSearchConfig{Backend: "brave", URL: server.URL + "/missing"}).
+		search(context.Background(), server.Client(), "go context", 2)
+	assert.ErrorContains(t, err, "status code 404")
+
+	assert.Nil(t, newSearchBackend(config.WebSearchConfig{Backend: "searxng"}))
+	assert.Nil(t, newSearchBackend(config.WebSearchConfig{Backend: "bing"}))
+}
+
+func TestFormatSearchResults(t *testing.T) {
+	assert.Equal(t, "No results found", formatSearchResults(nil))
+	assert.Equal(t,
+		"1. [Package context](https://pkg.go.dev/context)\n   Package context defines the Context type.\n\n2. [https://go.dev](https://go.dev)\n",
+		formatSearchResults([]searchResult{
+			{Title: "Package context", URL: "https://pkg.go.dev/context", Snippet: "Package context defines the Context type."},
+			{URL: "https://go.dev"},
+		}),
+	)
+}
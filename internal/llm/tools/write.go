@@ -185,11 +185,19 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	recordEditBaseline(ctx, filePath, w.lspClients)
 	err = os.WriteFile(filePath, []byte(params.Content), 0o644)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error writing file: %w", err)
 	}
 
+	result := fmt.Sprintf("File successfully written: %s", filePath)
+	if formatted, ok := formatWrittenFile(ctx, filePath, oldContent, params.Content, w.lspClients); ok {
+		params.Content = formatted.Content
+		diff, additions, removals = formatted.Diff, formatted.Additions, formatted.Removals
+		result += fmt.Sprintf(" (formatted with %s)", formatted.Formatter)
+	}
+
 	// Check if file exists in history
 	file, err := w.files.GetByPathAndSession(ctx, filePath, sessionID)
 	if err != nil {
@@ -216,7 +224,6 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	recordFileRead(filePath)
 	waitForLspDiagnostics(ctx, filePath, w.lspClients)
 
-	result := fmt.Sprintf("File successfully written: %s", filePath)
 	result = fmt.Sprintf("<result>\n%s\n</result>", result)
 	result += getDiagnostics(filePath, w.lspClients)
 	return WithResponseMetadata(NewTextResponse(result),
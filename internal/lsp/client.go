@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -44,73 +45,65 @@ type Client struct {
 	diagnostics   map[protocol.DocumentUri][]protocol.Diagnostic
 	diagnosticsMu sync.RWMutex
 
+	// Pull diagnostics support, see pull.go
+	pullDiagnostics          bool
+	pullWorkspaceDiagnostics bool
+	diagnosticIdentifier     string
+
 	// Files are currently opened by the LSP
 	openFiles   map[string]*OpenFileInfo
 	openFilesMu sync.RWMutex
 
 	// Server state
 	serverState atomic.Value
+
+	// Process supervision, see supervisor.go
+	ctx          context.Context
+	command      string
+	args         []string
+	workspaceDir string
+	processMu    sync.RWMutex
+	writeMu      sync.Mutex
+	exited       chan struct{}
+	closing      atomic.Bool
+	restarting   atomic.Bool
+	restarts     int
+	lastRestart  time.Time
+
+	// File watch registrations of the server, see handlers.go
+	fileWatchHandler       FileWatchRegistrationHandler
+	fileWatchRegistrations map[string][]protocol.FileSystemWatcher
+	fileWatchMu            sync.Mutex
+
+	// Workspace folders, see workspace.go
+	extensions       []string
+	rootMarkers      []string
+	workspaceFolders []string
+	foldersSupported bool
+	foldersMu        sync.Mutex
 }
 
 func NewClient(ctx context.Context, command string, args ...string) (*Client, error) {
-	cmd := exec.CommandContext(ctx, command, args...)
-	// Copy env
-	cmd.Env = os.Environ()
-
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
-	}
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
 	client := &Client{
-		Cmd:                   cmd,
-		stdin:                 stdin,
-		stdout:                bufio.NewReader(stdout),
-		stderr:                stderr,
-		handlers:              make(map[int32]chan *Message),
-		notificationHandlers:  make(map[string]NotificationHandler),
-		serverRequestHandlers: make(map[string]ServerRequestHandler),
-		diagnostics:           make(map[protocol.DocumentUri][]protocol.Diagnostic),
-		openFiles:             make(map[string]*OpenFileInfo),
+		handlers:               make(map[int32]chan *Message),
+		notificationHandlers:   make(map[string]NotificationHandler),
+		serverRequestHandlers:  make(map[string]ServerRequestHandler),
+		diagnostics:            make(map[protocol.DocumentUri][]protocol.Diagnostic),
+		openFiles:              make(map[string]*OpenFileInfo),
+		fileWatchRegistrations: make(map[string][]protocol.FileSystemWatcher),
+		ctx:                    ctx,
+		command:                command,
+		args:                   args,
 	}
 
 	// Initialize server state
 	client.serverState.Store(StateStarting)
 
 	// Start the LSP server process
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start LSP server: %w", err)
+	if err := client.startProcess(); err != nil {
+		return nil, err
 	}
 
-	// Handle stderr in a separate goroutine
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			fmt.Fprintf(os.Stderr, "LSP Server: %s\n", scanner.Text())
-		}
-		if err := scanner.Err(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading stderr: %v\n", err)
-		}
-	}()
-
-	// Start message handling loop
-	go func() {
-		defer logging.RecoverPanic("LSP-message-handler", func() {
-			logging.ErrorPersist("LSP message handler crashed, LSP functionality may be impaired")
-		})
-		client.handleMessages()
-	}()
-
 	return client, nil
 }
 
@@ -127,6 +120,7 @@ func (c *Client) RegisterServerRequestHandler(method string, handler ServerReque
 }
 
 func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (*protocol.InitializeResult, error) {
+	c.workspaceDir = workspaceDir
 	initParams := &protocol.InitializeParams{
 		WorkspaceFoldersInitializeParams: protocol.WorkspaceFoldersInitializeParams{
 			WorkspaceFolders: []protocol.WorkspaceFolder{
@@ -147,7 +141,8 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 			RootURI:  protocol.DocumentUri("file://" + workspaceDir),
 			Capabilities: protocol.ClientCapabilities{
 				Workspace: protocol.WorkspaceClientCapabilities{
-					Configuration: true,
+					WorkspaceFolders: true,
+					Configuration:    true,
 					DidChangeConfiguration: protocol.DidChangeConfigurationClientCapabilities{
 						DynamicRegistration: true,
 					},
@@ -178,6 +173,9 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 					PublishDiagnostics: protocol.PublishDiagnosticsClientCapabilities{
 						VersionSupport: true,
 					},
+					Diagnostic: &protocol.DiagnosticClientCapabilities{
+						RelatedDocumentSupport: true,
+					},
 					SemanticTokens: protocol.SemanticTokensClientCapabilities{
 						Requests: protocol.ClientSemanticTokensRequestOptions{
 							Range: &protocol.Or_ClientSemanticTokensRequestOptions_range{},
@@ -208,6 +206,8 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 	if err := c.Call(ctx, "initialize", initParams, &result); err != nil {
 		return nil, fmt.Errorf("initialize failed: %w", err)
 	}
+	c.resetWorkspaceFolders(workspaceDir, result)
+	c.setPullDiagnostics(result.Capabilities.DiagnosticProvider)
 
 	if err := c.Notify(ctx, "initialized", struct{}{}); err != nil {
 		return nil, fmt.Errorf("initialized notification failed: %w", err)
@@ -216,7 +216,8 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 	// Register handlers
 	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
 	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
-	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
+	c.RegisterServerRequestHandler("client/registerCapability",
+		func(params json.RawMessage) (any, error) { return HandleRegisterCapability(c, params) })
 	c.RegisterNotificationHandler("window/showMessage", HandleServerMessage)
 	c.RegisterNotificationHandler("textDocument/publishDiagnostics",
 		func(params json.RawMessage) { HandleDiagnostics(c, params) })
@@ -231,6 +232,9 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 }
 
 func (c *Client) Close() error {
+	// The server is going away on purpose, don't restart it
+	c.closing.Store(true)
+
 	// Try to close all open files first
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -238,24 +242,22 @@ func (c *Client) Close() error {
 	// Attempt to close files but continue shutdown regardless
 	c.CloseAllFiles(ctx)
 
+	c.processMu.RLock()
+	stdin, exited := c.stdin, c.exited
+	c.processMu.RUnlock()
+
 	// Close stdin to signal the server
-	if err := c.stdin.Close(); err != nil {
+	if err := stdin.Close(); err != nil {
 		return fmt.Errorf("failed to close stdin: %w", err)
 	}
 
-	// Use a channel to handle the Wait with timeout
-	done := make(chan error, 1)
-	go func() {
-		done <- c.Cmd.Wait()
-	}()
-
 	// Wait for process to exit with timeout
 	select {
-	case err := <-done:
-		return err
+	case <-exited:
+		return nil
 	case <-time.After(2 * time.Second):
 		// If we timeout, try to kill the process
-		if err := c.Cmd.Process.Kill(); err != nil {
+		if err := c.kill(); err != nil {
 			return fmt.Errorf("failed to kill process: %w", err)
 		}
 		return fmt.Errorf("process killed after timeout")
@@ -268,6 +270,7 @@ const (
 	StateStarting ServerState = iota
 	StateReady
 	StateError
+	StateRestarting
 )
 
 // GetServerState returns the current state of the LSP server
@@ -354,11 +357,11 @@ const (
 
 // detectServerType tries to determine what type of LSP server we're dealing with
 func (c *Client) detectServerType() ServerType {
-	if c.Cmd == nil {
+	if c.command == "" {
 		return ServerTypeUnknown
 	}
 
-	cmdPath := strings.ToLower(c.Cmd.Path)
+	cmdPath := strings.ToLower(c.command)
 
 	switch {
 	case strings.Contains(cmdPath, "gopls"):
@@ -601,6 +604,9 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 		return fmt.Errorf("error reading file: %w", err)
 	}
 
+	// Files of a nested project are handled in their own workspace folder
+	c.addWorkspaceFolder(ctx, filepath)
+
 	params := protocol.DidOpenTextDocumentParams{
 		TextDocument: protocol.TextDocumentItem{
 			URI:        protocol.DocumentUri(uri),
@@ -738,7 +744,9 @@ func (c *Client) GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnos
 
 // GetDiagnostics returns all diagnostics for all files
 func (c *Client) GetDiagnostics() map[protocol.DocumentUri][]protocol.Diagnostic {
-	return c.diagnostics
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+	return maps.Clone(c.diagnostics)
 }
 
 // OpenFileOnDemand opens a file only if it's not already open
@@ -2,6 +2,7 @@ package lsp
 
 import (
 	"encoding/json"
+	"maps"
 
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/logging"
@@ -15,7 +16,7 @@ func HandleWorkspaceConfiguration(params json.RawMessage) (any, error) {
 	return []map[string]any{{}}, nil
 }
 
-func HandleRegisterCapability(params json.RawMessage) (any, error) {
+func HandleRegisterCapability(client *Client, params json.RawMessage) (any, error) {
 	var registerParams protocol.RegistrationParams
 	if err := json.Unmarshal(params, &registerParams); err != nil {
 		logging.Error("Error unmarshaling registration params", "error", err)
@@ -39,7 +40,7 @@ func HandleRegisterCapability(params json.RawMessage) (any, error) {
 			}
 
 			// Store the file watchers registrations
-			notifyFileWatchRegistration(reg.ID, options.Watchers)
+			client.notifyFileWatchRegistration(reg.ID, options.Watchers)
 		}
 	}
 
@@ -64,18 +65,29 @@ func HandleApplyEdit(params json.RawMessage) (any, error) {
 // FileWatchRegistrationHandler is a function that will be called when file watch registrations are received
 type FileWatchRegistrationHandler func(id string, watchers []protocol.FileSystemWatcher)
 
-// fileWatchHandler holds the current handler for file watch registrations
-var fileWatchHandler FileWatchRegistrationHandler
-
-// RegisterFileWatchHandler sets the handler for file watch registrations
-func RegisterFileWatchHandler(handler FileWatchRegistrationHandler) {
-	fileWatchHandler = handler
+// RegisterFileWatchHandler sets the handler for the file watch registrations
+// of the server. The registrations received before are passed to it right
+// away, and again after the server is restarted.
+func (c *Client) RegisterFileWatchHandler(handler FileWatchRegistrationHandler) {
+	c.fileWatchMu.Lock()
+	c.fileWatchHandler = handler
+	registrations := maps.Clone(c.fileWatchRegistrations)
+	c.fileWatchMu.Unlock()
+
+	for id, watchers := range registrations {
+		handler(id, watchers)
+	}
 }
 
 // notifyFileWatchRegistration notifies the handler about new file watch registrations
-func notifyFileWatchRegistration(id string, watchers []protocol.FileSystemWatcher) {
-	if fileWatchHandler != nil {
-		fileWatchHandler(id, watchers)
+func (c *Client) notifyFileWatchRegistration(id string, watchers []protocol.FileSystemWatcher) {
+	c.fileWatchMu.Lock()
+	c.fileWatchRegistrations[id] = watchers
+	handler := c.fileWatchHandler
+	c.fileWatchMu.Unlock()
+
+	if handler != nil {
+		handler(id, watchers)
 	}
 }
 
@@ -0,0 +1,62 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+)
+
+// TestMain lets the test binary act as a minimal language server. It supports
+// pull diagnostics and workspace folders, answers other requests with an
+// empty result and exits on "test/crash". "test/addedFolders" returns the
+// folders added so far.
+func TestMain(m *testing.M) {
+	if os.Getenv("OPENCODE_TEST_LSP_SERVER") != "1" {
+		os.Exit(m.Run())
+	}
+
+	// The transport reads the debug settings from the config
+	if _, err := config.Load(os.TempDir(), false); err != nil {
+		os.Exit(2)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	addedFolders := []string{}
+	for {
+		msg, err := ReadMessage(reader)
+		if err != nil {
+			os.Exit(0)
+		}
+		if msg.Method == "test/crash" {
+			os.Exit(1)
+		}
+		if msg.Method == "workspace/didChangeWorkspaceFolders" {
+			var params protocol.DidChangeWorkspaceFoldersParams
+			if err := json.Unmarshal(msg.Params, &params); err == nil {
+				for _, folder := range params.Event.Added {
+					addedFolders = append(addedFolders, string(folder.URI))
+				}
+			}
+		}
+		if msg.ID == 0 {
+			continue
+		}
+		result := json.RawMessage("null")
+		switch msg.Method {
+		case "initialize":
+			result = json.RawMessage(`{"capabilities":{"diagnosticProvider":{"interFileDependencies":true,"workspaceDiagnostics":true},"workspace":{"workspaceFolders":{"supported":true}}}}`)
+		case "textDocument/diagnostic":
+			result = json.RawMessage(`{"kind":"full","items":[{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":1}},"severity":1,"message":"pulled"}]}`)
+		case "workspace/diagnostic":
+			result = json.RawMessage(`{"items":[{"uri":"file:///src/other.go","version":0,"kind":"full","items":[{"range":{"start":{"line":2,"character":0},"end":{"line":2,"character":1}},"severity":2,"message":"unopened"}]}]}`)
+		case "test/addedFolders":
+			result, _ = json.Marshal(addedFolders)
+		}
+		if err := WriteMessage(os.Stdout, &Message{JSONRPC: "2.0", ID: msg.ID, Result: result}); err != nil {
+			os.Exit(0)
+		}
+	}
+}
@@ -242,6 +242,7 @@ func (c *Client) Initialize(ctx context.Context, params protocol.ParamInitialize
 // Shutdown sends a shutdown request to the LSP server.
 // A shutdown request is sent from the client to the server. It is sent once when the client decides to shutdown the server. The only notification that is sent after a shutdown request is the exit event.
 func (c *Client) Shutdown(ctx context.Context) error {
+	c.closing.Store(true)
 	return c.Call(ctx, "shutdown", nil, nil)
 }
 
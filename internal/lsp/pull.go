@@ -0,0 +1,113 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+)
+
+// setPullDiagnostics records whether the server supports the LSP 3.17 pull
+// model for diagnostics, from its initialize result
+func (c *Client) setPullDiagnostics(provider *protocol.Or_ServerCapabilities_diagnosticProvider) {
+	var options protocol.DiagnosticOptions
+	supported := false
+	if provider != nil {
+		switch v := provider.Value.(type) {
+		case protocol.DiagnosticOptions:
+			options, supported = v, true
+		case protocol.DiagnosticRegistrationOptions:
+			options, supported = v.DiagnosticOptions, true
+		}
+	}
+
+	c.diagnosticsMu.Lock()
+	defer c.diagnosticsMu.Unlock()
+	c.pullDiagnostics = supported
+	c.pullWorkspaceDiagnostics = supported && options.WorkspaceDiagnostics
+	c.diagnosticIdentifier = options.Identifier
+}
+
+// SupportsPullDiagnostics reports whether the diagnostics of a document, and
+// of the whole workspace, can be requested from the server instead of waiting
+// for it to publish them
+func (c *Client) SupportsPullDiagnostics() (document bool, workspace bool) {
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+	return c.pullDiagnostics, c.pullWorkspaceDiagnostics
+}
+
+// PullDiagnostics requests the diagnostics of the file at path, which must be
+// open, and stores them with the published ones
+func (c *Client) PullDiagnostics(ctx context.Context, path string) error {
+	c.diagnosticsMu.RLock()
+	identifier := c.diagnosticIdentifier
+	c.diagnosticsMu.RUnlock()
+
+	uri := protocol.DocumentUri("file://" + path)
+	report, err := c.Diagnostic(ctx, protocol.DocumentDiagnosticParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Identifier:   identifier,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull diagnostics: %w", err)
+	}
+
+	full, ok := report.Value.(protocol.RelatedFullDocumentDiagnosticReport)
+	if !ok || full.Kind != string(protocol.DiagnosticFull) {
+		// No previous result ID is sent, so servers don't answer with unchanged reports
+		return nil
+	}
+	c.setDiagnostics(uri, full.Items)
+	for related, value := range full.RelatedDocuments {
+		if items, ok := relatedDiagnostics(value); ok {
+			c.setDiagnostics(related, items)
+		}
+	}
+	return nil
+}
+
+// PullWorkspaceDiagnostics requests the diagnostics of every file in the
+// workspace, including the files that were never opened, and stores them with
+// the published ones
+func (c *Client) PullWorkspaceDiagnostics(ctx context.Context) error {
+	c.diagnosticsMu.RLock()
+	identifier := c.diagnosticIdentifier
+	c.diagnosticsMu.RUnlock()
+
+	report, err := c.DiagnosticWorkspace(ctx, protocol.WorkspaceDiagnosticParams{
+		Identifier:        identifier,
+		PreviousResultIds: []protocol.PreviousResultId{},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull workspace diagnostics: %w", err)
+	}
+
+	for _, item := range report.Items {
+		if full, ok := item.Value.(protocol.WorkspaceFullDocumentDiagnosticReport); ok && full.Kind == string(protocol.DiagnosticFull) {
+			c.setDiagnostics(full.URI, full.Items)
+		}
+	}
+	return nil
+}
+
+func (c *Client) setDiagnostics(uri protocol.DocumentUri, diagnostics []protocol.Diagnostic) {
+	c.diagnosticsMu.Lock()
+	defer c.diagnosticsMu.Unlock()
+	c.diagnostics[uri] = diagnostics
+}
+
+// relatedDiagnostics decodes the report of a related document, which the
+// protocol types leave untyped
+func relatedDiagnostics(value any) ([]protocol.Diagnostic, bool) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var report protocol.FullDocumentDiagnosticReport
+	if err := json.Unmarshal(data, &report); err != nil || report.Kind != string(protocol.DiagnosticFull) {
+		return nil, false
+	}
+	return report.Items, true
+}
@@ -0,0 +1,43 @@
+package lsp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	_, err := config.Load(dir, false)
+	require.NoError(t, err)
+	t.Setenv("OPENCODE_TEST_LSP_SERVER", "1")
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, os.Args[0])
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.InitializeLSPClient(ctx, dir)
+	require.NoError(t, err)
+	document, workspace := client.SupportsPullDiagnostics()
+	assert.True(t, document)
+	assert.True(t, workspace)
+
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+	require.NoError(t, client.OpenFile(ctx, path))
+	require.NoError(t, client.PullDiagnostics(ctx, path))
+	require.NoError(t, client.PullWorkspaceDiagnostics(ctx))
+
+	diagnostics := client.GetDiagnostics()
+	require.Len(t, diagnostics[protocol.DocumentUri("file://"+path)], 1)
+	assert.Equal(t, "pulled", diagnostics[protocol.DocumentUri("file://"+path)][0].Message)
+	require.Len(t, diagnostics["file:///src/other.go"], 1)
+	assert.Equal(t, "unopened", diagnostics["file:///src/other.go"][0].Message)
+}
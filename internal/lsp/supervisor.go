@@ -0,0 +1,244 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/logging"
+)
+
+// ErrServerExited is returned for the requests pending when the server process exits
+var ErrServerExited = errors.New("language server exited")
+
+const (
+	// maxRestarts is the number of restarts attempted before giving up
+	maxRestarts = 5
+	// restartResetInterval is how long a server has to run for its restart
+	// count to be reset
+	restartResetInterval = 5 * time.Minute
+	maxRestartDelay      = 30 * time.Second
+)
+
+// startProcess starts the server process and the goroutines reading from it.
+// When the process exits, pending requests fail and, unless the client is being
+// closed, the server is restarted.
+func (c *Client) startProcess() error {
+	cmd := exec.CommandContext(c.ctx, c.command, c.args...)
+	// Copy env
+	cmd.Env = os.Environ()
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start LSP server: %w", err)
+	}
+
+	exited := make(chan struct{})
+	reader := bufio.NewReader(stdout)
+	c.processMu.Lock()
+	c.Cmd = cmd
+	c.stdin = stdin
+	c.stdout = reader
+	c.stderr = stderr
+	c.exited = exited
+	c.processMu.Unlock()
+
+	// Handle stderr in a separate goroutine
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			fmt.Fprintf(os.Stderr, "LSP Server: %s\n", scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stderr: %v\n", err)
+		}
+	}()
+
+	// Start message handling loop, it ends when the process closes stdout
+	go func() {
+		defer close(exited)
+		func() {
+			defer logging.RecoverPanic("LSP-message-handler", func() {
+				logging.ErrorPersist("LSP message handler crashed, LSP functionality may be impaired")
+			})
+			c.handleMessages(reader)
+		}()
+		<-stderrDone
+		c.handleExit(cmd.Wait())
+	}()
+
+	return nil
+}
+
+// handleExit is called once the server process has exited
+func (c *Client) handleExit(err error) {
+	c.failPendingRequests()
+	if c.closing.Load() || c.ctx.Err() != nil {
+		return
+	}
+
+	// The diagnostics of a dead server are stale
+	c.diagnosticsMu.Lock()
+	clear(c.diagnostics)
+	c.diagnosticsMu.Unlock()
+
+	if !c.restarting.CompareAndSwap(false, true) {
+		// A restart is in progress and will try again
+		return
+	}
+	logging.WarnPersist(fmt.Sprintf("LSP server %s exited, restarting it", c.name()), "error", err)
+	go c.restart()
+}
+
+// restart starts the server again with an exponential backoff, and reopens the
+// files that were open in the exited server
+func (c *Client) restart() {
+	defer c.restarting.Store(false)
+	c.SetServerState(StateRestarting)
+
+	files := c.takeOpenFiles()
+	if time.Since(c.lastRestart) > restartResetInterval {
+		c.restarts = 0
+	}
+	for c.restarts < maxRestarts {
+		c.restarts++
+		c.lastRestart = time.Now()
+
+		delay := restartDelay(c.restarts)
+		logging.Info("Restarting LSP server", "command", c.command, "attempt", c.restarts, "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-c.ctx.Done():
+			return
+		}
+		if c.closing.Load() {
+			return
+		}
+
+		c.SetServerState(StateRestarting)
+		if err := c.reinitialize(files); err != nil {
+			logging.Warn("Failed to restart LSP server", "command", c.command, "attempt", c.restarts, "error", err)
+			continue
+		}
+		logging.InfoPersist(fmt.Sprintf("LSP server %s restarted", c.name()))
+		return
+	}
+
+	c.SetServerState(StateError)
+	logging.ErrorPersist(fmt.Sprintf("LSP server %s keeps exiting, giving up after %d restarts", c.name(), maxRestarts))
+}
+
+// reinitialize starts a new server process and brings it to the state of the
+// exited one
+func (c *Client) reinitialize(files []string) error {
+	if err := c.startProcess(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, 30*time.Second)
+	defer cancel()
+
+	if _, err := c.InitializeLSPClient(ctx, c.workspaceDir); err != nil {
+		_ = c.kill()
+		return err
+	}
+	if err := c.WaitForServerReady(ctx); err != nil {
+		_ = c.kill()
+		return err
+	}
+
+	for _, path := range files {
+		if err := c.OpenFile(ctx, path); err != nil {
+			logging.Debug("Failed to reopen file after restart", "file", path, "error", err)
+		}
+	}
+	return nil
+}
+
+// takeOpenFiles forgets the open files and returns their paths
+func (c *Client) takeOpenFiles() []string {
+	c.openFilesMu.Lock()
+	defer c.openFilesMu.Unlock()
+
+	files := make([]string, 0, len(c.openFiles))
+	for uri := range c.openFiles {
+		files = append(files, strings.TrimPrefix(uri, "file://"))
+	}
+	clear(c.openFiles)
+	return files
+}
+
+// failPendingRequests unblocks the calls waiting for a response
+func (c *Client) failPendingRequests() {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+
+	for id, ch := range c.handlers {
+		close(ch)
+		delete(c.handlers, id)
+	}
+}
+
+// Process returns the running server process, which is replaced when the
+// server is restarted
+func (c *Client) Process() *exec.Cmd {
+	c.processMu.RLock()
+	defer c.processMu.RUnlock()
+	return c.Cmd
+}
+
+// kill stops the running server process
+func (c *Client) kill() error {
+	c.processMu.RLock()
+	cmd := c.Cmd
+	c.processMu.RUnlock()
+
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+// write sends a message to the running server process
+func (c *Client) write(msg *Message) error {
+	c.processMu.RLock()
+	stdin := c.stdin
+	c.processMu.RUnlock()
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return WriteMessage(stdin, msg)
+}
+
+// name returns the name of the server command, used in status messages
+func (c *Client) name() string {
+	return filepath.Base(c.command)
+}
+
+// restartDelay returns the backoff before the given restart attempt
+func restartDelay(attempt int) time.Duration {
+	delay := time.Second << (attempt - 1)
+	return min(delay, maxRestartDelay)
+}
@@ -0,0 +1,50 @@
+package lsp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRestartsExitedServer(t *testing.T) {
+	dir := t.TempDir()
+	_, err := config.Load(dir, false)
+	require.NoError(t, err)
+	t.Setenv("OPENCODE_TEST_LSP_SERVER", "1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := NewClient(ctx, os.Args[0])
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.InitializeLSPClient(ctx, dir)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+	require.NoError(t, client.OpenFile(ctx, path))
+
+	// The pending request fails instead of hanging
+	err = client.Call(ctx, "test/crash", nil, nil)
+	assert.ErrorIs(t, err, ErrServerExited)
+
+	require.Eventually(t, func() bool {
+		return client.GetServerState() == StateReady
+	}, 10*time.Second, 50*time.Millisecond)
+	assert.True(t, client.IsFileOpen(path), "open files are reopened after a restart")
+	assert.NoError(t, client.Call(ctx, "workspace/symbol", nil, nil))
+}
+
+func TestRestartDelay(t *testing.T) {
+	assert.Equal(t, time.Second, restartDelay(1))
+	assert.Equal(t, 4*time.Second, restartDelay(3))
+	assert.Equal(t, maxRestartDelay, restartDelay(10))
+}
@@ -89,11 +89,12 @@ func ReadMessage(r *bufio.Reader) (*Message, error) {
 	return &msg, nil
 }
 
-// handleMessages reads and dispatches messages in a loop
-func (c *Client) handleMessages() {
+// handleMessages reads and dispatches messages in a loop until the server
+// closes its output
+func (c *Client) handleMessages(stdout *bufio.Reader) {
 	cnf := config.Get()
 	for {
-		msg, err := ReadMessage(c.stdout)
+		msg, err := ReadMessage(stdout)
 		if err != nil {
 			if cnf.DebugLSP {
 				logging.Error("Error reading message", "error", err)
@@ -143,7 +144,7 @@ func (c *Client) handleMessages() {
 			}
 
 			// Send response back to server
-			if err := WriteMessage(c.stdin, response); err != nil {
+			if err := c.write(response); err != nil {
 				logging.Error("Error sending response to server", "error", err)
 			}
 
@@ -169,9 +170,11 @@ func (c *Client) handleMessages() {
 
 		// Handle response to our request (has ID but no Method)
 		if msg.ID != 0 && msg.Method == "" {
-			c.handlersMu.RLock()
+			// Take the handler so that it isn't failed concurrently
+			c.handlersMu.Lock()
 			ch, ok := c.handlers[msg.ID]
-			c.handlersMu.RUnlock()
+			delete(c.handlers, msg.ID)
+			c.handlersMu.Unlock()
 
 			if ok {
 				if cnf.DebugLSP {
@@ -213,7 +216,7 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	}()
 
 	// Send request
-	if err := WriteMessage(c.stdin, msg); err != nil {
+	if err := c.write(msg); err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 
@@ -222,7 +225,16 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	}
 
 	// Wait for response
-	resp := <-ch
+	var resp *Message
+	select {
+	case r, ok := <-ch:
+		if !ok {
+			return ErrServerExited
+		}
+		resp = r
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 
 	if cnf.DebugLSP {
 		logging.Debug("Received response", "id", id)
@@ -259,7 +271,7 @@ func (c *Client) Notify(ctx context.Context, method string, params any) error {
 		return fmt.Errorf("failed to create notification: %w", err)
 	}
 
-	if err := WriteMessage(c.stdin, msg); err != nil {
+	if err := c.write(msg); err != nil {
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
 
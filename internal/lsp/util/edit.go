@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -19,6 +20,20 @@ func applyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent, err := ApplyTextEditsToContent(content, edits)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyTextEditsToContent returns content with edits applied
+func ApplyTextEditsToContent(content []byte, edits []protocol.TextEdit) (string, error) {
 	// Detect line ending style
 	var lineEnding string
 	if bytes.Contains(content, []byte("\r\n")) {
@@ -37,7 +52,7 @@ func applyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if rangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return "", fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -56,7 +71,7 @@ func applyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 	for _, edit := range sortedEdits {
 		newLines, err := applyTextEdit(lines, edit)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return "", fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
@@ -75,11 +90,7 @@ func applyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := os.WriteFile(path, []byte(newContent.String()), 0o644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	return newContent.String(), nil
 }
 
 func applyTextEdit(lines []string, edit protocol.TextEdit) ([]string, error) {
@@ -225,6 +236,170 @@ func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
 	return nil
 }
 
+// FileEdit is the effect of a WorkspaceEdit on a single file
+type FileEdit struct {
+	Path       string
+	OldContent string
+	NewContent string
+	// NewPath is set when the file is renamed
+	NewPath string
+	Created bool
+	Deleted bool
+}
+
+// PreviewWorkspaceEdit returns the files changed by the given WorkspaceEdit
+// and their new content, without writing to the filesystem
+func PreviewWorkspaceEdit(edit protocol.WorkspaceEdit) ([]FileEdit, error) {
+	var fileEdits []*FileEdit
+	byPath := make(map[string]*FileEdit)
+
+	get := func(path string) (*FileEdit, error) {
+		if fe, ok := byPath[path]; ok {
+			return fe, nil
+		}
+		fe := &FileEdit{Path: path}
+		content, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		fe.OldContent = string(content)
+		fe.NewContent = fe.OldContent
+		fe.Created = os.IsNotExist(err)
+		byPath[path] = fe
+		fileEdits = append(fileEdits, fe)
+		return fe, nil
+	}
+	applyEdits := func(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
+		fe, err := get(strings.TrimPrefix(string(uri), "file://"))
+		if err != nil {
+			return err
+		}
+		if fe.Deleted {
+			return fmt.Errorf("edit of deleted file %s", fe.Path)
+		}
+		fe.NewContent, err = ApplyTextEditsToContent([]byte(fe.NewContent), edits)
+		return err
+	}
+
+	// Sort the files, ranging over the map would list them in a random order
+	uris := make([]string, 0, len(edit.Changes))
+	for uri := range edit.Changes {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		if err := applyEdits(protocol.DocumentUri(uri), edit.Changes[protocol.DocumentUri(uri)]); err != nil {
+			return nil, fmt.Errorf("failed to apply text edits: %w", err)
+		}
+	}
+
+	for _, change := range edit.DocumentChanges {
+		switch {
+		case change.CreateFile != nil:
+			fe, err := get(strings.TrimPrefix(string(change.CreateFile.URI), "file://"))
+			if err != nil {
+				return nil, err
+			}
+			if fe.Created || change.CreateFile.Options == nil || !change.CreateFile.Options.IgnoreIfExists {
+				fe.NewContent = ""
+				fe.Deleted = false
+			}
+		case change.DeleteFile != nil:
+			fe, err := get(strings.TrimPrefix(string(change.DeleteFile.URI), "file://"))
+			if err != nil {
+				return nil, err
+			}
+			fe.NewContent = ""
+			fe.Deleted = true
+		case change.RenameFile != nil:
+			fe, err := get(strings.TrimPrefix(string(change.RenameFile.OldURI), "file://"))
+			if err != nil {
+				return nil, err
+			}
+			fe.NewPath = strings.TrimPrefix(string(change.RenameFile.NewURI), "file://")
+			// Later edits address the file by its new name
+			byPath[fe.NewPath] = fe
+		case change.TextDocumentEdit != nil:
+			textEdits := make([]protocol.TextEdit, len(change.TextDocumentEdit.Edits))
+			for i, edit := range change.TextDocumentEdit.Edits {
+				var err error
+				textEdits[i], err = edit.AsTextEdit()
+				if err != nil {
+					return nil, fmt.Errorf("invalid edit type: %w", err)
+				}
+			}
+			if err := applyEdits(change.TextDocumentEdit.TextDocument.URI, textEdits); err != nil {
+				return nil, fmt.Errorf("failed to apply document change: %w", err)
+			}
+		}
+	}
+
+	result := make([]FileEdit, 0, len(fileEdits))
+	for _, fe := range fileEdits {
+		result = append(result, *fe)
+	}
+	return result, nil
+}
+
+// WriteFileEdits writes the previewed effect of a WorkspaceEdit. Nothing is
+// written when one of the files changed since the preview, as the edit would
+// no longer be the one that was previewed.
+func WriteFileEdits(fileEdits []FileEdit) error {
+	for _, fe := range fileEdits {
+		content, err := os.ReadFile(fe.Path)
+		switch {
+		case fe.Created && !os.IsNotExist(err):
+			return fmt.Errorf("file %s was created since the edit was computed", fe.Path)
+		case !fe.Created && err != nil:
+			return fmt.Errorf("failed to read file: %w", err)
+		case !fe.Created && string(content) != fe.OldContent:
+			return fmt.Errorf("file %s was modified since the edit was computed", fe.Path)
+		}
+	}
+
+	for _, fe := range fileEdits {
+		switch {
+		case fe.Deleted:
+			if fe.Created {
+				continue
+			}
+			if err := os.Remove(fe.Path); err != nil {
+				return fmt.Errorf("failed to delete file: %w", err)
+			}
+		case fe.NewPath != "":
+			if err := writeFileContent(fe.NewPath, fe.Path, fe.NewContent); err != nil {
+				return err
+			}
+			if !fe.Created {
+				if err := os.Remove(fe.Path); err != nil {
+					return fmt.Errorf("failed to rename file: %w", err)
+				}
+			}
+		default:
+			if err := writeFileContent(fe.Path, fe.Path, fe.NewContent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeFileContent writes content to path, with the permissions of the file
+// at modePath when it exists
+func writeFileContent(path, modePath, content string) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(modePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
 func rangesOverlap(r1, r2 protocol.Range) bool {
 	if r1.Start.Line > r2.End.Line || r2.Start.Line > r1.End.Line {
 		return false
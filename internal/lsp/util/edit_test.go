@@ -0,0 +1,103 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewWorkspaceEdit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	require.NoError(t, os.WriteFile(path, []byte("func Old() {}\nvar x = Old()\n"), 0o644))
+	uri := protocol.DocumentUri("file://" + path)
+
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			uri: {
+				{Range: protocol.Range{Start: protocol.Position{Line: 0, Character: 5}, End: protocol.Position{Line: 0, Character: 8}}, NewText: "New"},
+				{Range: protocol.Range{Start: protocol.Position{Line: 1, Character: 8}, End: protocol.Position{Line: 1, Character: 11}}, NewText: "New"},
+			},
+		},
+	}
+
+	fileEdits, err := PreviewWorkspaceEdit(edit)
+	require.NoError(t, err)
+	require.Len(t, fileEdits, 1)
+	assert.Equal(t, path, fileEdits[0].Path)
+	assert.Equal(t, "func Old() {}\nvar x = Old()\n", fileEdits[0].OldContent)
+	assert.Equal(t, "func New() {}\nvar x = New()\n", fileEdits[0].NewContent)
+
+	// The preview must not touch the file
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "func Old() {}\nvar x = Old()\n", string(content))
+
+	require.NoError(t, ApplyWorkspaceEdit(edit))
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, fileEdits[0].NewContent, string(content))
+}
+
+func TestPreviewWorkspaceEditRenameFile(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.go")
+	newPath := filepath.Join(dir, "new.go")
+	require.NoError(t, os.WriteFile(oldPath, []byte("package a\n"), 0o644))
+
+	edit := protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.DocumentChange{
+			{RenameFile: &protocol.RenameFile{OldURI: protocol.DocumentUri("file://" + oldPath), NewURI: protocol.DocumentUri("file://" + newPath)}},
+		},
+	}
+
+	fileEdits, err := PreviewWorkspaceEdit(edit)
+	require.NoError(t, err)
+	require.Len(t, fileEdits, 1)
+	assert.Equal(t, oldPath, fileEdits[0].Path)
+	assert.Equal(t, newPath, fileEdits[0].NewPath)
+	assert.Equal(t, "package a\n", fileEdits[0].NewContent)
+}
+
+func TestWriteFileEdits(t *testing.T) {
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.go")
+	renamed := filepath.Join(dir, "renamed.go")
+	deleted := filepath.Join(dir, "deleted.go")
+	require.NoError(t, os.WriteFile(edited, []byte("package a\n"), 0o600))
+	require.NoError(t, os.WriteFile(renamed, []byte("package a\n"), 0o644))
+	require.NoError(t, os.WriteFile(deleted, []byte("package a\n"), 0o644))
+
+	fileEdits := []FileEdit{
+		{Path: edited, OldContent: "package a\n", NewContent: "package b\n"},
+		{Path: renamed, OldContent: "package a\n", NewContent: "package a\n", NewPath: filepath.Join(dir, "sub", "new.go")},
+		{Path: deleted, OldContent: "package a\n", Deleted: true},
+		{Path: filepath.Join(dir, "created.go"), NewContent: "package c\n", Created: true},
+	}
+
+	// Nothing is written when a file changed since the preview
+	require.NoError(t, os.WriteFile(deleted, []byte("package changed\n"), 0o644))
+	assert.ErrorContains(t, WriteFileEdits(fileEdits), "deleted.go was modified")
+	content, err := os.ReadFile(edited)
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n", string(content))
+
+	require.NoError(t, os.WriteFile(deleted, []byte("package a\n"), 0o644))
+	require.NoError(t, WriteFileEdits(fileEdits))
+	content, err = os.ReadFile(edited)
+	require.NoError(t, err)
+	assert.Equal(t, "package b\n", string(content))
+	info, err := os.Stat(edited)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	assert.NoFileExists(t, renamed)
+	assert.FileExists(t, filepath.Join(dir, "sub", "new.go"))
+	assert.NoFileExists(t, deleted)
+	content, err = os.ReadFile(filepath.Join(dir, "created.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package c\n", string(content))
+}
@@ -0,0 +1,225 @@
+package watcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// errWatchLimit is returned by a backend that can't watch more directories
+var errWatchLimit = errors.New("watch limit reached")
+
+// Backend reports the changes to the files of the directories it watches
+type Backend interface {
+	// Add watches the entries of a directory, not recursively
+	Add(dir string) error
+	Remove(dir string) error
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+	Close() error
+}
+
+// fsnotifyBackend watches directories with the notifications of the operating
+// system, up to a maximum number of directories
+type fsnotifyBackend struct {
+	watcher    *fsnotify.Watcher
+	maxWatches int
+	// watches counts the directories added, including the ones deleted or
+	// moved since, whose watches fsnotify drops by itself
+	watches int
+}
+
+func newFsnotifyBackend(maxWatches int) (*fsnotifyBackend, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &fsnotifyBackend{watcher: watcher, maxWatches: maxWatches}, nil
+}
+
+func (b *fsnotifyBackend) Add(dir string) error {
+	if b.maxWatches > 0 && b.watches >= b.maxWatches {
+		// Recount without the watches dropped since
+		b.watches = len(b.watcher.WatchList())
+		if b.watches >= b.maxWatches {
+			return errWatchLimit
+		}
+	}
+	if err := b.watcher.Add(dir); err != nil {
+		// The inotify limits of the system are reached
+		if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE) {
+			return errWatchLimit
+		}
+		return err
+	}
+	b.watches++
+	return nil
+}
+
+func (b *fsnotifyBackend) Remove(dir string) error {
+	if err := b.watcher.Remove(dir); err != nil {
+		return err
+	}
+	b.watches--
+	return nil
+}
+
+func (b *fsnotifyBackend) Events() <-chan fsnotify.Event { return b.watcher.Events }
+
+func (b *fsnotifyBackend) Errors() <-chan error { return b.watcher.Errors }
+
+func (b *fsnotifyBackend) Close() error { return b.watcher.Close() }
+
+// pollingBackend watches directories by listing them periodically, for the
+// workspaces too large to be watched with notifications
+type pollingBackend struct {
+	interval time.Duration
+	events   chan fsnotify.Event
+	errors   chan error
+	done     chan struct{}
+	closed   sync.Once
+
+	mu   sync.Mutex
+	dirs map[string]map[string]entryState
+}
+
+// entryState is what a poll compares to detect a change
+type entryState struct {
+	modTime time.Time
+	size    int64
+	isDir   bool
+}
+
+func newPollingBackend(interval time.Duration) *pollingBackend {
+	b := &pollingBackend{
+		interval: interval,
+		events:   make(chan fsnotify.Event, 256),
+		errors:   make(chan error, 1),
+		done:     make(chan struct{}),
+		dirs:     make(map[string]map[string]entryState),
+	}
+	go b.run()
+	return b
+}
+
+func (b *pollingBackend) Add(dir string) error {
+	entries, err := listDir(dir)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.dirs[dir]; !ok {
+		b.dirs[dir] = entries
+	}
+	return nil
+}
+
+func (b *pollingBackend) Remove(dir string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.dirs, dir)
+	return nil
+}
+
+func (b *pollingBackend) Events() <-chan fsnotify.Event { return b.events }
+
+func (b *pollingBackend) Errors() <-chan error { return b.errors }
+
+func (b *pollingBackend) Close() error {
+	b.closed.Do(func() { close(b.done) })
+	return nil
+}
+
+func (b *pollingBackend) run() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			for _, event := range b.poll() {
+				select {
+				case b.events <- event:
+				case <-b.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// poll lists the watched directories and returns the changes since the
+// previous poll
+func (b *pollingBackend) poll() []fsnotify.Event {
+	b.mu.Lock()
+	dirs := make([]string, 0, len(b.dirs))
+	for dir := range b.dirs {
+		dirs = append(dirs, dir)
+	}
+	b.mu.Unlock()
+
+	var events []fsnotify.Event
+	for _, dir := range dirs {
+		entries, err := listDir(dir)
+
+		b.mu.Lock()
+		previous, ok := b.dirs[dir]
+		if !ok {
+			// Removed while listing
+			b.mu.Unlock()
+			continue
+		}
+		if err != nil {
+			// The directory itself is gone, its parent reports it
+			delete(b.dirs, dir)
+			b.mu.Unlock()
+			continue
+		}
+		b.dirs[dir] = entries
+		b.mu.Unlock()
+
+		for name, state := range entries {
+			path := filepath.Join(dir, name)
+			old, existed := previous[name]
+			switch {
+			case !existed:
+				events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Create})
+			case !state.isDir && (state.modTime != old.modTime || state.size != old.size):
+				events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Write})
+			}
+		}
+		for name := range previous {
+			if _, exists := entries[name]; !exists {
+				events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Remove})
+			}
+		}
+	}
+	return events
+}
+
+func listDir(dir string) (map[string]entryState, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]entryState, len(dirEntries))
+	for _, entry := range dirEntries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		entries[entry.Name()] = entryState{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+			isDir:   entry.IsDir(),
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,218 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/fileutil"
+	"github.com/opencode-ai/opencode/internal/logging"
+)
+
+// subscriberBuffer is the number of events queued for a slow subscriber
+// before its events are dropped
+const subscriberBuffer = 1024
+
+// FileWatcher watches the workspace once for all the LSP clients. Paths
+// ignored by .gitignore and .ignore files, by the configured exclude patterns
+// or by the default exclusions are not watched. When the workspace has too
+// many directories to be watched with notifications, it is polled instead.
+type FileWatcher struct {
+	root    string
+	ignorer *fileutil.Ignorer
+	cfg     config.WatcherConfig
+
+	// backend is only used by the Run goroutine
+	backend Backend
+	polling bool
+
+	subscribersMu sync.Mutex
+	subscribers   map[*WorkspaceWatcher]chan fsnotify.Event
+}
+
+// NewFileWatcher creates a watcher for the workspace at root
+func NewFileWatcher(root string) *FileWatcher {
+	cfg := config.Get().Watcher
+	return &FileWatcher{
+		root:        root,
+		ignorer:     fileutil.NewIgnorer(root, cfg.Exclude),
+		cfg:         cfg,
+		subscribers: make(map[*WorkspaceWatcher]chan fsnotify.Event),
+	}
+}
+
+// Root returns the directory of the watched workspace
+func (fw *FileWatcher) Root() string {
+	return fw.root
+}
+
+// IsExcluded reports whether the files at path are neither watched nor opened
+func (fw *FileWatcher) IsExcluded(path string, isDir bool) bool {
+	if isDir && path != fw.root && shouldExcludeDir(path) {
+		return true
+	}
+	return fw.ignorer.IsIgnored(path, isDir)
+}
+
+// Run watches the workspace and dispatches its events to the subscribers until
+// ctx is done
+func (fw *FileWatcher) Run(ctx context.Context) {
+	dirs := fw.walk(fw.root)
+	if err := fw.start(dirs); err != nil {
+		logging.Error("Error starting file watcher", "error", err)
+		return
+	}
+	defer func() { fw.backend.Close() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-fw.backend.Events():
+			if !ok {
+				return
+			}
+			fw.handleEvent(event)
+		case err, ok := <-fw.backend.Errors():
+			if !ok {
+				return
+			}
+			logging.Error("Error watching file", "error", err)
+		}
+	}
+}
+
+// start creates the backend and watches dirs with it
+func (fw *FileWatcher) start(dirs []string) error {
+	switch fw.cfg.Backend {
+	case "polling":
+		fw.startPolling(dirs, "configured")
+		return nil
+	case "fsnotify":
+	default:
+		if fw.cfg.MaxWatches > 0 && len(dirs) > fw.cfg.MaxWatches {
+			fw.startPolling(dirs, "too many directories")
+			return nil
+		}
+	}
+
+	backend, err := newFsnotifyBackend(fw.cfg.MaxWatches)
+	if err != nil {
+		if fw.cfg.Backend == "fsnotify" {
+			return err
+		}
+		fw.startPolling(dirs, err.Error())
+		return nil
+	}
+	fw.backend = backend
+	logging.Debug("Watching workspace", "root", fw.root, "directories", len(dirs))
+	fw.addDirs(dirs)
+	return nil
+}
+
+// startPolling replaces the backend with a polling one
+func (fw *FileWatcher) startPolling(dirs []string, reason string) {
+	if fw.backend != nil {
+		fw.backend.Close()
+	}
+	interval := time.Duration(max(fw.cfg.PollInterval, 1)) * time.Second
+	fw.backend = newPollingBackend(interval)
+	fw.polling = true
+	logging.Info("Polling workspace for changes", "root", fw.root, "directories", len(dirs), "interval", interval, "reason", reason)
+	fw.addDirs(dirs)
+}
+
+// addDirs watches dirs, and falls back to polling when the backend can't
+// watch all of them
+func (fw *FileWatcher) addDirs(dirs []string) {
+	for _, dir := range dirs {
+		err := fw.backend.Add(dir)
+		if errors.Is(err, errWatchLimit) {
+			if fw.polling || fw.cfg.Backend == "fsnotify" {
+				logging.Warn("Too many directories to watch, some changes will be missed", "limit", fw.cfg.MaxWatches)
+				return
+			}
+			fw.startPolling(fw.walk(fw.root), "watch limit reached")
+			return
+		}
+		if err != nil {
+			logging.Debug("Error watching directory", "path", dir, "error", err)
+		}
+	}
+}
+
+// walk returns the directories of the tree at dir that are not excluded
+func (fw *FileWatcher) walk(dir string) []string {
+	var dirs []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if fw.IsExcluded(path, true) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	if err != nil {
+		logging.Debug("Error walking workspace", "path", dir, "error", err)
+	}
+	return dirs
+}
+
+// handleEvent watches new directories and passes the event to the
+// subscribers, unless its path is excluded
+func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
+	if fileutil.IsIgnoreFile(event.Name) {
+		fw.ignorer.Invalidate()
+	}
+
+	isDir := false
+	if event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+		if info, err := os.Stat(event.Name); err == nil {
+			isDir = info.IsDir()
+		}
+	}
+	if fw.IsExcluded(event.Name, isDir) {
+		return
+	}
+	if isDir && event.Op&fsnotify.Create != 0 {
+		fw.addDirs(fw.walk(event.Name))
+	}
+
+	fw.subscribersMu.Lock()
+	defer fw.subscribersMu.Unlock()
+	for _, events := range fw.subscribers {
+		select {
+		case events <- event:
+		default:
+			logging.Debug("Dropping file event for busy LSP client", "path", event.Name)
+		}
+	}
+}
+
+// subscribe returns the channel the events of the workspace are sent to
+func (fw *FileWatcher) subscribe(w *WorkspaceWatcher) <-chan fsnotify.Event {
+	fw.subscribersMu.Lock()
+	defer fw.subscribersMu.Unlock()
+	events := make(chan fsnotify.Event, subscriberBuffer)
+	fw.subscribers[w] = events
+	return events
+}
+
+func (fw *FileWatcher) unsubscribe(w *WorkspaceWatcher) {
+	fw.subscribersMu.Lock()
+	defer fw.subscribersMu.Unlock()
+	delete(fw.subscribers, w)
+}
@@ -0,0 +1,136 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWatcherWalk(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"src/pkg", "generated/api", "node_modules/lib", "vendored"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("generated/\n"), 0o644))
+	_, err := config.Load(root, false)
+	require.NoError(t, err)
+
+	fw := NewFileWatcher(root)
+	dirs := fw.walk(root)
+	assert.ElementsMatch(t, []string{
+		root,
+		filepath.Join(root, "src"),
+		filepath.Join(root, "src", "pkg"),
+		filepath.Join(root, "vendored"),
+	}, dirs)
+
+	// Too many directories for the cap, the workspace is polled
+	fw.cfg.Backend = "auto"
+	fw.cfg.MaxWatches = 2
+	require.NoError(t, fw.start(dirs))
+	defer fw.backend.Close()
+	assert.True(t, fw.polling)
+}
+
+func TestFileWatcherDispatch(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\n"), 0o644))
+	_, err := config.Load(root, false)
+	require.NoError(t, err)
+
+	fw := NewFileWatcher(root)
+	fw.cfg.Backend = "fsnotify"
+	events := fw.subscribe(&WorkspaceWatcher{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fw.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The ignored file is written first, so it would be received first
+	require.Eventually(t, func() bool {
+		require.NoError(t, os.WriteFile(filepath.Join(root, "debug.log"), []byte("x"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644))
+		select {
+		case event := <-events:
+			assert.Equal(t, filepath.Join(root, "main.go"), event.Name)
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPollingBackend(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.go")
+	require.NoError(t, os.WriteFile(existing, []byte("package a\n"), 0o644))
+
+	backend := newPollingBackend(10 * time.Millisecond)
+	defer backend.Close()
+	require.NoError(t, backend.Add(dir))
+
+	next := func() fsnotify.Event {
+		select {
+		case event := <-backend.Events():
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+			return fsnotify.Event{}
+		}
+	}
+
+	created := filepath.Join(dir, "new.go")
+	require.NoError(t, os.WriteFile(created, []byte("package a\n"), 0o644))
+	assert.Equal(t, fsnotify.Event{Name: created, Op: fsnotify.Create}, next())
+
+	require.NoError(t, os.WriteFile(existing, []byte("package a\n\nfunc A() {}\n"), 0o644))
+	assert.Equal(t, fsnotify.Event{Name: existing, Op: fsnotify.Write}, next())
+
+	require.NoError(t, os.Remove(created))
+	assert.Equal(t, fsnotify.Event{Name: created, Op: fsnotify.Remove}, next())
+}
+
+func TestFsnotifyBackendLimit(t *testing.T) {
+	root := t.TempDir()
+	dirs := make([]string, 3)
+	for i := range dirs {
+		dirs[i] = filepath.Join(root, string(rune('a'+i)))
+		require.NoError(t, os.Mkdir(dirs[i], 0o755))
+	}
+
+	backend, err := newFsnotifyBackend(2)
+	require.NoError(t, err)
+	defer backend.Close()
+	go func() {
+		for range backend.Events() {
+		}
+	}()
+
+	require.NoError(t, backend.Add(dirs[0]))
+	require.NoError(t, backend.Add(dirs[1]))
+	assert.ErrorIs(t, backend.Add(dirs[2]), errWatchLimit)
+
+	require.NoError(t, backend.Remove(dirs[1]))
+	require.NoError(t, backend.Add(dirs[2]))
+	assert.ErrorIs(t, backend.Add(dirs[1]), errWatchLimit)
+
+	// The watches of deleted directories no longer count
+	require.NoError(t, os.RemoveAll(dirs[0]))
+	assert.Eventually(t, func() bool {
+		return backend.Add(dirs[1]) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
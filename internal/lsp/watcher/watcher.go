@@ -17,27 +17,30 @@ import (
 	"github.com/opencode-ai/opencode/internal/lsp/protocol"
 )
 
-// WorkspaceWatcher manages LSP file watching
+// WorkspaceWatcher forwards the file events of the workspace to an LSP client
 type WorkspaceWatcher struct {
 	client        *lsp.Client
 	workspacePath string
+	fileWatcher   *FileWatcher
 
 	debounceTime time.Duration
 	debounceMap  map[string]*time.Timer
 	debounceMu   sync.Mutex
 
 	// File watchers registered by the server
-	registrations  []protocol.FileSystemWatcher
-	registrationMu sync.RWMutex
+	registrations   []protocol.FileSystemWatcher
+	registrationIDs map[string]bool
+	registrationMu  sync.RWMutex
 }
 
 // NewWorkspaceWatcher creates a new workspace watcher
 func NewWorkspaceWatcher(client *lsp.Client) *WorkspaceWatcher {
 	return &WorkspaceWatcher{
-		client:        client,
-		debounceTime:  300 * time.Millisecond,
-		debounceMap:   make(map[string]*time.Timer),
-		registrations: []protocol.FileSystemWatcher{},
+		client:          client,
+		debounceTime:    300 * time.Millisecond,
+		debounceMap:     make(map[string]*time.Timer),
+		registrations:   []protocol.FileSystemWatcher{},
+		registrationIDs: make(map[string]bool),
 	}
 }
 
@@ -49,6 +52,12 @@ func (w *WorkspaceWatcher) AddRegistrations(ctx context.Context, id string, watc
 	w.registrationMu.Lock()
 	defer w.registrationMu.Unlock()
 
+	// A restarted server registers its watchers again
+	if w.registrationIDs[id] {
+		return
+	}
+	w.registrationIDs[id] = true
+
 	// Add new watchers
 	w.registrations = append(w.registrations, watchers...)
 
@@ -147,7 +156,7 @@ func (w *WorkspaceWatcher) AddRegistrations(ctx context.Context, id string, watc
 
 				// Skip directories that should be excluded
 				if d.IsDir() {
-					if path != w.workspacePath && shouldExcludeDir(path) {
+					if path != w.workspacePath && w.fileWatcher.IsExcluded(path, true) {
 						if cnf.DebugLSP {
 							logging.Debug("Skipping excluded directory", "path", path)
 						}
@@ -274,7 +283,7 @@ func (w *WorkspaceWatcher) openHighPriorityFiles(ctx context.Context, serverName
 
 			// Skip directories and excluded files
 			info, err := os.Stat(fullPath)
-			if err != nil || info.IsDir() || shouldExcludeFile(fullPath) {
+			if err != nil || info.IsDir() || w.isExcludedFile(fullPath) {
 				continue
 			}
 
@@ -303,10 +312,11 @@ func (w *WorkspaceWatcher) openHighPriorityFiles(ctx context.Context, serverName
 	return filesOpened
 }
 
-// WatchWorkspace sets up file watching for a workspace
-func (w *WorkspaceWatcher) WatchWorkspace(ctx context.Context, workspacePath string) {
-	cnf := config.Get()
-	w.workspacePath = workspacePath
+// WatchWorkspace forwards the events of the shared file watcher to the client
+// until ctx is done
+func (w *WorkspaceWatcher) WatchWorkspace(ctx context.Context, fileWatcher *FileWatcher) {
+	w.workspacePath = fileWatcher.Root()
+	w.fileWatcher = fileWatcher
 
 	// Store the watcher in the context for later use
 	ctx = context.WithValue(ctx, "workspaceWatcher", w)
@@ -318,133 +328,87 @@ func (w *WorkspaceWatcher) WatchWorkspace(ctx context.Context, workspacePath str
 	}
 
 	serverName := getServerNameFromContext(ctx)
-	logging.Debug("Starting workspace watcher", "workspacePath", workspacePath, "serverName", serverName)
+	logging.Debug("Starting workspace watcher", "workspacePath", w.workspacePath, "serverName", serverName)
 
 	// Register handler for file watcher registrations from the server
-	lsp.RegisterFileWatchHandler(func(id string, watchers []protocol.FileSystemWatcher) {
+	w.client.RegisterFileWatchHandler(func(id string, watchers []protocol.FileSystemWatcher) {
 		w.AddRegistrations(ctx, id, watchers)
 	})
 
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		logging.Error("Error creating watcher", "error", err)
-	}
-	defer watcher.Close()
+	events := fileWatcher.subscribe(w)
+	defer fileWatcher.unsubscribe(w)
 
-	// Watch the workspace recursively
-	err = filepath.WalkDir(workspacePath, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			w.handleEvent(ctx, event)
 		}
+	}
+}
 
-		// Skip excluded directories (except workspace root)
-		if d.IsDir() && path != workspacePath {
-			if shouldExcludeDir(path) {
-				if cnf.DebugLSP {
-					logging.Debug("Skipping excluded directory", "path", path)
-				}
-				return filepath.SkipDir
-			}
-		}
+// handleEvent notifies the client about a file event, according to the file
+// watchers it registered
+func (w *WorkspaceWatcher) handleEvent(ctx context.Context, event fsnotify.Event) {
+	cnf := config.Get()
+	uri := fmt.Sprintf("file://%s", event.Name)
 
-		// Add directories to watcher
-		if d.IsDir() {
-			err = watcher.Add(path)
-			if err != nil {
-				logging.Error("Error watching path", "path", path, "error", err)
+	// Open newly created files, new directories are watched by the file watcher
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && !info.IsDir() {
+			if !w.isExcludedFile(event.Name) {
+				w.openMatchingFile(ctx, event.Name)
 			}
 		}
+	}
+
+	// Debug logging
+	if cnf.DebugLSP {
+		matched, kind := w.isPathWatched(event.Name)
+		logging.Debug("File event",
+			"path", event.Name,
+			"operation", event.Op.String(),
+			"watched", matched,
+			"kind", kind,
+		)
 
-		return nil
-	})
-	if err != nil {
-		logging.Error("Error walking workspace", "error", err)
 	}
 
-	// Event loop
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case event, ok := <-watcher.Events:
-			if !ok {
+	// Check if this path should be watched according to server registrations
+	if watched, watchKind := w.isPathWatched(event.Name); watched {
+		switch {
+		case event.Op&fsnotify.Write != 0:
+			if watchKind&protocol.WatchChange != 0 {
+				w.debounceHandleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Changed))
+			}
+		case event.Op&fsnotify.Create != 0:
+			// Already handled earlier
+			// Just send the notification if needed
+			info, err := os.Stat(event.Name)
+			if err != nil {
+				logging.Error("Error getting file info", "path", event.Name, "error", err)
 				return
 			}
-
-			uri := fmt.Sprintf("file://%s", event.Name)
-
-			// Add new directories to the watcher
-			if event.Op&fsnotify.Create != 0 {
-				if info, err := os.Stat(event.Name); err == nil {
-					if info.IsDir() {
-						// Skip excluded directories
-						if !shouldExcludeDir(event.Name) {
-							if err := watcher.Add(event.Name); err != nil {
-								logging.Error("Error adding directory to watcher", "path", event.Name, "error", err)
-							}
-						}
-					} else {
-						// For newly created files
-						if !shouldExcludeFile(event.Name) {
-							w.openMatchingFile(ctx, event.Name)
-						}
-					}
-				}
+			if !info.IsDir() && watchKind&protocol.WatchCreate != 0 {
+				w.debounceHandleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Created))
 			}
-
-			// Debug logging
-			if cnf.DebugLSP {
-				matched, kind := w.isPathWatched(event.Name)
-				logging.Debug("File event",
-					"path", event.Name,
-					"operation", event.Op.String(),
-					"watched", matched,
-					"kind", kind,
-				)
-
+		case event.Op&fsnotify.Remove != 0:
+			if watchKind&protocol.WatchDelete != 0 {
+				w.handleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Deleted))
+			}
+		case event.Op&fsnotify.Rename != 0:
+			// For renames, first delete
+			if watchKind&protocol.WatchDelete != 0 {
+				w.handleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Deleted))
 			}
 
-			// Check if this path should be watched according to server registrations
-			if watched, watchKind := w.isPathWatched(event.Name); watched {
-				switch {
-				case event.Op&fsnotify.Write != 0:
-					if watchKind&protocol.WatchChange != 0 {
-						w.debounceHandleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Changed))
-					}
-				case event.Op&fsnotify.Create != 0:
-					// Already handled earlier in the event loop
-					// Just send the notification if needed
-					info, err := os.Stat(event.Name)
-					if err != nil {
-						logging.Error("Error getting file info", "path", event.Name, "error", err)
-						return
-					}
-					if !info.IsDir() && watchKind&protocol.WatchCreate != 0 {
-						w.debounceHandleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Created))
-					}
-				case event.Op&fsnotify.Remove != 0:
-					if watchKind&protocol.WatchDelete != 0 {
-						w.handleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Deleted))
-					}
-				case event.Op&fsnotify.Rename != 0:
-					// For renames, first delete
-					if watchKind&protocol.WatchDelete != 0 {
-						w.handleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Deleted))
-					}
-
-					// Then check if the new file exists and create an event
-					if info, err := os.Stat(event.Name); err == nil && !info.IsDir() {
-						if watchKind&protocol.WatchCreate != 0 {
-							w.debounceHandleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Created))
-						}
-					}
+			// Then check if the new file exists and create an event
+			if info, err := os.Stat(event.Name); err == nil && !info.IsDir() {
+				if watchKind&protocol.WatchCreate != 0 {
+					w.debounceHandleFileEvent(ctx, uri, protocol.FileChangeType(protocol.Created))
 				}
 			}
-		case err, ok := <-watcher.Errors:
-			if !ok {
-				return
-			}
-			logging.Error("Error watching file", "error", err)
 		}
 	}
 }
@@ -690,8 +654,8 @@ func getServerNameFromContext(ctx context.Context) string {
 	}
 
 	// Otherwise, try to extract server name from the client command path
-	if w, ok := ctx.Value("workspaceWatcher").(*WorkspaceWatcher); ok && w != nil && w.client != nil && w.client.Cmd != nil {
-		path := strings.ToLower(w.client.Cmd.Path)
+	if w, ok := ctx.Value("workspaceWatcher").(*WorkspaceWatcher); ok && w != nil && w.client != nil && w.client.Process() != nil {
+		path := strings.ToLower(w.client.Process().Path)
 
 		// Extract server name from path
 		if strings.Contains(path, "typescript") || strings.Contains(path, "tsserver") || strings.Contains(path, "vtsls") {
@@ -732,8 +696,8 @@ func shouldPreloadFiles(serverName string) bool {
 	}
 }
 
-// Common patterns for directories and files to exclude
-// TODO: make configurable
+// Common patterns for directories and files to exclude, on top of the ones
+// ignored by the file watcher
 var (
 	excludedDirNames = map[string]bool{
 		".git":         true,
@@ -853,6 +817,11 @@ func shouldExcludeFile(filePath string) bool {
 	return false
 }
 
+// isExcludedFile reports whether a file should not be opened
+func (w *WorkspaceWatcher) isExcludedFile(path string) bool {
+	return shouldExcludeFile(path) || w.fileWatcher.IsExcluded(path, false)
+}
+
 // openMatchingFile opens a file if it matches any of the registered patterns
 func (w *WorkspaceWatcher) openMatchingFile(ctx context.Context, path string) {
 	cnf := config.Get()
@@ -863,7 +832,7 @@ func (w *WorkspaceWatcher) openMatchingFile(ctx context.Context, path string) {
 	}
 
 	// Skip excluded files
-	if shouldExcludeFile(path) {
+	if w.isExcludedFile(path) {
 		return
 	}
 
@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/lsp/protocol"
+)
+
+// ConfigureWorkspace sets the file extensions the server handles and the files
+// marking the roots of its projects. Without extensions the server is used for
+// every file. It must be called before the client is initialized.
+func (c *Client) ConfigureWorkspace(extensions, rootMarkers []string) {
+	c.extensions = extensions
+	c.rootMarkers = rootMarkers
+}
+
+// HandlesFile reports whether the server handles the file at path
+func (c *Client) HandlesFile(path string) bool {
+	if len(c.extensions) == 0 {
+		return true
+	}
+	ext := filepath.Ext(path)
+	for _, e := range c.extensions {
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		if strings.EqualFold(e, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkspaceFolders returns the project roots the server was told about
+func (c *Client) WorkspaceFolders() []string {
+	c.foldersMu.Lock()
+	defer c.foldersMu.Unlock()
+	return slices.Clone(c.workspaceFolders)
+}
+
+// resetWorkspaceFolders records the folders sent with the initialize request
+// and whether the server accepts more of them
+func (c *Client) resetWorkspaceFolders(workspaceDir string, result protocol.InitializeResult) {
+	c.foldersMu.Lock()
+	defer c.foldersMu.Unlock()
+	c.workspaceFolders = []string{workspaceDir}
+	workspace := result.Capabilities.Workspace
+	c.foldersSupported = workspace != nil && workspace.WorkspaceFolders != nil && workspace.WorkspaceFolders.Supported
+}
+
+// addWorkspaceFolder adds the nearest project root of path to the workspace
+// folders, so that servers index each project of a monorepo with its own
+// module or package configuration.
+func (c *Client) addWorkspaceFolder(ctx context.Context, path string) {
+	if len(c.rootMarkers) == 0 {
+		return
+	}
+	root := config.FindProjectRoot(filepath.Dir(path), c.rootMarkers)
+	if root == "" {
+		return
+	}
+
+	c.foldersMu.Lock()
+	if !c.foldersSupported || slices.Contains(c.workspaceFolders, root) {
+		c.foldersMu.Unlock()
+		return
+	}
+	c.workspaceFolders = append(c.workspaceFolders, root)
+	c.foldersMu.Unlock()
+
+	logging.Debug("Adding LSP workspace folder", "command", c.command, "folder", root)
+	err := c.DidChangeWorkspaceFolders(ctx, protocol.DidChangeWorkspaceFoldersParams{
+		Event: protocol.WorkspaceFoldersChangeEvent{
+			Added:   []protocol.WorkspaceFolder{{URI: protocol.URI("file://" + root), Name: root}},
+			Removed: []protocol.WorkspaceFolder{},
+		},
+	})
+	if err != nil {
+		logging.Debug("Failed to add LSP workspace folder", "folder", root, "error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package lsp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlesFile(t *testing.T) {
+	c := &Client{}
+	assert.True(t, c.HandlesFile("/src/main.go"), "clients without extensions handle every file")
+
+	c.ConfigureWorkspace([]string{".ts", "tsx"}, nil)
+	assert.True(t, c.HandlesFile("/src/app.ts"))
+	assert.True(t, c.HandlesFile("/src/App.TSX"))
+	assert.False(t, c.HandlesFile("/src/main.go"))
+	assert.False(t, c.HandlesFile("/src/Makefile"))
+}
+
+func TestAddWorkspaceFolder(t *testing.T) {
+	dir := t.TempDir()
+	_, err := config.Load(dir, false)
+	require.NoError(t, err)
+	t.Setenv("OPENCODE_TEST_LSP_SERVER", "1")
+	for _, name := range []string{".git/HEAD", "go.work", "main.go", "api/go.mod", "api/api.go", "api/handlers/user.go", "web/go.mod", "web/web.go"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+	}
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, os.Args[0])
+	require.NoError(t, err)
+	defer client.Close()
+	client.ConfigureWorkspace([]string{".go"}, []string{"go.work", "go.mod"})
+	_, err = client.InitializeLSPClient(ctx, dir)
+	require.NoError(t, err)
+
+	// The folder of the workspace is not added again, the others are added
+	// once for all their files
+	for _, name := range []string{"main.go", "api/api.go", "api/handlers/user.go", "web/web.go"} {
+		require.NoError(t, client.OpenFile(ctx, filepath.Join(dir, name)))
+	}
+	var added []string
+	require.NoError(t, client.Call(ctx, "test/addedFolders", nil, &added))
+	assert.Equal(t, []string{"file://" + filepath.Join(dir, "api"), "file://" + filepath.Join(dir, "web")}, added)
+	assert.Equal(t, []string{dir, filepath.Join(dir, "api"), filepath.Join(dir, "web")}, client.WorkspaceFolders())
+}
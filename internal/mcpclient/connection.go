@@ -0,0 +1,371 @@
+package mcpclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/pubsub"
+)
+
+// connection is the connection to a server, checked and reestablished by its
+// monitor goroutine
+type connection struct {
+	service *service
+	name    string
+	cfg     config.MCPServer
+	// check requests a health check from the monitor
+	check chan struct{}
+	// refresh requests the lists of the server again, after it notified a
+	// change
+	refresh chan struct{}
+
+	mu           sync.Mutex
+	client       client.MCPClient
+	capabilities mcp.ServerCapabilities
+	// closed is closed with the client, to end the requests pending on it
+	closed    chan struct{}
+	tools     []mcp.Tool
+	resources []mcp.Resource
+	prompts   []mcp.Prompt
+	state     State
+	err       error
+
+	// reconnects and lastReconnect are only used by the monitor
+	reconnects    int
+	lastReconnect time.Time
+}
+
+// start connects to the server and starts monitoring the connection, or
+// reconnecting when the server can't be reached
+func (c *connection) start(ctx context.Context) {
+	err := c.connect(ctx)
+	if err != nil {
+		logging.WarnPersist(fmt.Sprintf("Failed to connect to MCP server %s, retrying", c.name), "error", err)
+	}
+	c.service.wg.Add(1)
+	go func() {
+		defer c.service.wg.Done()
+		defer logging.RecoverPanic(fmt.Sprintf("MCP-monitor-%s", c.name), nil)
+		c.monitor(err)
+	}()
+}
+
+// connect creates a client and initializes it
+func (c *connection) connect(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
+	mcpClient, err := newClient(c.service.ctx, c.cfg)
+	if err != nil {
+		return err
+	}
+	mcpClient.OnNotification(c.notified)
+	initResult, err := mcpClient.Initialize(ctx, initializeRequest())
+	if err != nil {
+		closeClient(c.name, mcpClient)
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	lists, err := c.list(ctx, mcpClient, initResult.Capabilities)
+	if err != nil {
+		closeClient(c.name, mcpClient)
+		return err
+	}
+	if err := c.service.ctx.Err(); err != nil {
+		// Shut down while connecting
+		closeClient(c.name, mcpClient)
+		return err
+	}
+
+	c.mu.Lock()
+	c.client = mcpClient
+	c.capabilities = initResult.Capabilities
+	c.closed = make(chan struct{})
+	c.tools, c.resources, c.prompts = lists.tools, lists.resources, lists.prompts
+	c.state = StateConnected
+	c.err = nil
+	server := c.snapshotLocked()
+	c.mu.Unlock()
+
+	logging.Info("Connected to MCP server", "name", c.name, "tools", len(lists.tools), "resources", len(lists.resources), "prompts", len(lists.prompts))
+	c.service.Publish(pubsub.UpdatedEvent, server)
+	return nil
+}
+
+// monitor pings the server periodically and when a request fails, and
+// reconnects when it doesn't answer. failed is the error of the initial
+// connection.
+func (c *connection) monitor(failed error) {
+	if failed != nil && !c.reconnect(failed) {
+		return
+	}
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.service.ctx.Done():
+			return
+		case <-c.refresh:
+			c.refreshLists()
+			continue
+		case <-ticker.C:
+		case <-c.check:
+		}
+
+		if err := c.ping(); err != nil {
+			if c.service.ctx.Err() != nil {
+				return
+			}
+			logging.WarnPersist(fmt.Sprintf("MCP server %s is not responding, reconnecting", c.name), "error", err)
+			if !c.reconnect(err) {
+				return
+			}
+		}
+	}
+}
+
+func (c *connection) ping() error {
+	c.mu.Lock()
+	mcpClient := c.client
+	c.mu.Unlock()
+	if mcpClient == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(c.service.ctx, pingTimeout)
+	defer cancel()
+	return mcpClient.Ping(ctx)
+}
+
+// reconnect replaces the client with an exponential backoff. It returns false
+// when it gives up or the service is shut down.
+func (c *connection) reconnect(cause error) bool {
+	c.closeClient()
+	c.setState(StateReconnecting, cause)
+
+	if time.Since(c.lastReconnect) > reconnectResetInterval {
+		c.reconnects = 0
+	}
+	for c.reconnects < maxReconnects {
+		c.reconnects++
+		c.lastReconnect = time.Now()
+
+		delay := reconnectDelay(c.reconnects)
+		logging.Info("Reconnecting to MCP server", "name", c.name, "attempt", c.reconnects, "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-c.service.ctx.Done():
+			return false
+		}
+
+		err := c.connect(c.service.ctx)
+		if err == nil {
+			logging.InfoPersist(fmt.Sprintf("Reconnected to MCP server %s", c.name))
+			return true
+		}
+		if c.service.ctx.Err() != nil {
+			return false
+		}
+		logging.Warn("Failed to reconnect to MCP server", "name", c.name, "attempt", c.reconnects, "error", err)
+		cause = err
+		c.setState(StateReconnecting, cause)
+	}
+
+	c.setState(StateError, cause)
+	logging.ErrorPersist(fmt.Sprintf("MCP server %s is unavailable, giving up after %d attempts", c.name, maxReconnects))
+	return false
+}
+
+// call runs a request on the client. The request ends when the client is
+// closed, and a failed request triggers a health check.
+func (c *connection) call(ctx context.Context, fn func(context.Context, client.MCPClient) error) error {
+	c.mu.Lock()
+	mcpClient, closed, state, lastErr := c.client, c.closed, c.state, c.err
+	c.mu.Unlock()
+	if state != StateConnected {
+		if lastErr != nil {
+			return fmt.Errorf("MCP server %s is %s: %w", c.name, state, lastErr)
+		}
+		return fmt.Errorf("MCP server %s is %s", c.name, state)
+	}
+
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-closed:
+			cancel()
+		case <-callCtx.Done():
+		}
+	}()
+
+	err := fn(callCtx, mcpClient)
+	if err == nil {
+		return nil
+	}
+	select {
+	case <-closed:
+		return fmt.Errorf("MCP server %s disconnected", c.name)
+	default:
+	}
+	if ctx.Err() == nil {
+		select {
+		case c.check <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+// notified handles the notifications of the server
+func (c *connection) notified(notification mcp.JSONRPCNotification) {
+	switch notification.Method {
+	case mcp.MethodNotificationToolsListChanged,
+		mcp.MethodNotificationResourcesListChanged,
+		mcp.MethodNotificationPromptsListChanged:
+		// Requests can't be sent from the notification handler, the monitor
+		// refreshes the lists
+		select {
+		case c.refresh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// refreshLists replaces the lists of the server with new ones
+func (c *connection) refreshLists() {
+	c.mu.Lock()
+	mcpClient, capabilities := c.client, c.capabilities
+	c.mu.Unlock()
+	if mcpClient == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.service.ctx, connectTimeout)
+	defer cancel()
+	lists, err := c.list(ctx, mcpClient, capabilities)
+	if err != nil {
+		logging.Warn("Failed to refresh the lists of MCP server", "name", c.name, "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	if c.client != mcpClient {
+		// Closed meanwhile
+		c.mu.Unlock()
+		return
+	}
+	c.tools, c.resources, c.prompts = lists.tools, lists.resources, lists.prompts
+	server := c.snapshotLocked()
+	c.mu.Unlock()
+
+	logging.Info("MCP server lists changed", "name", c.name, "tools", len(lists.tools), "resources", len(lists.resources), "prompts", len(lists.prompts))
+	c.service.Publish(pubsub.UpdatedEvent, server)
+}
+
+// serverLists are the tools, resources and prompts of a server
+type serverLists struct {
+	tools     []mcp.Tool
+	resources []mcp.Resource
+	prompts   []mcp.Prompt
+}
+
+// list lists the tools of a server, and its resources and prompts when it
+// offers them
+func (c *connection) list(ctx context.Context, mcpClient client.MCPClient, capabilities mcp.ServerCapabilities) (serverLists, error) {
+	var lists serverLists
+	tools, err := mcpClient.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return lists, fmt.Errorf("failed to list tools: %w", err)
+	}
+	lists.tools = enabledTools(c.cfg, tools.Tools)
+
+	// Resources and prompts are optional, the tools work without them
+	if capabilities.Resources != nil {
+		resources, err := mcpClient.ListResources(ctx, mcp.ListResourcesRequest{})
+		if err != nil {
+			logging.Warn("Failed to list MCP resources", "name", c.name, "error", err)
+		} else {
+			lists.resources = resources.Resources
+		}
+	}
+	if capabilities.Prompts != nil {
+		prompts, err := mcpClient.ListPrompts(ctx, mcp.ListPromptsRequest{})
+		if err != nil {
+			logging.Warn("Failed to list MCP prompts", "name", c.name, "error", err)
+		} else {
+			lists.prompts = prompts.Prompts
+		}
+	}
+	return lists, nil
+}
+
+// closeClient closes the current client, if any
+func (c *connection) closeClient() {
+	c.mu.Lock()
+	mcpClient, closed := c.client, c.closed
+	c.client, c.closed = nil, nil
+	c.tools, c.resources, c.prompts = nil, nil, nil
+	c.mu.Unlock()
+
+	if mcpClient == nil {
+		return
+	}
+	close(closed)
+	closeClient(c.name, mcpClient)
+}
+
+func (c *connection) setState(state State, err error) {
+	c.mu.Lock()
+	c.state = state
+	c.err = err
+	server := c.snapshotLocked()
+	c.mu.Unlock()
+	c.service.Publish(pubsub.UpdatedEvent, server)
+}
+
+func (c *connection) snapshot() Server {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.snapshotLocked()
+}
+
+func (c *connection) snapshotLocked() Server {
+	server := Server{
+		Name:  c.name,
+		Type:  c.cfg.Type,
+		State: c.state,
+		Tools: len(c.tools),
+	}
+	if c.err != nil {
+		server.Error = c.err.Error()
+	}
+	return server
+}
+
+// closeClient closes a client, without waiting for more than closeTimeout
+// for a server that doesn't exit
+func closeClient(name string, mcpClient client.MCPClient) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mcpClient.Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(closeTimeout):
+		logging.Warn("MCP server did not exit", "name", name)
+	}
+}
+
+// reconnectDelay returns the backoff before the given reconnection attempt
+func reconnectDelay(attempt int) time.Duration {
+	delay := time.Second << (attempt - 1)
+	return min(delay, maxReconnectDelay)
+}
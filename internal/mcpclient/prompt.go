@@ -0,0 +1,26 @@
+package mcpclient
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// PromptText joins the text of the messages of a prompt, to send it as a
+// single user message. Embedded text resources are inlined, and other
+// contents such as images are skipped.
+func PromptText(result *mcp.GetPromptResult) string {
+	var parts []string
+	for _, message := range result.Messages {
+		switch c := message.Content.(type) {
+		case mcp.TextContent:
+			parts = append(parts, c.Text)
+		case mcp.EmbeddedResource:
+			if text, ok := c.Resource.(mcp.TextResourceContents); ok {
+				parts = append(parts, fmt.Sprintf("<resource uri=%q>\n%s\n</resource>", text.URI, text.Text))
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,322 @@
+// Package mcpclient keeps the connections to the configured MCP servers open
+// for the lifetime of the application, so that servers keep their state
+// between tool calls and stdio servers are only spawned once.
+package mcpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/pubsub"
+	"github.com/opencode-ai/opencode/internal/version"
+)
+
+type State string
+
+const (
+	StateStarting     State = "starting"
+	StateConnected    State = "connected"
+	StateReconnecting State = "reconnecting"
+	// StateError is the state of a server that could not be reconnected
+	StateError State = "error"
+)
+
+const (
+	// connectTimeout bounds the initialization of a connection
+	connectTimeout = 30 * time.Second
+	// healthCheckInterval is the time between two pings of a server
+	healthCheckInterval = 30 * time.Second
+	// pingTimeout is how long a server has to answer a ping
+	pingTimeout = 10 * time.Second
+	// closeTimeout is how long a server has to exit when its connection is closed
+	closeTimeout = 5 * time.Second
+	// maxReconnects is the number of reconnections attempted before giving up
+	maxReconnects = 5
+	// reconnectResetInterval is how long a connection has to stay up for its
+	// reconnection count to be reset
+	reconnectResetInterval = 5 * time.Minute
+	maxReconnectDelay      = 30 * time.Second
+)
+
+// Server is a snapshot of the connection to an MCP server
+type Server struct {
+	Name  string         `json:"name"`
+	Type  config.MCPType `json:"type"`
+	State State          `json:"state"`
+	// Tools is the number of tools of the server
+	Tools int    `json:"tools"`
+	Error string `json:"error,omitempty"`
+}
+
+// Resource is a resource offered by a server
+type Resource struct {
+	Server string
+	mcp.Resource
+}
+
+// Prompt is a prompt template offered by a server
+type Prompt struct {
+	Server string
+	mcp.Prompt
+}
+
+// Service manages the connections to the MCP servers of the configuration
+type Service interface {
+	pubsub.Suscriber[Server]
+	// Start connects to the servers and returns once each of them is
+	// connected or failed to connect
+	Start(ctx context.Context)
+	Servers() []Server
+	// Tools returns the tools of the connected servers, by server name
+	Tools() map[string][]mcp.Tool
+	CallTool(ctx context.Context, server, tool string, args map[string]any) (*mcp.CallToolResult, error)
+	// Resources returns the resources of the connected servers
+	Resources() []Resource
+	ReadResource(ctx context.Context, server, uri string) (*mcp.ReadResourceResult, error)
+	// Prompts returns the prompts of the connected servers
+	Prompts() []Prompt
+	GetPrompt(ctx context.Context, server, name string, args map[string]string) (*mcp.GetPromptResult, error)
+	// Shutdown closes the connections and stops the servers
+	Shutdown()
+}
+
+// newClient creates the client of a server, whose connection lasts until ctx
+// is done or the client is closed. It is replaced in tests.
+var newClient = func(ctx context.Context, cfg config.MCPServer) (client.MCPClient, error) {
+	switch cfg.Type {
+	case config.MCPStdio:
+		// Started by the client rather than with NewStdioMCPClient, which
+		// doesn't deliver the notifications of the server
+		return startClient(ctx, client.NewClient(transport.NewStdio(cfg.Command, cfg.Env, cfg.Args...)))
+	case config.MCPSse:
+		c, err := client.NewSSEMCPClient(cfg.URL, client.WithHeaders(cfg.Headers))
+		if err != nil {
+			return nil, err
+		}
+		return startClient(ctx, c)
+	case config.MCPHttp:
+		// The continuous listening receives the notifications the server
+		// sends outside of the responses to requests
+		c, err := client.NewStreamableHttpClient(
+			cfg.URL,
+			transport.WithHTTPHeaders(cfg.Headers),
+			transport.WithContinuousListening(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return startClient(ctx, c)
+	}
+	return nil, fmt.Errorf("invalid mcp type: %q", cfg.Type)
+}
+
+func startClient(ctx context.Context, c *client.Client) (client.MCPClient, error) {
+	if err := c.Start(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+type service struct {
+	*pubsub.Broker[Server]
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	connections map[string]*connection
+}
+
+func NewService(servers map[string]config.MCPServer) Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &service{
+		Broker:      pubsub.NewBroker[Server](),
+		ctx:         ctx,
+		cancel:      cancel,
+		connections: make(map[string]*connection, len(servers)),
+	}
+	for name, cfg := range servers {
+		s.connections[name] = &connection{
+			service: s,
+			name:    name,
+			cfg:     cfg,
+			check:   make(chan struct{}, 1),
+			refresh: make(chan struct{}, 1),
+			state:   StateStarting,
+		}
+	}
+	return s
+}
+
+func (s *service) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, conn := range s.connections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn.start(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *service) Servers() []Server {
+	servers := make([]Server, 0, len(s.connections))
+	for _, conn := range s.connections {
+		servers = append(servers, conn.snapshot())
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers
+}
+
+func (s *service) Tools() map[string][]mcp.Tool {
+	tools := make(map[string][]mcp.Tool)
+	for name, conn := range s.connections {
+		conn.mu.Lock()
+		if conn.state == StateConnected && len(conn.tools) > 0 {
+			tools[name] = conn.tools
+		}
+		conn.mu.Unlock()
+	}
+	return tools
+}
+
+func (s *service) CallTool(ctx context.Context, server, tool string, args map[string]any) (*mcp.CallToolResult, error) {
+	conn, err := s.connection(server)
+	if err != nil {
+		return nil, err
+	}
+	var result *mcp.CallToolResult
+	err = conn.call(ctx, func(ctx context.Context, c client.MCPClient) error {
+		if conn.cfg.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(conn.cfg.Timeout)*time.Second)
+			defer cancel()
+		}
+		request := mcp.CallToolRequest{}
+		request.Params.Name = tool
+		request.Params.Arguments = args
+		var err error
+		result, err = c.CallTool(ctx, request)
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+			return fmt.Errorf("MCP tool %s timed out after %d seconds", tool, conn.cfg.Timeout)
+		}
+		return err
+	})
+	return result, err
+}
+
+func (s *service) Resources() []Resource {
+	var resources []Resource
+	for _, name := range s.names() {
+		conn := s.connections[name]
+		conn.mu.Lock()
+		if conn.state == StateConnected {
+			for _, resource := range conn.resources {
+				resources = append(resources, Resource{Server: name, Resource: resource})
+			}
+		}
+		conn.mu.Unlock()
+	}
+	return resources
+}
+
+func (s *service) ReadResource(ctx context.Context, server, uri string) (*mcp.ReadResourceResult, error) {
+	conn, err := s.connection(server)
+	if err != nil {
+		return nil, err
+	}
+	var result *mcp.ReadResourceResult
+	err = conn.call(ctx, func(ctx context.Context, c client.MCPClient) error {
+		request := mcp.ReadResourceRequest{}
+		request.Params.URI = uri
+		var err error
+		result, err = c.ReadResource(ctx, request)
+		return err
+	})
+	return result, err
+}
+
+func (s *service) Prompts() []Prompt {
+	var prompts []Prompt
+	for _, name := range s.names() {
+		conn := s.connections[name]
+		conn.mu.Lock()
+		if conn.state == StateConnected {
+			for _, prompt := range conn.prompts {
+				prompts = append(prompts, Prompt{Server: name, Prompt: prompt})
+			}
+		}
+		conn.mu.Unlock()
+	}
+	return prompts
+}
+
+func (s *service) GetPrompt(ctx context.Context, server, name string, args map[string]string) (*mcp.GetPromptResult, error) {
+	conn, err := s.connection(server)
+	if err != nil {
+		return nil, err
+	}
+	var result *mcp.GetPromptResult
+	err = conn.call(ctx, func(ctx context.Context, c client.MCPClient) error {
+		request := mcp.GetPromptRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		var err error
+		result, err = c.GetPrompt(ctx, request)
+		return err
+	})
+	return result, err
+}
+
+func (s *service) Shutdown() {
+	s.cancel()
+	var wg sync.WaitGroup
+	for _, conn := range s.connections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn.closeClient()
+		}()
+	}
+	wg.Wait()
+	s.wg.Wait()
+	s.Broker.Shutdown()
+}
+
+func (s *service) connection(server string) (*connection, error) {
+	conn, ok := s.connections[server]
+	if !ok {
+		return nil, fmt.Errorf("unknown MCP server: %s", server)
+	}
+	return conn, nil
+}
+
+// names returns the names of the servers, sorted
+func (s *service) names() []string {
+	names := make([]string, 0, len(s.connections))
+	for name := range s.connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func initializeRequest() mcp.InitializeRequest {
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{
+		Name:    "OpenCode",
+		Version: version.Version,
+	}
+	return request
+}
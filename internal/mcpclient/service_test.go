@@ -0,0 +1,281 @@
+package mcpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient is a server answering with the text of the tool called, until
+// it is killed. It offers resources and a prompt.
+type fakeClient struct {
+	client.MCPClient
+
+	initialized atomic.Int32
+	dead        atomic.Bool
+	closed      atomic.Bool
+	// tools is the number of tools listed after the first one
+	tools atomic.Int32
+
+	mu     sync.Mutex
+	notify func(notification mcp.JSONRPCNotification)
+}
+
+func (c *fakeClient) OnNotification(handler func(notification mcp.JSONRPCNotification)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notify = handler
+}
+
+func (c *fakeClient) notification(method string) {
+	c.mu.Lock()
+	notify := c.notify
+	c.mu.Unlock()
+	notification := mcp.JSONRPCNotification{JSONRPC: mcp.JSONRPC_VERSION}
+	notification.Method = method
+	notify(notification)
+}
+
+func (c *fakeClient) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	c.initialized.Add(1)
+	result := &mcp.InitializeResult{}
+	result.Capabilities.Resources = &struct {
+		Subscribe   bool `json:"subscribe,omitempty"`
+		ListChanged bool `json:"listChanged,omitempty"`
+	}{}
+	result.Capabilities.Prompts = &struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	}{}
+	return result, nil
+}
+
+func (c *fakeClient) ListResources(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	return &mcp.ListResourcesResult{Resources: []mcp.Resource{
+		mcp.NewResource("file:///a.txt", "a"),
+		mcp.NewResource("file:///b.txt", "b"),
+	}}, nil
+}
+
+func (c *fakeClient) ReadResource(ctx context.Context, request mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	return &mcp.ReadResourceResult{Contents: []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: request.Params.URI, Text: "contents"},
+	}}, nil
+}
+
+func (c *fakeClient) ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	return &mcp.ListPromptsResult{Prompts: []mcp.Prompt{
+		mcp.NewPrompt("review", mcp.WithArgument("file")),
+	}}, nil
+}
+
+func (c *fakeClient) GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return mcp.NewGetPromptResult("", []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("Review "+request.Params.Arguments["file"])),
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(mcp.TextResourceContents{URI: "file:///a.txt", Text: "contents"})),
+	}), nil
+}
+
+func (c *fakeClient) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	tools := []mcp.Tool{mcp.NewTool("echo")}
+	for i := range c.tools.Load() {
+		tools = append(tools, mcp.NewTool(fmt.Sprintf("tool%d", i)))
+	}
+	return &mcp.ListToolsResult{Tools: tools}, nil
+}
+
+func (c *fakeClient) Ping(ctx context.Context) error {
+	if c.dead.Load() {
+		return errors.New("broken pipe")
+	}
+	return nil
+}
+
+func (c *fakeClient) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if c.dead.Load() {
+		return nil, errors.New("broken pipe")
+	}
+	if request.Params.Name == "slow" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(request.Params.Name)}}, nil
+}
+
+func (c *fakeClient) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+// fakeClients replaces the clients created by the service
+func fakeClients(t *testing.T) func() []*fakeClient {
+	var mu sync.Mutex
+	var clients []*fakeClient
+	original := newClient
+	newClient = func(ctx context.Context, cfg config.MCPServer) (client.MCPClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		c := &fakeClient{}
+		clients = append(clients, c)
+		return c, nil
+	}
+	t.Cleanup(func() { newClient = original })
+	return func() []*fakeClient {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*fakeClient(nil), clients...)
+	}
+}
+
+func callText(t *testing.T, s Service) (string, error) {
+	t.Helper()
+	result, err := s.CallTool(context.Background(), "fake", "echo", nil)
+	if err != nil {
+		return "", err
+	}
+	require.Len(t, result.Content, 1)
+	return result.Content[0].(mcp.TextContent).Text, nil
+}
+
+func TestServiceReusesConnection(t *testing.T) {
+	clients := fakeClients(t)
+	s := NewService(map[string]config.MCPServer{"fake": {Type: config.MCPStdio}})
+	s.Start(context.Background())
+
+	assert.Equal(t, []Server{{Name: "fake", Type: config.MCPStdio, State: StateConnected, Tools: 1}}, s.Servers())
+	assert.Len(t, s.Tools()["fake"], 1)
+	for range 3 {
+		text, err := callText(t, s)
+		require.NoError(t, err)
+		assert.Equal(t, "echo", text)
+	}
+	require.Len(t, clients(), 1)
+	assert.Equal(t, int32(1), clients()[0].initialized.Load())
+
+	_, err := s.CallTool(context.Background(), "missing", "echo", nil)
+	assert.EqualError(t, err, "unknown MCP server: missing")
+
+	s.Shutdown()
+	assert.True(t, clients()[0].closed.Load())
+}
+
+func TestServiceReconnects(t *testing.T) {
+	clients := fakeClients(t)
+	s := NewService(map[string]config.MCPServer{"fake": {Type: config.MCPStdio}})
+	s.Start(context.Background())
+	defer s.Shutdown()
+
+	events := s.Subscribe(context.Background())
+	clients()[0].dead.Store(true)
+
+	// The failed call triggers a health check, which reconnects
+	_, err := callText(t, s)
+	require.Error(t, err)
+	select {
+	case event := <-events:
+		assert.Equal(t, StateReconnecting, event.Payload.State)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reconnection")
+	}
+	_, err = callText(t, s)
+	assert.ErrorContains(t, err, "MCP server fake is reconnecting")
+
+	require.Eventually(t, func() bool {
+		return s.Servers()[0].State == StateConnected
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, clients(), 2)
+	assert.True(t, clients()[0].closed.Load())
+	text, err := callText(t, s)
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+}
+
+func TestServiceResourcesAndPrompts(t *testing.T) {
+	fakeClients(t)
+	s := NewService(map[string]config.MCPServer{"fake": {Type: config.MCPStdio}})
+	s.Start(context.Background())
+	defer s.Shutdown()
+
+	resources := s.Resources()
+	require.Len(t, resources, 2)
+	assert.Equal(t, "fake", resources[0].Server)
+	assert.Equal(t, "file:///a.txt", resources[0].URI)
+	assert.Equal(t, "file:///b.txt", resources[1].URI)
+
+	result, err := s.ReadResource(context.Background(), "fake", resources[1].URI)
+	require.NoError(t, err)
+	assert.Equal(t, []mcp.ResourceContents{mcp.TextResourceContents{URI: "file:///b.txt", Text: "contents"}}, result.Contents)
+
+	prompts := s.Prompts()
+	require.Len(t, prompts, 1)
+	assert.Equal(t, "review", prompts[0].Name)
+
+	prompt, err := s.GetPrompt(context.Background(), "fake", "review", map[string]string{"file": "main.go"})
+	require.NoError(t, err)
+	assert.Equal(t, "Review main.go\n\n<resource uri=\"file:///a.txt\">\ncontents\n</resource>", PromptText(prompt))
+}
+
+func TestServiceRefreshesChangedLists(t *testing.T) {
+	clients := fakeClients(t)
+	s := NewService(map[string]config.MCPServer{"fake": {Type: config.MCPStdio}})
+	s.Start(context.Background())
+	defer s.Shutdown()
+
+	events := s.Subscribe(context.Background())
+	clients()[0].tools.Store(2)
+	clients()[0].notification(mcp.MethodNotificationToolsListChanged)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, 3, event.Payload.Tools)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no refresh")
+	}
+	assert.Len(t, s.Tools()["fake"], 3)
+}
+
+func TestServiceToolTimeout(t *testing.T) {
+	fakeClients(t)
+	s := NewService(map[string]config.MCPServer{"fake": {Type: config.MCPStdio, Timeout: 1}})
+	s.Start(context.Background())
+	defer s.Shutdown()
+
+	_, err := s.CallTool(context.Background(), "fake", "slow", nil)
+	assert.EqualError(t, err, "MCP tool slow timed out after 1 seconds")
+	text, err := callText(t, s)
+	require.NoError(t, err)
+	assert.Equal(t, "echo", text)
+}
+
+func TestToolFilters(t *testing.T) {
+	cfg := config.MCPServer{
+		EnabledTools:  []string{"browser_*", "search"},
+		DisabledTools: []string{"browser_install"},
+		TrustedTools:  []string{"browser_snapshot", "search"},
+	}
+	tools := enabledTools(cfg, []mcp.Tool{
+		mcp.NewTool("browser_click"),
+		mcp.NewTool("browser_install"),
+		mcp.NewTool("search"),
+		mcp.NewTool("fetch"),
+	})
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	assert.Equal(t, []string{"browser_click", "search"}, names)
+	assert.True(t, ToolTrusted(cfg, "search"))
+	assert.False(t, ToolTrusted(cfg, "browser_click"))
+
+	assert.True(t, toolEnabled(config.MCPServer{}, "fetch"))
+	assert.False(t, toolEnabled(config.MCPServer{DisabledTools: []string{"*"}}, "fetch"))
+}
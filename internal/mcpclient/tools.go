@@ -0,0 +1,48 @@
+package mcpclient
+
+import (
+	"path"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/logging"
+)
+
+// ToolTrusted tells if a tool of the server is called without asking for
+// permission
+func ToolTrusted(cfg config.MCPServer, tool string) bool {
+	return matchTool(cfg.TrustedTools, tool)
+}
+
+// toolEnabled tells if a tool of the server is offered to the AI assistant
+func toolEnabled(cfg config.MCPServer, tool string) bool {
+	if len(cfg.EnabledTools) > 0 && !matchTool(cfg.EnabledTools, tool) {
+		return false
+	}
+	return !matchTool(cfg.DisabledTools, tool)
+}
+
+// enabledTools filters the tools listed by the server
+func enabledTools(cfg config.MCPServer, tools []mcp.Tool) []mcp.Tool {
+	var enabled []mcp.Tool
+	for _, tool := range tools {
+		if toolEnabled(cfg, tool.Name) {
+			enabled = append(enabled, tool)
+		}
+	}
+	return enabled
+}
+
+func matchTool(patterns []string, tool string) bool {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, tool)
+		if err != nil {
+			logging.Warn("Invalid MCP tool pattern", "pattern", pattern, "error", err)
+			continue
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
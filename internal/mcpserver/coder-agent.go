@@ -0,0 +1,115 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/opencode-ai/opencode/internal/app"
+	"github.com/opencode-ai/opencode/internal/llm/agent"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/message"
+	"github.com/opencode-ai/opencode/internal/permission"
+)
+
+type coderAgentTool struct {
+	app *app.App
+}
+
+const CoderAgentToolName = "coder_agent"
+
+type CoderAgentParams struct {
+	Prompt string `json:"prompt"`
+}
+
+func newCoderAgentTool(app *app.App) tools.BaseTool {
+	return &coderAgentTool{app: app}
+}
+
+func (t *coderAgentTool) Info() tools.ToolInfo {
+	return tools.ToolInfo{
+		Name:        CoderAgentToolName,
+		Description: "Run the OpenCode coder agent on a task in the working directory of the server. The agent can read, search and change files without asking for permission, and returns its final message. Each call starts a new session, so the prompt should describe the task completely.",
+		Parameters: map[string]any{
+			"prompt": map[string]any{
+				"type":        "string",
+				"description": "The task for the agent to perform",
+			},
+		},
+		Required: []string{"prompt"},
+	}
+}
+
+func (t *coderAgentTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	var params CoderAgentParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return tools.NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+	if params.Prompt == "" {
+		return tools.NewTextErrorResponse("prompt is required"), nil
+	}
+
+	sess, err := t.app.Sessions.Create(ctx, "MCP: "+truncate(params.Prompt, 100))
+	if err != nil {
+		return tools.ToolResponse{}, fmt.Errorf("error creating session: %w", err)
+	}
+	done, err := t.app.CoderAgent.Run(ctx, sess.ID, params.Prompt)
+	if err != nil {
+		return tools.ToolResponse{}, fmt.Errorf("error running agent: %w", err)
+	}
+	// The agent stops when the client cancels the call
+	result := <-done
+	if result.Error != nil {
+		if errors.Is(result.Error, context.Canceled) || errors.Is(result.Error, agent.ErrRequestCancelled) {
+			return tools.NewTextErrorResponse("the agent was cancelled"), nil
+		}
+		return tools.ToolResponse{}, fmt.Errorf("error running agent: %w", result.Error)
+	}
+	if result.Message.Role != message.Assistant || result.Message.Content().String() == "" {
+		return tools.NewTextErrorResponse("no response"), nil
+	}
+	return tools.NewTextResponse(result.Message.Content().String()), nil
+}
+
+// agentEditTools are the tools the agent may use when the client approves a
+// call of coder_agent. Shell commands, the network and the tools of MCP
+// servers are only allowed with the full access option.
+var agentEditTools = []string{
+	tools.EditToolName,
+	tools.WriteToolName,
+	tools.PatchToolName,
+	tools.RenameSymbolToolName,
+	tools.CodeActionToolName,
+	tools.GitCommitToolName,
+	tools.GitBranchToolName,
+}
+
+// answerAgentPermissions answers the permission requests of the agent
+// sessions, as no one else can. The session of the served tools is approved
+// beforehand, so the requests all come from the agent.
+func answerAgentPermissions(ctx context.Context, permissions permission.Service, fullAccess bool) {
+	requests := permissions.Subscribe(ctx)
+	go func() {
+		for event := range requests {
+			request := event.Payload
+			if fullAccess || slices.Contains(agentEditTools, request.ToolName) {
+				permissions.Grant(request)
+				continue
+			}
+			logging.Info("Denied a tool of the coder agent", "tool", request.ToolName, "session_id", request.SessionID)
+			permissions.Deny(request)
+		}
+	}()
+}
+
+// truncate cuts s to n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
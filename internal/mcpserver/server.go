@@ -0,0 +1,125 @@
+// Package mcpserver serves the built-in tools of OpenCode over the MCP stdio
+// protocol, so that other agents and editors can use its file editing and
+// LSP integration.
+package mcpserver
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/opencode-ai/opencode/internal/app"
+	"github.com/opencode-ai/opencode/internal/llm/agent"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/version"
+)
+
+type Options struct {
+	// ReadOnly leaves out the tools that change files
+	ReadOnly bool
+	// Agent offers a tool running the coder agent on a task
+	Agent bool
+	// AgentFullAccess lets the coder agent run shell commands, reach the
+	// network and use the tools of MCP servers
+	AgentFullAccess bool
+}
+
+// Serve serves the tools on stdin and stdout until ctx is done or the client
+// closes the input.
+func Serve(ctx context.Context, app *app.App, opts Options) error {
+	if opts.ReadOnly && opts.Agent {
+		return errors.New("the coder agent can't be served in read-only mode")
+	}
+	if opts.AgentFullAccess && !opts.Agent {
+		return errors.New("full access is only given to the coder agent")
+	}
+
+	sess, err := app.Sessions.Create(ctx, "MCP server")
+	if err != nil {
+		return fmt.Errorf("failed to create session for the MCP server: %w", err)
+	}
+	// The client asks its user before calling a tool, so like the
+	// non-interactive mode the calls are approved here
+	app.Permissions.AutoApproveSession(sess.ID)
+
+	serverTools := agent.ServerTools(app.Permissions, app.History, app.LSPClients, opts.ReadOnly)
+	if opts.Agent {
+		answerAgentPermissions(ctx, app.Permissions, opts.AgentFullAccess)
+		serverTools = append(serverTools, newCoderAgentTool(app))
+	}
+
+	logging.Info("Serving tools over MCP", "session_id", sess.ID, "tools", len(serverTools))
+	return server.NewStdioServer(newServer(sess.ID, serverTools)).Listen(ctx, os.Stdin, os.Stdout)
+}
+
+func newServer(sessionID string, baseTools []tools.BaseTool) *server.MCPServer {
+	s := server.NewMCPServer(
+		"opencode",
+		version.Version,
+		server.WithToolCapabilities(false),
+		server.WithRecovery(),
+	)
+	for _, tool := range baseTools {
+		s.AddTool(mcpTool(tool.Info()), toolHandler(sessionID, tool))
+	}
+	return s
+}
+
+func mcpTool(info tools.ToolInfo) mcp.Tool {
+	properties := info.Parameters
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	required := info.Required
+	if required == nil {
+		required = []string{}
+	}
+	schema, _ := json.Marshal(map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	})
+	return mcp.NewToolWithRawSchema(info.Name, info.Description, schema)
+}
+
+func toolHandler(sessionID string, tool tools.BaseTool) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		if args == nil {
+			args = map[string]any{}
+		}
+		input, err := json.Marshal(args)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid arguments: %s", err)), nil
+		}
+
+		// There is no message behind the call, the call id keeps the
+		// changes of each call apart in the file history
+		callID := uuid.NewString()
+		ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+		ctx = context.WithValue(ctx, tools.MessageIDContextKey, callID)
+		response, err := tool.Run(ctx, tools.ToolCall{
+			ID:    callID,
+			Name:  tool.Info().Name,
+			Input: string(input),
+		})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if response.IsError {
+			return mcp.NewToolResultError(response.Content), nil
+		}
+		result := mcp.NewToolResultText(response.Content)
+		for _, image := range response.Images {
+			result.Content = append(result.Content, mcp.NewImageContent(base64.StdEncoding.EncodeToString(image.Data), image.MIMEType))
+		}
+		return result, nil
+	}
+}
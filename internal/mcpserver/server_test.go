@@ -0,0 +1,132 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/opencode-ai/opencode/internal/llm/tools"
+	"github.com/opencode-ai/opencode/internal/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoTool answers with its input and the session it was called in
+type echoTool struct{}
+
+func (echoTool) Info() tools.ToolInfo {
+	return tools.ToolInfo{
+		Name:        "echo",
+		Description: "Echo the text",
+		Parameters: map[string]any{
+			"text": map[string]any{"type": "string"},
+		},
+		Required: []string{"text"},
+	}
+}
+
+func (echoTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	var params struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return tools.ToolResponse{}, err
+	}
+	switch params.Text {
+	case "":
+		return tools.NewTextErrorResponse("text is required"), nil
+	case "fail":
+		return tools.ToolResponse{}, errors.New("failed")
+	case "image":
+		return tools.NewImageResponse("an image", tools.ToolImage{MIMEType: "image/png", Data: []byte("png")}), nil
+	}
+	sessionID, messageID := tools.GetContextValues(ctx)
+	if messageID != call.ID {
+		return tools.ToolResponse{}, errors.New("the message id is not the call id")
+	}
+	return tools.NewTextResponse(fmt.Sprintf("%s: %s", sessionID, params.Text)), nil
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	c, err := client.NewInProcessClient(newServer("session", []tools.BaseTool{echoTool{}}))
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Start(ctx))
+	_, err = c.Initialize(ctx, mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	list, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Tools, 1)
+	assert.Equal(t, "echo", list.Tools[0].Name)
+	schema, err := json.Marshal(list.Tools[0].InputSchema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`, string(schema))
+
+	call := func(text string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "echo"
+		request.Params.Arguments = map[string]any{"text": text}
+		result, err := c.CallTool(ctx, request)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Content)
+		return result
+	}
+
+	result := call("hello")
+	assert.False(t, result.IsError)
+	assert.Equal(t, "session: hello", result.Content[0].(mcp.TextContent).Text)
+
+	result = call("")
+	assert.True(t, result.IsError)
+	assert.Equal(t, "text is required", result.Content[0].(mcp.TextContent).Text)
+
+	result = call("fail")
+	assert.True(t, result.IsError)
+	assert.Equal(t, "failed", result.Content[0].(mcp.TextContent).Text)
+
+	// Images follow the text describing them
+	result = call("image")
+	assert.False(t, result.IsError)
+	require.Len(t, result.Content, 2)
+	assert.Equal(t, "an image", result.Content[0].(mcp.TextContent).Text)
+	image := result.Content[1].(mcp.ImageContent)
+	assert.Equal(t, "image/png", image.MIMEType)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("png")), image.Data)
+}
+
+func TestAnswerAgentPermissions(t *testing.T) {
+	request := func(permissions permission.Service, toolName string) bool {
+		return permissions.Request(permission.CreatePermissionRequest{
+			SessionID: "agent",
+			ToolName:  toolName,
+			Path:      "/project/main.go",
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	permissions := permission.NewPermissionService()
+	answerAgentPermissions(ctx, permissions, false)
+	assert.True(t, request(permissions, tools.EditToolName))
+	assert.True(t, request(permissions, tools.GitCommitToolName))
+	assert.False(t, request(permissions, tools.BashToolName))
+	assert.False(t, request(permissions, tools.FetchToolName))
+	assert.False(t, request(permissions, "github_create_issue"))
+
+	permissions = permission.NewPermissionService()
+	answerAgentPermissions(ctx, permissions, true)
+	assert.True(t, request(permissions, tools.BashToolName))
+	assert.True(t, request(permissions, tools.FetchToolName))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "ééé...", truncate("éééé", 3))
+}
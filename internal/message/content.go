@@ -2,7 +2,9 @@ package message
 
 import (
 	"encoding/base64"
+	"fmt"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/opencode-ai/opencode/internal/llm/models"
@@ -69,6 +71,9 @@ type BinaryContent struct {
 	Path     string
 	MIMEType string
 	Data     []byte
+	// Text is the text extracted from a document, for the providers that
+	// can't read it
+	Text string `json:",omitempty"`
 }
 
 func (bc BinaryContent) String(provider models.ModelProvider) string {
@@ -79,8 +84,38 @@ func (bc BinaryContent) String(provider models.ModelProvider) string {
 	return base64Encoded
 }
 
+// IsText reports whether the content is text, which is inlined in the prompt
+// rather than sent as an image
+func (bc BinaryContent) IsText() bool {
+	return IsTextMIMEType(bc.MIMEType)
+}
+
+// IsPDF reports whether the content is a PDF document, which is sent as a
+// document to the providers that read them and as text to the others
+func (bc BinaryContent) IsPDF() bool {
+	return IsPDFMIMEType(bc.MIMEType)
+}
+
 func (BinaryContent) isPart() {}
 
+// IsTextMIMEType reports whether a MIME type is the type of a text document
+func IsTextMIMEType(mimeType string) bool {
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml",
+		"application/toml", "application/javascript", "application/sql", "application/x-sh":
+		return true
+	}
+	return false
+}
+
 type ToolCall struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
@@ -97,6 +132,8 @@ type ToolResult struct {
 	Content    string `json:"content"`
 	Metadata   string `json:"metadata"`
 	IsError    bool   `json:"is_error"`
+	// Images are the images of the tool response
+	Images []BinaryContent `json:"images,omitempty"`
 }
 
 func (ToolResult) isPart() {}
@@ -156,6 +193,56 @@ func (m *Message) BinaryContent() []BinaryContent {
 	return binaryContents
 }
 
+// ImageContent returns the attachments sent to the providers as images
+func (m *Message) ImageContent() []BinaryContent {
+	images := make([]BinaryContent, 0)
+	for _, c := range m.BinaryContent() {
+		if !c.IsText() && !c.IsPDF() {
+			images = append(images, c)
+		}
+	}
+	return images
+}
+
+// DocumentContent returns the PDF attachments, for the providers that read
+// them natively
+func (m *Message) DocumentContent() []BinaryContent {
+	documents := make([]BinaryContent, 0)
+	for _, c := range m.BinaryContent() {
+		if c.IsPDF() {
+			documents = append(documents, c)
+		}
+	}
+	return documents
+}
+
+// PromptText returns the text of the message followed by its text
+// attachments, each under a header naming it
+func (m *Message) PromptText() string {
+	var sb strings.Builder
+	sb.WriteString(m.Content().String())
+	for _, c := range m.BinaryContent() {
+		if c.IsText() {
+			fmt.Fprintf(&sb, "\n\n<attachment name=%q>\n%s\n</attachment>", c.Path, c.Data)
+		}
+	}
+	return sb.String()
+}
+
+// DocumentText returns the text extracted from the PDF attachments, each under
+// a header naming it, for the providers that can't read them
+func (m *Message) DocumentText() string {
+	var sb strings.Builder
+	for _, c := range m.DocumentContent() {
+		text := c.Text
+		if text == "" {
+			text = "(no text could be extracted from this document)"
+		}
+		fmt.Fprintf(&sb, "\n\n<attachment name=%q>\n%s\n</attachment>", c.Path, text)
+	}
+	return sb.String()
+}
+
 func (m *Message) ToolCalls() []ToolCall {
 	toolCalls := make([]ToolCall, 0)
 	for _, part := range m.Parts {
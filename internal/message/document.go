@@ -0,0 +1,50 @@
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDFMIMEType is the MIME type of the PDF documents, which some providers
+// read natively
+const PDFMIMEType = "application/pdf"
+
+// IsPDFMIMEType reports whether a MIME type is the type of a PDF document
+func IsPDFMIMEType(mimeType string) bool {
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), PDFMIMEType)
+}
+
+// ExtractPDFText returns the text of the pages of a PDF document, for the
+// providers that can't read PDFs
+func ExtractPDFText(data []byte) (text string, err error) {
+	// The parser panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid PDF document: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	var pages []string
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		pageText, err := page.GetPlainText(nil)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %w", i, err)
+		}
+		if pageText = strings.TrimSpace(pageText); pageText != "" {
+			pages = append(pages, pageText)
+		}
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
@@ -0,0 +1,71 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPDF builds a PDF document with one page per text
+func testPDF(texts ...string) []byte {
+	var objects []string
+	kids := make([]string, len(texts))
+	for i := range texts {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(texts)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)
+	for i, text := range texts {
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = sb.Len()
+		fmt.Fprintf(&sb, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := sb.Len()
+	fmt.Fprintf(&sb, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&sb, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&sb, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return []byte(sb.String())
+}
+
+func TestExtractPDFText(t *testing.T) {
+	text, err := ExtractPDFText(testPDF("Design spec", "Second page"))
+	require.NoError(t, err)
+	assert.Equal(t, "Design spec\n\nSecond page", text)
+
+	_, err = ExtractPDFText([]byte("not a pdf"))
+	assert.Error(t, err)
+}
+
+func TestMessageDocuments(t *testing.T) {
+	msg := Message{Parts: []ContentPart{
+		TextContent{Text: "Review the spec"},
+		BinaryContent{Path: "spec.pdf", MIMEType: PDFMIMEType, Data: []byte("%PDF"), Text: "Design spec"},
+		BinaryContent{Path: "main.go", MIMEType: "text/plain; charset=utf-8", Data: []byte("package main")},
+		BinaryContent{Path: "ui.png", MIMEType: "image/png", Data: []byte("png")},
+	}}
+
+	assert.Len(t, msg.ImageContent(), 1)
+	assert.Equal(t, "ui.png", msg.ImageContent()[0].Path)
+	assert.Len(t, msg.DocumentContent(), 1)
+	assert.Equal(t, "spec.pdf", msg.DocumentContent()[0].Path)
+	assert.Equal(t, "Review the spec\n\n<attachment name=\"main.go\">\npackage main\n</attachment>", msg.PromptText())
+	assert.Equal(t, "\n\n<attachment name=\"spec.pdf\">\nDesign spec\n</attachment>", msg.DocumentText())
+}
@@ -0,0 +1,395 @@
+// Package repomap builds a map of a repository: the top-level symbols of its
+// source files, ranked by how much the rest of the repository refers to them,
+// and summarized within a token budget.
+package repomap
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/opencode-ai/opencode/internal/fileutil"
+)
+
+const (
+	// DefaultMaxTokens is the default budget of a map
+	DefaultMaxTokens = 2048
+	// maxFiles is the number of source files read to build a map
+	maxFiles = 3000
+	// maxFileSize is the size of the largest source file read
+	maxFileSize = 512 * 1024
+	// maxLineLength is the length of the longest declaration line shown
+	maxLineLength = 120
+
+	pageRankDamping    = 0.85
+	pageRankIterations = 30
+)
+
+var (
+	identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	generatedPattern  = regexp.MustCompile(`(?m)^\W*(?:Code generated .* DO NOT EDIT|@generated\b)`)
+)
+
+// Symbol is a top-level declaration of a file
+type Symbol struct {
+	Name string
+	Kind string
+	// Line is the 1-based line of the declaration
+	Line int
+}
+
+// SymbolSource returns the top-level symbols of a file, or false when it can't
+// and the built-in extractors should be used instead
+type SymbolSource func(ctx context.Context, path string, content []byte) ([]Symbol, bool)
+
+// Options configure the generation of a map
+type Options struct {
+	// Root is the root of the repository, paths are shown relative to it
+	Root string
+	// Dir restricts the map to the files under it, the whole repository when empty
+	Dir string
+	// MaxTokens is the budget of the map, DefaultMaxTokens when zero
+	MaxTokens int
+	// Source extracts the symbols of files before the built-in extractors
+	Source SymbolSource
+}
+
+// sourceFile is a file of the map with its symbols and the identifiers it uses
+type sourceFile struct {
+	path        string
+	lines       []string
+	symbols     []Symbol
+	identifiers map[string]int
+	rank        float64
+}
+
+// rankedSymbol is a symbol with the score used to select it
+type rankedSymbol struct {
+	file   int
+	symbol Symbol
+	score  float64
+}
+
+// Generate builds the map of the repository. Files ignored by .gitignore and
+// .ignore files, hidden files and common dependency directories are skipped.
+func Generate(ctx context.Context, opts Options) (string, error) {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultMaxTokens
+	}
+	dir := opts.Root
+	if opts.Dir != "" {
+		dir = opts.Dir
+	}
+
+	paths, truncated, err := listSourceFiles(opts.Root, dir)
+	if err != nil {
+		return "", err
+	}
+
+	files := make([]*sourceFile, 0, len(paths))
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if file := readSourceFile(ctx, opts, path); file != nil {
+			files = append(files, file)
+		}
+	}
+
+	ranked := rankSymbols(files)
+	output := render(opts.Root, files, ranked, opts.MaxTokens)
+	if truncated && output != "" {
+		output += fmt.Sprintf("\n(only the first %d source files were mapped)\n", maxFiles)
+	}
+	return output, nil
+}
+
+// listSourceFiles returns the source files under dir, sorted
+func listSourceFiles(root, dir string) ([]string, bool, error) {
+	ignorer := fileutil.NewIgnorer(root, nil)
+	var paths []string
+	truncated := false
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			rel = path
+		}
+		if path != dir && (fileutil.SkipHidden(rel) || ignorer.IsIgnored(path, d.IsDir())) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !sourceExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		if len(paths) >= maxFiles {
+			truncated = true
+			return filepath.SkipAll
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	slices.Sort(paths)
+	return paths, truncated, nil
+}
+
+// readSourceFile reads a file and extracts its symbols and identifiers
+func readSourceFile(ctx context.Context, opts Options, path string) *sourceFile {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxFileSize {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || isGenerated(content) {
+		return nil
+	}
+
+	var symbols []Symbol
+	ok := false
+	if opts.Source != nil {
+		symbols, ok = opts.Source(ctx, path, content)
+	}
+	if !ok {
+		symbols = extractSymbols(path, content)
+	}
+
+	identifiers := make(map[string]int)
+	for _, identifier := range identifierPattern.FindAll(content, -1) {
+		identifiers[string(identifier)]++
+	}
+	return &sourceFile{
+		path:        path,
+		lines:       strings.Split(string(content), "\n"),
+		symbols:     symbols,
+		identifiers: identifiers,
+	}
+}
+
+// isGenerated reports whether a file is generated, from the marker comment at
+// its top
+func isGenerated(content []byte) bool {
+	header := content[:min(len(content), 1024)]
+	return generatedPattern.Match(header)
+}
+
+// rankSymbols ranks the files with PageRank on the graph of the references
+// between them, and scores each symbol by the ranks of the files referring to
+// it. A file refers to a symbol of another file when it uses its name.
+func rankSymbols(files []*sourceFile) []rankedSymbol {
+	definedIn := make(map[string][]int)
+	for i, file := range files {
+		for _, symbol := range file.symbols {
+			if !slices.Contains(definedIn[symbol.Name], i) {
+				definedIn[symbol.Name] = append(definedIn[symbol.Name], i)
+			}
+		}
+	}
+
+	// weight returns the weight of the references of a file to a name
+	// declared in another file
+	weight := func(count int, name string) float64 {
+		return nameWeight(name, len(definedIn[name])) * math.Sqrt(float64(count))
+	}
+
+	edges := make([]map[int]float64, len(files))
+	outWeights := make([]float64, len(files))
+	for i, file := range files {
+		edges[i] = make(map[int]float64)
+		for name, count := range file.identifiers {
+			for _, d := range definedIn[name] {
+				if d != i {
+					w := weight(count, name)
+					edges[i][d] += w
+					outWeights[i] += w
+				}
+			}
+		}
+	}
+
+	n := float64(len(files))
+	ranks := make([]float64, len(files))
+	for i := range ranks {
+		ranks[i] = 1 / n
+	}
+	for range pageRankIterations {
+		next := make([]float64, len(files))
+		dangling := 0.0
+		for i := range files {
+			if outWeights[i] == 0 {
+				dangling += ranks[i]
+				continue
+			}
+			for d, w := range edges[i] {
+				next[d] += pageRankDamping * ranks[i] * w / outWeights[i]
+			}
+		}
+		for i := range next {
+			next[i] += (1-pageRankDamping)/n + pageRankDamping*dangling/n
+		}
+		ranks = next
+	}
+
+	// A symbol gets the share of rank its references pass to its file
+	scores := make([]map[string]float64, len(files))
+	for i := range files {
+		scores[i] = make(map[string]float64)
+	}
+	for i, file := range files {
+		if outWeights[i] == 0 {
+			continue
+		}
+		for name, count := range file.identifiers {
+			for _, d := range definedIn[name] {
+				if d != i {
+					scores[d][name] += ranks[i] * weight(count, name) / outWeights[i]
+				}
+			}
+		}
+	}
+
+	var ranked []rankedSymbol
+	for d, file := range files {
+		file.rank = ranks[d]
+		for _, symbol := range file.symbols {
+			ranked = append(ranked, rankedSymbol{file: d, symbol: symbol, score: scores[d][symbol.Name]})
+		}
+	}
+	slices.SortStableFunc(ranked, func(a, b rankedSymbol) int {
+		switch {
+		case a.score != b.score:
+			return compareFloat(b.score, a.score)
+		case files[a.file].rank != files[b.file].rank:
+			return compareFloat(files[b.file].rank, files[a.file].rank)
+		case a.file != b.file:
+			return a.file - b.file
+		}
+		return a.symbol.Line - b.symbol.Line
+	})
+	return ranked
+}
+
+// nameWeight is the importance of the references to a name. Without type
+// information, a reference to a short or common name is likely to be a
+// reference to something else with the same name, while long distinctive
+// names are likely to be the symbol itself.
+func nameWeight(name string, definitions int) float64 {
+	weight := 1.0
+	if len(name) >= 8 && (strings.Contains(name, "_") || isCamelCase(name)) {
+		weight *= 10
+	}
+	if len(name) < 4 || strings.HasPrefix(name, "_") {
+		weight *= 0.1
+	}
+	if definitions > 5 {
+		weight *= 0.1
+	}
+	return weight
+}
+
+func isCamelCase(name string) bool {
+	hasLower, hasInnerUpper := false, false
+	for i, r := range name {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r) && i > 0:
+			hasInnerUpper = true
+		}
+	}
+	return hasLower && hasInnerUpper
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// render selects the best ranked symbols within the budget and lists them by
+// file, the files by rank and their symbols in source order
+func render(root string, files []*sourceFile, ranked []rankedSymbol, maxTokens int) string {
+	selected := make(map[int][]Symbol)
+	tokens := 0
+	for _, r := range ranked {
+		cost := estimateTokens(declarationLine(files[r.file], r.symbol))
+		if _, ok := selected[r.file]; !ok {
+			cost += estimateTokens(relativePath(root, files[r.file].path))
+		}
+		if tokens+cost > maxTokens {
+			break
+		}
+		tokens += cost
+		selected[r.file] = append(selected[r.file], r.symbol)
+	}
+
+	order := make([]int, 0, len(selected))
+	for i := range selected {
+		order = append(order, i)
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		if files[a].rank != files[b].rank {
+			return compareFloat(files[b].rank, files[a].rank)
+		}
+		return strings.Compare(files[a].path, files[b].path)
+	})
+
+	var sb strings.Builder
+	for _, i := range order {
+		symbols := selected[i]
+		slices.SortFunc(symbols, func(a, b Symbol) int { return a.Line - b.Line })
+		fmt.Fprintf(&sb, "%s:\n", relativePath(root, files[i].path))
+		for _, symbol := range symbols {
+			fmt.Fprintf(&sb, "  %s\n", declarationLine(files[i], symbol))
+		}
+	}
+	return sb.String()
+}
+
+// declarationLine returns the source line declaring a symbol, shortened
+func declarationLine(file *sourceFile, symbol Symbol) string {
+	line := ""
+	if symbol.Line > 0 && symbol.Line <= len(file.lines) {
+		line = strings.TrimSpace(file.lines[symbol.Line-1])
+		line = strings.TrimSpace(strings.TrimSuffix(line, "{"))
+	}
+	if !strings.Contains(line, symbol.Name) {
+		line = strings.TrimSpace(symbol.Kind + " " + symbol.Name)
+	}
+	if runes := []rune(line); len(runes) > maxLineLength {
+		line = string(runes[:maxLineLength]) + "..."
+	}
+	return line
+}
+
+// estimateTokens approximates the number of tokens of a line of the map
+func estimateTokens(line string) int {
+	return (len(line)+3)/4 + 1
+}
+
+func relativePath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,159 @@
+package repomap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSymbols(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    []Symbol
+	}{
+		{
+			name: "go",
+			path: "main.go",
+			content: `package main
+
+type Server struct{}
+
+type Handler interface{}
+
+func (s *Server) Serve() {}
+
+func main() {
+	var local int
+	_ = local
+}
+
+const (
+	Version = "1"
+	_       = 0
+)
+`,
+			want: []Symbol{
+				{Name: "Server", Kind: "struct", Line: 3},
+				{Name: "Handler", Kind: "interface", Line: 5},
+				{Name: "Serve", Kind: "method", Line: 7},
+				{Name: "main", Kind: "func", Line: 9},
+				{Name: "Version", Kind: "const", Line: 15},
+			},
+		},
+		{
+			name: "python",
+			path: "app.py",
+			content: `class Server:
+    def serve(self):
+        pass
+
+async def main():
+    pass
+`,
+			want: []Symbol{
+				{Name: "Server", Kind: "class", Line: 1},
+				{Name: "main", Kind: "func", Line: 5},
+			},
+		},
+		{
+			name: "typescript",
+			path: "app.ts",
+			content: `export default class Server {}
+export interface Options {}
+const handler = () => {}
+  function nested() {}
+`,
+			want: []Symbol{
+				{Name: "Server", Kind: "class", Line: 1},
+				{Name: "Options", Kind: "interface", Line: 2},
+				{Name: "handler", Kind: "const", Line: 3},
+			},
+		},
+		{
+			name:    "unknown",
+			path:    "notes.txt",
+			content: "class Server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, extractSymbols(tt.path, []byte(tt.content)))
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore": "build/\n",
+		"store.go": `package app
+
+type DocumentStore struct{}
+
+func NewDocumentStore() *DocumentStore { return nil }
+`,
+		"handler.go": `package app
+
+func handleRequest() {
+	store := NewDocumentStore()
+	_ = store
+}
+`,
+		"server.go": `package app
+
+func startServer() {
+	var s *DocumentStore = NewDocumentStore()
+	_ = s
+	handleRequest()
+}
+`,
+		"generated.go": `// Code generated by a tool. DO NOT EDIT.
+
+package app
+
+func GeneratedHelper() { NewDocumentStore() }
+`,
+		"build/output.go": "package build\n\nfunc BuildOutput() {}\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0o644))
+	}
+
+	output, err := Generate(context.Background(), Options{Root: root})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(output, "store.go:\n  type DocumentStore struct{}\n  func NewDocumentStore() *DocumentStore"), output)
+	assert.Contains(t, output, "handler.go:\n  func handleRequest()\n")
+	assert.NotContains(t, output, "GeneratedHelper")
+	assert.NotContains(t, output, "BuildOutput")
+
+	// The budget keeps the best ranked symbols only
+	output, err = Generate(context.Background(), Options{Root: root, MaxTokens: 20})
+	require.NoError(t, err)
+	assert.Equal(t, "store.go:\n  func NewDocumentStore() *DocumentStore { return nil }\n", output)
+
+	// A source replaces the built-in extractors
+	output, err = Generate(context.Background(), Options{
+		Root: root,
+		Dir:  filepath.Join(root, "store.go"),
+		Source: func(ctx context.Context, path string, content []byte) ([]Symbol, bool) {
+			return []Symbol{{Name: "Custom", Kind: "class", Line: 1}}, true
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "store.go:\n  class Custom\n", output)
+}
@@ -0,0 +1,184 @@
+package repomap
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// declarationPattern matches a top-level declaration line, with "kind" and
+// "name" submatches
+type declarationPattern struct {
+	re *regexp.Regexp
+	// kinds maps the keyword of the declaration to the kind of the symbol
+	kinds map[string]string
+}
+
+var (
+	cStyleKinds = map[string]string{
+		"class":     "class",
+		"interface": "interface",
+		"enum":      "enum",
+		"record":    "class",
+		"object":    "class",
+		"trait":     "interface",
+		"struct":    "struct",
+		"protocol":  "interface",
+		"fun":       "func",
+		"func":      "func",
+		"function":  "func",
+		"def":       "func",
+		"fn":        "func",
+		"type":      "type",
+		"const":     "const",
+		"let":       "var",
+		"var":       "var",
+		"static":    "var",
+		"module":    "module",
+		"mod":       "module",
+		"namespace": "namespace",
+	}
+
+	pythonPattern = declarationPattern{
+		re:    regexp.MustCompile(`^(?:async\s+)?(?P<kind>def|class)\s+(?P<name>[A-Za-z_]\w*)`),
+		kinds: cStyleKinds,
+	}
+	javascriptPattern = declarationPattern{
+		re:    regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(?P<kind>function\*?|class|interface|type|enum|const|let|var|namespace)\s+(?P<name>[A-Za-z_$][\w$]*)`),
+		kinds: cStyleKinds,
+	}
+	rustPattern = declarationPattern{
+		re:    regexp.MustCompile(`^(?:pub(?:\([\w\s:]+\))?\s+)?(?:async\s+)?(?:unsafe\s+)?(?:const\s+)?(?P<kind>fn|struct|enum|trait|type|const|static|mod)\s+(?P<name>[A-Za-z_]\w*)`),
+		kinds: cStyleKinds,
+	}
+	jvmPattern = declarationPattern{
+		re:    regexp.MustCompile(`^(?:(?:public|private|protected|internal|abstract|final|sealed|static|data|open|partial)\s+)*(?P<kind>class|interface|enum|record|object|trait|fun|def|struct|namespace)\s+(?P<name>[A-Za-z_]\w*)`),
+		kinds: cStyleKinds,
+	}
+	rubyPattern = declarationPattern{
+		re:    regexp.MustCompile(`^(?P<kind>class|module|def)\s+(?:self\.)?(?P<name>[A-Za-z_]\w*[?!]?)`),
+		kinds: cStyleKinds,
+	}
+	phpPattern = declarationPattern{
+		re:    regexp.MustCompile(`^(?:(?:abstract|final)\s+)*(?P<kind>function|class|interface|trait|enum)\s+(?P<name>[A-Za-z_]\w*)`),
+		kinds: cStyleKinds,
+	}
+	swiftPattern = declarationPattern{
+		re:    regexp.MustCompile(`^(?:(?:public|private|internal|open|fileprivate|final)\s+)*(?P<kind>func|class|struct|enum|protocol)\s+(?P<name>[A-Za-z_]\w*)`),
+		kinds: cStyleKinds,
+	}
+
+	// declarationPatterns are the patterns of the languages without a parser,
+	// by file extension
+	declarationPatterns = map[string]declarationPattern{
+		".py":    pythonPattern,
+		".js":    javascriptPattern,
+		".jsx":   javascriptPattern,
+		".mjs":   javascriptPattern,
+		".cjs":   javascriptPattern,
+		".ts":    javascriptPattern,
+		".tsx":   javascriptPattern,
+		".mts":   javascriptPattern,
+		".rs":    rustPattern,
+		".java":  jvmPattern,
+		".kt":    jvmPattern,
+		".scala": jvmPattern,
+		".cs":    jvmPattern,
+		".rb":    rubyPattern,
+		".php":   phpPattern,
+		".swift": swiftPattern,
+	}
+
+	// sourceExtensions are the extensions of the files mapped, the ones
+	// without a built-in extractor only have symbols from a SymbolSource
+	sourceExtensions = map[string]bool{
+		".go": true, ".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true,
+		".zig": true, ".lua": true, ".ex": true, ".exs": true, ".dart": true,
+	}
+)
+
+func init() {
+	for ext := range declarationPatterns {
+		sourceExtensions[ext] = true
+	}
+}
+
+// extractSymbols returns the top-level symbols of a file with the built-in
+// extractors: the Go parser for Go files, declaration patterns otherwise
+func extractSymbols(path string, content []byte) []Symbol {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		return goSymbols(path, content)
+	}
+	pattern, ok := declarationPatterns[ext]
+	if !ok {
+		return nil
+	}
+
+	var symbols []Symbol
+	for i, line := range strings.Split(string(content), "\n") {
+		// Only declarations at the start of a line are top-level
+		match := pattern.re.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		keyword := strings.TrimSuffix(match[pattern.re.SubexpIndex("kind")], "*")
+		symbols = append(symbols, Symbol{
+			Name: match[pattern.re.SubexpIndex("name")],
+			Kind: pattern.kinds[keyword],
+			Line: i + 1,
+		})
+	}
+	return symbols
+}
+
+// goSymbols returns the top-level declarations of a Go file
+func goSymbols(path string, content []byte) []Symbol {
+	fset := token.NewFileSet()
+	// A partial syntax tree is returned for files with syntax errors
+	file, _ := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+	if file == nil {
+		return nil
+	}
+
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			kind := "func"
+			if d.Recv != nil {
+				kind = "method"
+			}
+			symbols = append(symbols, Symbol{Name: d.Name.Name, Kind: kind, Line: fset.Position(d.Pos()).Line})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					kind := "type"
+					switch s.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+					case *ast.InterfaceType:
+						kind = "interface"
+					}
+					symbols = append(symbols, Symbol{Name: s.Name.Name, Kind: kind, Line: fset.Position(s.Pos()).Line})
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, name := range s.Names {
+						if name.Name == "_" {
+							continue
+						}
+						symbols = append(symbols, Symbol{Name: name.Name, Kind: kind, Line: fset.Position(name.Pos()).Line})
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
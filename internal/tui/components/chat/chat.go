@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/opencode-ai/opencode/internal/config"
+	"github.com/opencode-ai/opencode/internal/mcpclient"
 	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/session"
 	"github.com/opencode-ai/opencode/internal/tui/styles"
@@ -97,6 +98,60 @@ func lspsConfigured(width int) string {
 		)
 }
 
+// mcpServersSection lists the MCP servers with the state of their connection
+func mcpServersSection(width int, servers []mcpclient.Server) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+
+	title := baseStyle.
+		Width(width).
+		Foreground(t.Primary()).
+		Bold(true).
+		Render(ansi.Truncate("MCP Servers", width, "…"))
+
+	var serverViews []string
+	for _, server := range servers {
+		stateColor := t.TextMuted()
+		state := string(server.State)
+		switch server.State {
+		case mcpclient.StateConnected:
+			stateColor = t.Success()
+			state = fmt.Sprintf("%d tools", server.Tools)
+		case mcpclient.StateReconnecting:
+			stateColor = t.Warning()
+		case mcpclient.StateError:
+			stateColor = t.Error()
+		}
+
+		name := baseStyle.
+			Foreground(t.Text()).
+			Render(fmt.Sprintf("• %s", server.Name))
+		state = ansi.Truncate(state, width-lipgloss.Width(name)-3, "…")
+		stateStr := baseStyle.
+			Foreground(stateColor).
+			Render(fmt.Sprintf(" (%s)", state))
+
+		serverViews = append(serverViews,
+			baseStyle.
+				Width(width).
+				Render(lipgloss.JoinHorizontal(lipgloss.Left, name, stateStr)),
+		)
+	}
+
+	return baseStyle.
+		Width(width).
+		Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				title,
+				lipgloss.JoinVertical(
+					lipgloss.Left,
+					serverViews...,
+				),
+			),
+		)
+}
+
 func logo(width int) string {
 	logo := fmt.Sprintf("%s %s", styles.OpenCodeIcon, "OpenCode")
 	t := theme.CurrentTheme()
@@ -0,0 +1,108 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opencode-ai/opencode/internal/message"
+)
+
+// clipboardCommand reads the clipboard of a display server. Terminals only
+// share text over OSC 52, so images are read with the clipboard tools.
+type clipboardCommand struct {
+	// env is the variable set when the display server is running, if any
+	env string
+	// list prints the MIME types of the clipboard content, one per line
+	list []string
+	// read prints the clipboard content of the MIME type appended to it
+	read []string
+}
+
+// clipboardCommands are tried in order. They are replaced in tests.
+var clipboardCommands = []clipboardCommand{
+	{
+		env:  "WAYLAND_DISPLAY",
+		list: []string{"wl-paste", "--list-types"},
+		read: []string{"wl-paste", "--no-newline", "--type"},
+	},
+	{
+		env:  "DISPLAY",
+		list: []string{"xclip", "-selection", "clipboard", "-t", "TARGETS", "-o"},
+		read: []string{"xclip", "-selection", "clipboard", "-o", "-t"},
+	},
+}
+
+// clipboardImageTypes are the image types read from the clipboard, by
+// preference
+var clipboardImageTypes = []string{"image/png", "image/jpeg", "image/webp", "image/gif"}
+
+const (
+	// clipboardTimeout bounds the clipboard commands
+	clipboardTimeout = 5 * time.Second
+	// maxClipboardImageSize is the size limit of the attachments
+	maxClipboardImageSize = 5 * 1024 * 1024
+)
+
+var errNoClipboardImage = errors.New("no image in the clipboard")
+
+// readClipboardImage returns the image in the clipboard, or
+// errNoClipboardImage when there is none or no clipboard tool is available
+func readClipboardImage(ctx context.Context) (string, []byte, error) {
+	for _, c := range clipboardCommands {
+		if c.env != "" && os.Getenv(c.env) == "" {
+			continue
+		}
+		if _, err := exec.LookPath(c.list[0]); err != nil {
+			continue
+		}
+		// Listing the types of an empty clipboard fails
+		out, err := exec.CommandContext(ctx, c.list[0], c.list[1:]...).Output()
+		if err != nil {
+			continue
+		}
+		types := strings.Fields(string(out))
+		for _, mimeType := range clipboardImageTypes {
+			if !slices.Contains(types, mimeType) {
+				continue
+			}
+			args := append(slices.Clone(c.read[1:]), mimeType)
+			data, err := exec.CommandContext(ctx, c.read[0], args...).Output()
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to read the clipboard: %w", err)
+			}
+			return mimeType, data, nil
+		}
+	}
+	return "", nil, errNoClipboardImage
+}
+
+// clipboardAttachment reads the image in the clipboard. The attachment is
+// only kept in memory, its path is a name for it.
+func clipboardAttachment(ctx context.Context) (message.Attachment, error) {
+	mimeType, data, err := readClipboardImage(ctx)
+	if err != nil {
+		return message.Attachment{}, err
+	}
+	if len(data) > maxClipboardImageSize {
+		return message.Attachment{}, errors.New("image too large, max 5MB")
+	}
+	if detected := http.DetectContentType(data); detected != mimeType {
+		return message.Attachment{}, fmt.Errorf("the clipboard content is not a valid %s image", mimeType)
+	}
+
+	// Pastes are told apart by their time, for the preview
+	name := fmt.Sprintf("clipboard-%s.%s", time.Now().Format("150405.000"), strings.TrimPrefix(mimeType, "image/"))
+	return message.Attachment{
+		FilePath: name,
+		FileName: name,
+		MimeType: mimeType,
+		Content:  data,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubClipboard replaces the clipboard tools with a script listing the types
+// and printing the file of the type requested
+func stubClipboard(t *testing.T, types string, files map[string][]byte) {
+	t.Helper()
+	dir := t.TempDir()
+	for mimeType, data := range files {
+		name := filepath.Join(dir, filepath.Base(mimeType))
+		require.NoError(t, os.WriteFile(name, data, 0o644))
+	}
+	script := filepath.Join(dir, "clipboard")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+if [ "$1" = list ]; then
+	printf '`+types+`'
+	exit 0
+fi
+cat "`+dir+`/$(basename "$2")"
+`), 0o755))
+
+	saved := clipboardCommands
+	t.Cleanup(func() { clipboardCommands = saved })
+	clipboardCommands = []clipboardCommand{{
+		list: []string{script, "list"},
+		read: []string{script, "read"},
+	}}
+}
+
+func TestClipboardAttachment(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 2))))
+	data := buf.Bytes()
+	ctx := context.Background()
+
+	stubClipboard(t, `text/plain\nimage/png\n`, map[string][]byte{"image/png": data})
+	attachment, err := clipboardAttachment(ctx)
+	require.NoError(t, err)
+	assert.Regexp(t, `^clipboard-[0-9.]+\.png$`, attachment.FileName)
+	assert.Equal(t, "image/png", attachment.MimeType)
+	assert.Equal(t, data, attachment.Content)
+	// The image is only kept in memory
+	assert.NoFileExists(t, attachment.FilePath)
+
+	// Text is pasted by the editor
+	stubClipboard(t, `text/plain\n`, nil)
+	_, err = clipboardAttachment(ctx)
+	assert.ErrorIs(t, err, errNoClipboardImage)
+
+	stubClipboard(t, `image/jpeg\n`, map[string][]byte{"image/jpeg": data})
+	_, err = clipboardAttachment(ctx)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errNoClipboardImage)
+}
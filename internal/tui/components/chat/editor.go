@@ -1,22 +1,35 @@
 package chat
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	stdimage "image"
 	"os"
 	"os/exec"
+	"path"
 	"slices"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/opencode-ai/opencode/internal/app"
+	"github.com/opencode-ai/opencode/internal/completions"
+	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/mcpclient"
 	"github.com/opencode-ai/opencode/internal/message"
 	"github.com/opencode-ai/opencode/internal/session"
 	"github.com/opencode-ai/opencode/internal/tui/components/dialog"
+	"github.com/opencode-ai/opencode/internal/tui/image"
 	"github.com/opencode-ai/opencode/internal/tui/layout"
 	"github.com/opencode-ai/opencode/internal/tui/styles"
 	"github.com/opencode-ai/opencode/internal/tui/theme"
@@ -31,11 +44,19 @@ type editorCmp struct {
 	textarea    textarea.Model
 	attachments []message.Attachment
 	deleteMode  bool
+	// preview is a thumbnail of the last image attached
+	preview attachmentPreview
+}
+
+type attachmentPreview struct {
+	path string
+	view string
 }
 
 type EditorKeyMaps struct {
 	Send       key.Binding
 	OpenEditor key.Binding
+	PasteImage key.Binding
 }
 
 type bluredEditorKeyMaps struct {
@@ -58,6 +79,10 @@ var editorMaps = EditorKeyMaps{
 		key.WithKeys("ctrl+e"),
 		key.WithHelp("ctrl+e", "open editor"),
 	),
+	PasteImage: key.NewBinding(
+		key.WithKeys("ctrl+v"),
+		key.WithHelp("ctrl+v", "paste image or text"),
+	),
 }
 
 var DeleteKeyMaps = DeleteAttachmentKeyMaps{
@@ -77,6 +102,8 @@ var DeleteKeyMaps = DeleteAttachmentKeyMaps{
 
 const (
 	maxAttachments = 5
+	// mcpResourceTimeout bounds the reading of an attached MCP resource
+	mcpResourceTimeout = 30 * time.Second
 )
 
 func (m *editorCmp) openEditor() tea.Cmd {
@@ -115,6 +142,105 @@ func (m *editorCmp) openEditor() tea.Cmd {
 	})
 }
 
+// pasteTextMsg falls back to pasting text when the clipboard has no image
+type pasteTextMsg struct{}
+
+// pasteImage attaches the image in the clipboard. Text is pasted when the
+// model doesn't support images.
+func (m *editorCmp) pasteImage() tea.Cmd {
+	if !dialog.GetSelectedModel(config.Get()).SupportsAttachments {
+		return func() tea.Msg { return pasteTextMsg{} }
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+		defer cancel()
+		attachment, err := clipboardAttachment(ctx)
+		if errors.Is(err, errNoClipboardImage) {
+			return pasteTextMsg{}
+		}
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		return dialog.AttachmentAddedMsg{Attachment: attachment}
+	}
+}
+
+// attachMCPResource reads an MCP resource completed in the editor and
+// attaches its contents to the message
+func (m *editorCmp) attachMCPResource(value string) tea.Cmd {
+	var resource mcpclient.Resource
+	found := false
+	for _, r := range m.app.MCPClients.Resources() {
+		if completions.MCPResourceValue(r) == value {
+			resource, found = r, true
+			break
+		}
+	}
+	if !found {
+		return util.ReportError(fmt.Errorf("MCP resource not found: %s", value))
+	}
+
+	mcpClients := m.app.MCPClients
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), mcpResourceTimeout)
+		defer cancel()
+		result, err := mcpClients.ReadResource(ctx, resource.Server, resource.URI)
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: fmt.Sprintf("failed to read %s: %s", resource.URI, err)}
+		}
+		attachments, err := mcpResourceAttachments(resource, result)
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		cmds := make([]tea.Cmd, 0, len(attachments))
+		for _, attachment := range attachments {
+			cmds = append(cmds, util.CmdHandler(dialog.AttachmentAddedMsg{Attachment: attachment}))
+		}
+		return tea.BatchMsg(cmds)
+	}
+}
+
+// mcpResourceAttachments converts the contents of a resource to attachments.
+// Text is inlined in the prompt, and binary contents are only supported for
+// images and PDF documents.
+func mcpResourceAttachments(resource mcpclient.Resource, result *mcp.ReadResourceResult) ([]message.Attachment, error) {
+	var attachments []message.Attachment
+	for _, contents := range result.Contents {
+		switch c := contents.(type) {
+		case mcp.TextResourceContents:
+			mimeType := cmp.Or(c.MIMEType, resource.MIMEType, "text/plain")
+			if !message.IsTextMIMEType(mimeType) {
+				mimeType = "text/plain"
+			}
+			attachments = append(attachments, message.Attachment{
+				FilePath: c.URI,
+				FileName: cmp.Or(resource.Name, path.Base(c.URI)),
+				MimeType: mimeType,
+				Content:  []byte(c.Text),
+			})
+		case mcp.BlobResourceContents:
+			mimeType := cmp.Or(c.MIMEType, resource.MIMEType)
+			if !strings.HasPrefix(mimeType, "image/") && !message.IsPDFMIMEType(mimeType) {
+				return nil, fmt.Errorf("cannot attach %s: unsupported type %q", c.URI, mimeType)
+			}
+			data, err := base64.StdEncoding.DecodeString(c.Blob)
+			if err != nil {
+				return nil, fmt.Errorf("cannot attach %s: %w", c.URI, err)
+			}
+			attachments = append(attachments, message.Attachment{
+				FilePath: c.URI,
+				FileName: cmp.Or(resource.Name, path.Base(c.URI)),
+				MimeType: mimeType,
+				Content:  data,
+			})
+		}
+	}
+	if len(attachments) == 0 {
+		return nil, fmt.Errorf("MCP resource %s is empty", resource.URI)
+	}
+	return attachments, nil
+}
+
 func (m *editorCmp) Init() tea.Cmd {
 	return textarea.Blink
 }
@@ -129,6 +255,7 @@ func (m *editorCmp) send() tea.Cmd {
 	attachments := m.attachments
 
 	m.attachments = nil
+	m.preview = attachmentPreview{}
 	if value == "" {
 		return nil
 	}
@@ -147,6 +274,11 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.textarea = CreateTextArea(&m.textarea)
 	case dialog.CompletionSelectedMsg:
 		existingValue := m.textarea.Value()
+		if msg.ProviderID == completions.MCPResourcesProviderID {
+			// Resources are attached rather than referenced in the text
+			m.textarea.SetValue(strings.Replace(existingValue, msg.SearchString, "", 1))
+			return m, m.attachMCPResource(msg.CompletionValue)
+		}
 		modifiedValue := strings.Replace(existingValue, msg.SearchString, msg.CompletionValue, 1)
 
 		m.textarea.SetValue(modifiedValue)
@@ -158,10 +290,17 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case dialog.AttachmentAddedMsg:
 		if len(m.attachments) >= maxAttachments {
-			logging.ErrorPersist(fmt.Sprintf("cannot add more than %d images", maxAttachments))
+			logging.ErrorPersist(fmt.Sprintf("cannot add more than %d attachments", maxAttachments))
 			return m, cmd
 		}
 		m.attachments = append(m.attachments, msg.Attachment)
+		if preview, ok := m.thumbnail(msg.Attachment); ok {
+			m.preview = preview
+		}
+		return m, nil
+	case pasteTextMsg:
+		m.textarea, cmd = m.textarea.Update(tea.KeyMsg{Type: tea.KeyCtrlV})
+		return m, cmd
 	case tea.KeyMsg:
 		if key.Matches(msg, DeleteKeyMaps.AttachmentDeleteMode) {
 			m.deleteMode = true
@@ -188,6 +327,9 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			key.Matches(msg, messageKeys.HalfPageUp) || key.Matches(msg, messageKeys.HalfPageDown) {
 			return m, nil
 		}
+		if m.textarea.Focused() && key.Matches(msg, editorMaps.PasteImage) {
+			return m, m.pasteImage()
+		}
 		if key.Matches(msg, editorMaps.OpenEditor) {
 			if m.app.CoderAgent.IsSessionBusy(m.session.ID) {
 				return m, util.ReportWarn("Agent is working, please wait...")
@@ -225,6 +367,10 @@ func (m *editorCmp) View() string {
 		Bold(true).
 		Foreground(t.Primary())
 
+	// The thumbnail is shown on the right of the text
+	preview := m.previewView()
+	m.textarea.SetWidth(m.width - lipgloss.Width(preview))
+
 	if len(m.attachments) == 0 {
 		return lipgloss.JoinHorizontal(lipgloss.Top, style.Render(">"), m.textarea.View())
 	}
@@ -232,10 +378,44 @@ func (m *editorCmp) View() string {
 	return lipgloss.JoinVertical(lipgloss.Top,
 		m.attachmentsContent(),
 		lipgloss.JoinHorizontal(lipgloss.Top, style.Render(">"),
-			m.textarea.View()),
+			m.textarea.View(), preview),
 	)
 }
 
+// thumbnail renders an image attachment to fit the height of the editor
+func (m *editorCmp) thumbnail(attachment message.Attachment) (attachmentPreview, bool) {
+	if !strings.HasPrefix(attachment.MimeType, "image/") {
+		return attachmentPreview{}, false
+	}
+	img, _, err := stdimage.Decode(bytes.NewReader(attachment.Content))
+	if err != nil {
+		logging.Debug("Failed to render the attachment preview", "path", attachment.FilePath, "error", err)
+		return attachmentPreview{}, false
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return attachmentPreview{}, false
+	}
+	// Each line shows two rows of pixels
+	rows := m.height - 1
+	width := min(m.width/4, 2*rows*bounds.Dx()/bounds.Dy())
+	if width < 1 {
+		return attachmentPreview{}, false
+	}
+	view := image.ToString(width, img)
+	return attachmentPreview{path: attachment.FilePath, view: strings.TrimSuffix(view, "\n")}, true
+}
+
+// previewView returns the thumbnail, while its attachment is not removed
+func (m *editorCmp) previewView() string {
+	for _, attachment := range m.attachments {
+		if m.preview.path != "" && attachment.FilePath == m.preview.path {
+			return " " + m.preview.view
+		}
+	}
+	return ""
+}
+
 func (m *editorCmp) SetSize(width, height int) tea.Cmd {
 	m.width = width
 	m.height = height
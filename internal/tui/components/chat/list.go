@@ -403,12 +403,18 @@ func (m *messagesCmp) help() string {
 func (m *messagesCmp) initialScreen() string {
 	baseStyle := styles.BaseStyle()
 
+	sections := []string{
+		header(m.width),
+		"",
+		lspsConfigured(m.width),
+	}
+	if servers := m.app.MCPClients.Servers(); len(servers) > 0 {
+		sections = append(sections, "", mcpServersSection(m.width, servers))
+	}
 	return baseStyle.Width(m.width).Render(
 		lipgloss.JoinVertical(
 			lipgloss.Top,
-			header(m.width),
-			"",
-			lspsConfigured(m.width),
+			sections...,
 		),
 	)
 }
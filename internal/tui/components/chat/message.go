@@ -222,10 +222,44 @@ func toolName(name string) string {
 		return "Task"
 	case tools.BashToolName:
 		return "Bash"
+	case tools.BashOutputToolName:
+		return "Job Output"
+	case tools.BashInputToolName:
+		return "Job Input"
+	case tools.BashKillToolName:
+		return "Kill Job"
 	case tools.EditToolName:
 		return "Edit"
 	case tools.FetchToolName:
 		return "Fetch"
+	case tools.GitStatusToolName:
+		return "Git Status"
+	case tools.GitDiffToolName:
+		return "Git Diff"
+	case tools.GitLogToolName:
+		return "Git Log"
+	case tools.GitCommitToolName:
+		return "Git Commit"
+	case tools.GitBranchToolName:
+		return "Git Branch"
+	case tools.DefinitionToolName:
+		return "Definition"
+	case tools.ReferencesToolName:
+		return "References"
+	case tools.HoverToolName:
+		return "Hover"
+	case tools.CallHierarchyToolName:
+		return "Call Hierarchy"
+	case tools.WorkspaceSymbolsToolName:
+		return "Symbols"
+	case tools.RepoMapToolName:
+		return "Repo Map"
+	case tools.RenameSymbolToolName:
+		return "Rename"
+	case tools.CodeActionToolName:
+		return "Code Action"
+	case tools.DiagnosticsToolName:
+		return "Diagnostics"
 	case tools.GlobToolName:
 		return "Glob"
 	case tools.GrepToolName:
@@ -236,6 +270,8 @@ func toolName(name string) string {
 		return "Sourcegraph"
 	case tools.ViewToolName:
 		return "View"
+	case tools.WebSearchToolName:
+		return "Web Search"
 	case tools.WriteToolName:
 		return "Write"
 	case tools.PatchToolName:
@@ -250,10 +286,34 @@ func getToolAction(name string) string {
 		return "Preparing prompt..."
 	case tools.BashToolName:
 		return "Building command..."
+	case tools.BashOutputToolName:
+		return "Reading output..."
+	case tools.BashInputToolName:
+		return "Sending input..."
+	case tools.BashKillToolName:
+		return "Stopping job..."
 	case tools.EditToolName:
 		return "Preparing edit..."
 	case tools.FetchToolName:
 		return "Writing fetch..."
+	case tools.GitStatusToolName, tools.GitDiffToolName, tools.GitLogToolName:
+		return "Reading repository..."
+	case tools.GitCommitToolName:
+		return "Preparing commit..."
+	case tools.GitBranchToolName:
+		return "Preparing branch..."
+	case tools.DefinitionToolName, tools.ReferencesToolName, tools.HoverToolName, tools.CallHierarchyToolName:
+		return "Looking up symbol..."
+	case tools.WorkspaceSymbolsToolName:
+		return "Searching symbols..."
+	case tools.RepoMapToolName:
+		return "Mapping repository..."
+	case tools.RenameSymbolToolName:
+		return "Preparing rename..."
+	case tools.CodeActionToolName:
+		return "Preparing code action..."
+	case tools.DiagnosticsToolName:
+		return "Collecting diagnostics..."
 	case tools.GlobToolName:
 		return "Finding files..."
 	case tools.GrepToolName:
@@ -264,6 +324,8 @@ func getToolAction(name string) string {
 		return "Searching code..."
 	case tools.ViewToolName:
 		return "Reading file..."
+	case tools.WebSearchToolName:
+		return "Searching the web..."
 	case tools.WriteToolName:
 		return "Preparing write..."
 	case tools.PatchToolName:
@@ -315,6 +377,19 @@ func renderParams(paramsWidth int, params ...string) string {
 	return ansi.Truncate(mainParam, paramsWidth, "...")
 }
 
+func navigationTarget(params tools.NavigationParams) string {
+	if params.FilePath == "" {
+		return params.Symbol
+	}
+	target := removeWorkingDirPrefix(params.FilePath)
+	if params.Line > 0 {
+		target = fmt.Sprintf("%s:%d:%d", target, params.Line, max(params.Column, 1))
+	} else if params.Symbol != "" {
+		target = fmt.Sprintf("%s in %s", params.Symbol, target)
+	}
+	return target
+}
+
 func removeWorkingDirPrefix(path string) string {
 	wd := config.WorkingDirectory()
 	if strings.HasPrefix(path, wd) {
@@ -344,7 +419,26 @@ func renderToolParams(paramWidth int, toolCall message.ToolCall) string {
 		var params tools.BashParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
 		command := strings.ReplaceAll(params.Command, "\n", " ")
+		if params.RunInBackground {
+			return renderParams(paramWidth, command, "background", "true")
+		}
 		return renderParams(paramWidth, command)
+	case tools.BashOutputToolName:
+		var params tools.BashOutputParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		if params.JobID == "" {
+			return renderParams(paramWidth, "all jobs")
+		}
+		return renderParams(paramWidth, params.JobID)
+	case tools.BashInputToolName:
+		var params tools.BashInputParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		input := strings.ReplaceAll(params.Input, "\n", "\\n")
+		return renderParams(paramWidth, params.JobID, "input", input)
+	case tools.BashKillToolName:
+		var params tools.BashKillParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		return renderParams(paramWidth, params.JobID)
 	case tools.EditToolName:
 		var params tools.EditParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
@@ -363,6 +457,104 @@ func renderToolParams(paramWidth int, toolCall message.ToolCall) string {
 		if params.Timeout != 0 {
 			toolParams = append(toolParams, "timeout", (time.Duration(params.Timeout) * time.Second).String())
 		}
+		if params.Offset != 0 {
+			toolParams = append(toolParams, "offset", fmt.Sprintf("%d", params.Offset))
+		}
+		return renderParams(paramWidth, toolParams...)
+	case tools.GitStatusToolName:
+		return ""
+	case tools.GitDiffToolName:
+		var params tools.GitDiffParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		target := "unstaged"
+		if params.Staged {
+			target = "staged"
+		}
+		if params.Ref != "" {
+			target = params.Ref
+		}
+		return renderParams(paramWidth, target, "path", params.Path)
+	case tools.GitLogToolName:
+		var params tools.GitLogParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		ref := params.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		limit := ""
+		if params.Limit > 0 {
+			limit = fmt.Sprintf("%d", params.Limit)
+		}
+		return renderParams(paramWidth, ref, "limit", limit, "path", params.Path)
+	case tools.GitCommitToolName:
+		var params tools.GitCommitParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		message := strings.Split(params.Message, "\n")[0]
+		files := ""
+		if len(params.Files) > 0 {
+			files = fmt.Sprintf("%d", len(params.Files))
+		}
+		return renderParams(paramWidth, message, "files", files)
+	case tools.GitBranchToolName:
+		var params tools.GitBranchParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		if params.Name == "" {
+			return renderParams(paramWidth, "list")
+		}
+		checkout := ""
+		if params.Checkout {
+			checkout = "true"
+		}
+		return renderParams(paramWidth, params.Name, "from", params.StartPoint, "checkout", checkout)
+	case tools.DefinitionToolName, tools.ReferencesToolName, tools.HoverToolName:
+		var params tools.NavigationParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		return renderParams(paramWidth, navigationTarget(params))
+	case tools.CallHierarchyToolName:
+		var params tools.CallHierarchyParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		return renderParams(paramWidth, navigationTarget(params.NavigationParams), "direction", params.Direction)
+	case tools.WorkspaceSymbolsToolName:
+		var params tools.WorkspaceSymbolsParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		return renderParams(paramWidth, params.Query)
+	case tools.RepoMapToolName:
+		var params tools.RepoMapParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		path := params.Path
+		if path == "" {
+			path = "."
+		}
+		return renderParams(paramWidth, path)
+	case tools.RenameSymbolToolName:
+		var params tools.RenameSymbolParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		return renderParams(paramWidth, navigationTarget(params.NavigationParams), "to", params.NewName)
+	case tools.CodeActionToolName:
+		var params tools.CodeActionParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		target := removeWorkingDirPrefix(params.FilePath)
+		if params.Line > 0 {
+			target = fmt.Sprintf("%s:%d", target, params.Line)
+		}
+		action := params.Title
+		if action == "" {
+			action = params.Kind
+		}
+		return renderParams(paramWidth, target, "action", action)
+	case tools.DiagnosticsToolName:
+		var params tools.DiagnosticsParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		if params.FilePath != "" {
+			return renderParams(paramWidth, removeWorkingDirPrefix(params.FilePath))
+		}
+		toolParams := []string{"project"}
+		if params.Severity != "" {
+			toolParams = append(toolParams, "severity", params.Severity)
+		}
+		if params.PathGlob != "" {
+			toolParams = append(toolParams, "path_glob", params.PathGlob)
+		}
 		return renderParams(paramWidth, toolParams...)
 	case tools.GlobToolName:
 		var params tools.GlobParams
@@ -404,6 +596,16 @@ func renderToolParams(paramWidth int, toolCall message.ToolCall) string {
 		var params tools.SourcegraphParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
 		return renderParams(paramWidth, params.Query)
+	case tools.WebSearchToolName:
+		var params tools.WebSearchParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		toolParams := []string{
+			params.Query,
+		}
+		if params.MaxResults != 0 {
+			toolParams = append(toolParams, "max_results", fmt.Sprintf("%d", params.MaxResults))
+		}
+		return renderParams(paramWidth, toolParams...)
 	case tools.ViewToolName:
 		var params tools.ViewParams
 		json.Unmarshal([]byte(toolCall.Input), &params)
@@ -452,13 +654,17 @@ func renderToolResponse(toolCall message.ToolCall, response message.ToolResult,
 	}
 
 	resultContent := truncateHeight(response.Content, maxResultHeight)
+	if len(response.Images) > 0 {
+		// The content describes the images
+		return baseStyle.Width(width).Foreground(t.TextMuted()).Render(resultContent)
+	}
 	switch toolCall.Name {
 	case agent.AgentToolName:
 		return styles.ForceReplaceBackgroundWithLipgloss(
 			toMarkdown(resultContent, false, width),
 			t.Background(),
 		)
-	case tools.BashToolName:
+	case tools.BashToolName, tools.BashOutputToolName:
 		resultContent = fmt.Sprintf("```bash\n%s\n```", resultContent)
 		return styles.ForceReplaceBackgroundWithLipgloss(
 			toMarkdown(resultContent, true, width),
@@ -485,6 +691,34 @@ func renderToolResponse(toolCall message.ToolCall, response message.ToolResult,
 			toMarkdown(resultContent, true, width),
 			t.Background(),
 		)
+	case tools.GitDiffToolName:
+		resultContent = fmt.Sprintf("```diff\n%s\n```", resultContent)
+		return styles.ForceReplaceBackgroundWithLipgloss(
+			toMarkdown(resultContent, true, width),
+			t.Background(),
+		)
+	case tools.GitStatusToolName, tools.GitLogToolName, tools.GitBranchToolName:
+		return baseStyle.Width(width).Foreground(t.TextMuted()).Render(resultContent)
+	case tools.HoverToolName:
+		return styles.ForceReplaceBackgroundWithLipgloss(
+			toMarkdown(resultContent, true, width),
+			t.Background(),
+		)
+	case tools.DefinitionToolName, tools.ReferencesToolName, tools.CallHierarchyToolName, tools.WorkspaceSymbolsToolName, tools.RepoMapToolName:
+		return baseStyle.Width(width).Foreground(t.TextMuted()).Render(resultContent)
+	case tools.RenameSymbolToolName, tools.CodeActionToolName:
+		metadata := tools.WorkspaceEditResponseMetadata{}
+		json.Unmarshal([]byte(response.Metadata), &metadata)
+		if len(metadata.Files) == 0 {
+			// Listing code actions does not change any file
+			return baseStyle.Width(width).Foreground(t.TextMuted()).Render(resultContent)
+		}
+		parts := make([]string, 0, len(metadata.Files)*2)
+		for _, file := range metadata.Files {
+			formattedDiff, _ := diff.FormatDiff(truncateHeight(file.Diff, maxResultHeight), diff.WithTotalWidth(width))
+			parts = append(parts, baseStyle.Width(width).Foreground(t.Primary()).Bold(true).Render(file.Path), formattedDiff)
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, parts...)
 	case tools.GlobToolName:
 		return baseStyle.Width(width).Foreground(t.TextMuted()).Render(resultContent)
 	case tools.GrepToolName:
@@ -493,6 +727,11 @@ func renderToolResponse(toolCall message.ToolCall, response message.ToolResult,
 		return baseStyle.Width(width).Foreground(t.TextMuted()).Render(resultContent)
 	case tools.SourcegraphToolName:
 		return baseStyle.Width(width).Foreground(t.TextMuted()).Render(resultContent)
+	case tools.WebSearchToolName:
+		return styles.ForceReplaceBackgroundWithLipgloss(
+			toMarkdown(resultContent, true, width),
+			t.Background(),
+		)
 	case tools.ViewToolName:
 		metadata := tools.ViewResponseMetadata{}
 		json.Unmarshal([]byte(response.Metadata), &metadata)
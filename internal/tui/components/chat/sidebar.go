@@ -8,9 +8,12 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/diff"
 	"github.com/opencode-ai/opencode/internal/history"
+	"github.com/opencode-ai/opencode/internal/llm/tools/shell"
+	"github.com/opencode-ai/opencode/internal/mcpclient"
 	"github.com/opencode-ai/opencode/internal/pubsub"
 	"github.com/opencode-ai/opencode/internal/session"
 	"github.com/opencode-ai/opencode/internal/tui/styles"
@@ -25,9 +28,13 @@ type sidebarCmp struct {
 		additions int
 		removals  int
 	}
+	backgroundJobs shell.BackgroundService
+	jobs           map[string]shell.BackgroundJob
+	mcpClients     mcpclient.Service
 }
 
 func (m *sidebarCmp) Init() tea.Cmd {
+	m.loadBackgroundJobs()
 	if m.history != nil {
 		ctx := context.Background()
 		// Subscribe to file events
@@ -64,6 +71,11 @@ func (m *sidebarCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.session = msg.Payload
 			}
 		}
+	case pubsub.Event[shell.BackgroundJob]:
+		if m.jobs == nil {
+			m.jobs = make(map[string]shell.BackgroundJob)
+		}
+		m.jobs[msg.Payload.ID] = msg.Payload
 	case pubsub.Event[history.File]:
 		if msg.Payload.SessionID == m.session.ID {
 			// Process the individual file change instead of reloading all files
@@ -84,6 +96,23 @@ func (m *sidebarCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *sidebarCmp) View() string {
 	baseStyle := styles.BaseStyle()
 
+	sections := []string{
+		header(m.width),
+		" ",
+		m.sessionSection(),
+		" ",
+		lspsConfigured(m.width),
+	}
+	if m.mcpClients != nil {
+		if servers := m.mcpClients.Servers(); len(servers) > 0 {
+			sections = append(sections, " ", mcpServersSection(m.width, servers))
+		}
+	}
+	sections = append(sections, " ", m.modifiedFiles())
+	if len(m.jobs) > 0 {
+		sections = append(sections, " ", m.backgroundJobsSection())
+	}
+
 	return baseStyle.
 		Width(m.width).
 		PaddingLeft(4).
@@ -92,13 +121,7 @@ func (m *sidebarCmp) View() string {
 		Render(
 			lipgloss.JoinVertical(
 				lipgloss.Top,
-				header(m.width),
-				" ",
-				m.sessionSection(),
-				" ",
-				lspsConfigured(m.width),
-				" ",
-				m.modifiedFiles(),
+				sections...,
 			),
 		)
 }
@@ -225,6 +248,76 @@ func (m *sidebarCmp) modifiedFiles() string {
 		)
 }
 
+func (m *sidebarCmp) backgroundJobsSection() string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+
+	title := baseStyle.
+		Width(m.width).
+		Foreground(t.Primary()).
+		Bold(true).
+		Render("Background Jobs:")
+
+	jobs := make([]shell.BackgroundJob, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt < jobs[j].StartedAt ||
+			(jobs[i].StartedAt == jobs[j].StartedAt && jobs[i].ID < jobs[j].ID)
+	})
+
+	var jobViews []string
+	for _, job := range jobs {
+		statusColor := t.TextMuted()
+		status := string(job.Status)
+		switch job.Status {
+		case shell.JobRunning:
+			statusColor = t.Success()
+		case shell.JobExited:
+			status = fmt.Sprintf("exit %d", job.ExitCode)
+			if job.ExitCode != 0 {
+				statusColor = t.Error()
+			}
+		}
+
+		id := baseStyle.Foreground(t.Text()).Render(fmt.Sprintf("• %s ", job.ID))
+		statusStr := baseStyle.Foreground(statusColor).Render(status)
+		command := strings.ReplaceAll(job.Command, "\n", " ")
+		command = ansi.Truncate(command, m.width-lipgloss.Width(id)-lipgloss.Width(statusStr)-3, "…")
+		commandStr := baseStyle.Foreground(t.TextMuted()).Render(fmt.Sprintf(" %s", command))
+
+		jobViews = append(jobViews,
+			baseStyle.
+				Width(m.width).
+				Render(lipgloss.JoinHorizontal(lipgloss.Left, id, statusStr, commandStr)),
+		)
+	}
+
+	return baseStyle.
+		Width(m.width).
+		Render(
+			lipgloss.JoinVertical(
+				lipgloss.Top,
+				title,
+				lipgloss.JoinVertical(
+					lipgloss.Left,
+					jobViews...,
+				),
+			),
+		)
+}
+
+func (m *sidebarCmp) loadBackgroundJobs() {
+	if m.backgroundJobs == nil {
+		return
+	}
+	m.jobs = make(map[string]shell.BackgroundJob)
+	for _, job := range m.backgroundJobs.List() {
+		m.jobs[job.ID] = job
+	}
+}
+
 func (m *sidebarCmp) SetSize(width, height int) tea.Cmd {
 	m.width = width
 	m.height = height
@@ -235,10 +328,12 @@ func (m *sidebarCmp) GetSize() (int, int) {
 	return m.width, m.height
 }
 
-func NewSidebarCmp(session session.Session, history history.Service) tea.Model {
+func NewSidebarCmp(session session.Session, history history.Service, backgroundJobs shell.BackgroundService, mcpClients mcpclient.Service) tea.Model {
 	return &sidebarCmp{
-		session: session,
-		history: history,
+		session:        session,
+		history:        history,
+		backgroundJobs: backgroundJobs,
+		mcpClients:     mcpClients,
 	}
 }
 
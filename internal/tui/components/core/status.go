@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -182,16 +183,29 @@ func (m statusCmp) View() string {
 func (m *statusCmp) projectDiagnostics() string {
 	t := theme.CurrentTheme()
 
-	// Check if any LSP server is still initializing
+	// Check if any LSP server is still initializing, restarting or gave up
 	initializing := false
-	for _, client := range m.lspClients {
-		if client.GetServerState() == lsp.StateStarting {
+	var restarting, failed []string
+	for name, client := range m.lspClients {
+		switch client.GetServerState() {
+		case lsp.StateStarting:
 			initializing = true
-			break
+		case lsp.StateRestarting:
+			restarting = append(restarting, name)
+		case lsp.StateError:
+			failed = append(failed, name)
 		}
 	}
+	sort.Strings(restarting)
+	sort.Strings(failed)
 
-	// If any server is initializing, show that status
+	// If any server is restarting or initializing, show that status
+	if len(restarting) > 0 {
+		return lipgloss.NewStyle().
+			Background(t.BackgroundDarker()).
+			Foreground(t.Warning()).
+			Render(fmt.Sprintf("%s Restarting LSP (%s)...", styles.SpinnerIcon, strings.Join(restarting, ", ")))
+	}
 	if initializing {
 		return lipgloss.NewStyle().
 			Background(t.BackgroundDarker()).
@@ -220,12 +234,22 @@ func (m *statusCmp) projectDiagnostics() string {
 		}
 	}
 
+	diagnostics := []string{}
+	if len(failed) > 0 {
+		failedStr := lipgloss.NewStyle().
+			Background(t.BackgroundDarker()).
+			Foreground(t.Error()).
+			Render(fmt.Sprintf("%s LSP failed (%s)", styles.ErrorIcon, strings.Join(failed, ", ")))
+		diagnostics = append(diagnostics, failedStr)
+	}
+
 	if len(errorDiagnostics) == 0 && len(warnDiagnostics) == 0 && len(hintDiagnostics) == 0 && len(infoDiagnostics) == 0 {
+		if len(diagnostics) > 0 {
+			return diagnostics[0]
+		}
 		return "No diagnostics"
 	}
 
-	diagnostics := []string{}
-
 	if len(errorDiagnostics) > 0 {
 		errStr := lipgloss.NewStyle().
 			Background(t.BackgroundDarker()).
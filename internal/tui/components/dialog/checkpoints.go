@@ -0,0 +1,348 @@
+package dialog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/opencode-ai/opencode/internal/checkpoint"
+	"github.com/opencode-ai/opencode/internal/diff"
+	"github.com/opencode-ai/opencode/internal/tui/layout"
+	"github.com/opencode-ai/opencode/internal/tui/styles"
+	"github.com/opencode-ai/opencode/internal/tui/theme"
+	"github.com/opencode-ai/opencode/internal/tui/util"
+)
+
+const numVisibleCheckpoints = 10
+
+// CheckpointRestoredMsg is sent when the working tree was restored to a checkpoint
+type CheckpointRestoredMsg struct {
+	Checkpoint checkpoint.Checkpoint
+	// Undo is the checkpoint taken right before restoring
+	Undo checkpoint.Checkpoint
+}
+
+// CloseCheckpointDialogMsg is sent when the checkpoint dialog is closed
+type CloseCheckpointDialogMsg struct{}
+
+type checkpointDiffMsg struct {
+	id   string
+	diff string
+	err  error
+}
+
+// CheckpointDialog interface for the checkpoint timeline dialog
+type CheckpointDialog interface {
+	tea.Model
+	layout.Bindings
+	SetCheckpoints(sessionID string, checkpoints []checkpoint.Checkpoint)
+}
+
+type checkpointDialogCmp struct {
+	service     checkpoint.Service
+	sessionID   string
+	checkpoints []checkpoint.Checkpoint
+	selectedIdx int
+	width       int
+	height      int
+
+	showDiff       bool
+	diffViewport   viewport.Model
+	confirmRestore bool
+}
+
+type checkpointKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Enter   key.Binding
+	Restore key.Binding
+	Escape  key.Binding
+	J       key.Binding
+	K       key.Binding
+}
+
+var checkpointKeys = checkpointKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up"),
+		key.WithHelp("↑", "previous checkpoint"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down"),
+		key.WithHelp("↓", "next checkpoint"),
+	),
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "show changes since checkpoint"),
+	),
+	Restore: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "restore checkpoint"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back/close"),
+	),
+	J: key.NewBinding(
+		key.WithKeys("j"),
+		key.WithHelp("j", "next checkpoint"),
+	),
+	K: key.NewBinding(
+		key.WithKeys("k"),
+		key.WithHelp("k", "previous checkpoint"),
+	),
+}
+
+func (c *checkpointDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (c *checkpointDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.width = msg.Width
+		c.height = msg.Height
+		c.diffViewport.Width = c.diffWidth()
+		c.diffViewport.Height = max(5, c.height-12)
+	case checkpointDiffMsg:
+		if msg.err != nil {
+			c.showDiff = false
+			return c, util.ReportError(msg.err)
+		}
+		if c.showDiff && c.selected().ID == msg.id {
+			c.diffViewport.SetContent(c.renderDiff(msg.diff))
+			c.diffViewport.GotoTop()
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, checkpointKeys.Escape):
+			if c.confirmRestore {
+				c.confirmRestore = false
+				return c, nil
+			}
+			if c.showDiff {
+				c.showDiff = false
+				return c, nil
+			}
+			return c, util.CmdHandler(CloseCheckpointDialogMsg{})
+		case key.Matches(msg, checkpointKeys.Restore):
+			if len(c.checkpoints) == 0 {
+				return c, nil
+			}
+			if !c.confirmRestore {
+				c.confirmRestore = true
+				return c, nil
+			}
+			c.confirmRestore = false
+			return c, c.restore(c.selected())
+		}
+
+		c.confirmRestore = false
+		if c.showDiff {
+			var cmd tea.Cmd
+			c.diffViewport, cmd = c.diffViewport.Update(msg)
+			return c, cmd
+		}
+
+		switch {
+		case key.Matches(msg, checkpointKeys.Up) || key.Matches(msg, checkpointKeys.K):
+			if c.selectedIdx > 0 {
+				c.selectedIdx--
+			}
+		case key.Matches(msg, checkpointKeys.Down) || key.Matches(msg, checkpointKeys.J):
+			if c.selectedIdx < len(c.checkpoints)-1 {
+				c.selectedIdx++
+			}
+		case key.Matches(msg, checkpointKeys.Enter):
+			if len(c.checkpoints) > 0 {
+				c.showDiff = true
+				c.diffViewport.SetContent("Loading changes...")
+				return c, c.loadDiff(c.selected())
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *checkpointDialogCmp) selected() checkpoint.Checkpoint {
+	if c.selectedIdx >= len(c.checkpoints) {
+		return checkpoint.Checkpoint{}
+	}
+	return c.checkpoints[c.selectedIdx]
+}
+
+func (c *checkpointDialogCmp) loadDiff(cp checkpoint.Checkpoint) tea.Cmd {
+	return func() tea.Msg {
+		d, err := c.service.Diff(context.Background(), cp.ID)
+		return checkpointDiffMsg{id: cp.ID, diff: d, err: err}
+	}
+}
+
+func (c *checkpointDialogCmp) restore(cp checkpoint.Checkpoint) tea.Cmd {
+	sessionID := c.sessionID
+	return func() tea.Msg {
+		undo, err := c.service.Restore(context.Background(), sessionID, cp.ID)
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		return CheckpointRestoredMsg{Checkpoint: cp, Undo: undo}
+	}
+}
+
+func (c *checkpointDialogCmp) diffWidth() int {
+	return max(40, min(c.width-10, 160))
+}
+
+func (c *checkpointDialogCmp) renderDiff(diffText string) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+	width := c.diffWidth()
+
+	files := diff.SplitUnifiedDiff(diffText)
+	if strings.TrimSpace(diffText) == "" || len(files) == 0 {
+		return baseStyle.Foreground(t.TextMuted()).Render("No changes since this checkpoint")
+	}
+
+	var parts []string
+	for _, file := range files {
+		parts = append(parts, baseStyle.Foreground(t.Primary()).Bold(true).Width(width).Render(file.Path))
+		formatted, err := diff.FormatDiff(file.Diff, diff.WithTotalWidth(width))
+		if err != nil || strings.TrimSpace(formatted) == "" {
+			formatted = baseStyle.Foreground(t.TextMuted()).Width(width).Render("Binary file or mode change")
+		}
+		parts = append(parts, strings.TrimSuffix(formatted, "\n"), baseStyle.Width(width).Render(""))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+func formatCheckpointTime(unix int64) string {
+	created := time.Unix(unix, 0)
+	if time.Since(created) < 24*time.Hour {
+		return created.Format("15:04:05")
+	}
+	return created.Format("Jan 2 15:04")
+}
+
+func (c *checkpointDialogCmp) View() string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+
+	if len(c.checkpoints) == 0 {
+		return baseStyle.Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderBackground(t.Background()).
+			BorderForeground(t.TextMuted()).
+			Width(40).
+			Render("No checkpoints available")
+	}
+
+	var content string
+	if c.showDiff {
+		width := c.diffWidth()
+		cp := c.selected()
+		title := baseStyle.
+			Foreground(t.Primary()).
+			Bold(true).
+			Width(width).
+			Render(fmt.Sprintf("Changes since %s: %s", formatCheckpointTime(cp.CreatedAt), cp.Prompt))
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			title,
+			baseStyle.Width(width).Render(""),
+			c.diffViewport.View(),
+			baseStyle.Width(width).Render(""),
+			c.renderFooter(width),
+		)
+	} else {
+		maxWidth := max(40, min(80, c.width-15))
+
+		startIdx := 0
+		if c.selectedIdx >= numVisibleCheckpoints {
+			startIdx = c.selectedIdx - numVisibleCheckpoints + 1
+		}
+		endIdx := min(startIdx+numVisibleCheckpoints, len(c.checkpoints))
+
+		items := make([]string, 0, endIdx-startIdx)
+		for i := startIdx; i < endIdx; i++ {
+			cp := c.checkpoints[i]
+			itemStyle := baseStyle.Width(maxWidth)
+			if i == c.selectedIdx {
+				itemStyle = itemStyle.
+					Background(t.Primary()).
+					Foreground(t.Background()).
+					Bold(true)
+			}
+			label := fmt.Sprintf("%-11s %s", formatCheckpointTime(cp.CreatedAt), cp.Prompt)
+			items = append(items, itemStyle.Padding(0, 1).MaxHeight(1).Render(label))
+		}
+
+		title := baseStyle.
+			Foreground(t.Primary()).
+			Bold(true).
+			Width(maxWidth).
+			Padding(0, 1).
+			Render("Checkpoints")
+
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			title,
+			baseStyle.Width(maxWidth).Render(""),
+			baseStyle.Width(maxWidth).Render(lipgloss.JoinVertical(lipgloss.Left, items...)),
+			baseStyle.Width(maxWidth).Render(""),
+			c.renderFooter(maxWidth),
+		)
+	}
+
+	return baseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(t.Background()).
+		BorderForeground(t.TextMuted()).
+		Width(lipgloss.Width(content) + 4).
+		Render(content)
+}
+
+func (c *checkpointDialogCmp) renderFooter(width int) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+
+	if c.confirmRestore {
+		return baseStyle.
+			Foreground(t.Warning()).
+			Width(width).
+			Padding(0, 1).
+			Render("Press r again to restore the working tree to this checkpoint, esc to cancel")
+	}
+	help := "enter: show changes • r: restore • esc: close"
+	if c.showDiff {
+		help = "↑/↓: scroll • r: restore • esc: back"
+	}
+	return baseStyle.
+		Foreground(t.TextMuted()).
+		Width(width).
+		Padding(0, 1).
+		Render(help)
+}
+
+func (c *checkpointDialogCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(checkpointKeys)
+}
+
+func (c *checkpointDialogCmp) SetCheckpoints(sessionID string, checkpoints []checkpoint.Checkpoint) {
+	c.sessionID = sessionID
+	c.checkpoints = checkpoints
+	c.selectedIdx = 0
+	c.showDiff = false
+	c.confirmRestore = false
+}
+
+// NewCheckpointDialogCmp creates a new checkpoint timeline dialog
+func NewCheckpointDialogCmp(service checkpoint.Service) CheckpointDialog {
+	return &checkpointDialogCmp{
+		service:      service,
+		diffViewport: viewport.New(0, 0),
+	}
+}
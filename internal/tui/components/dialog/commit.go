@@ -0,0 +1,162 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/opencode-ai/opencode/internal/tui/layout"
+	"github.com/opencode-ai/opencode/internal/tui/styles"
+	"github.com/opencode-ai/opencode/internal/tui/theme"
+	"github.com/opencode-ai/opencode/internal/tui/util"
+)
+
+const numVisibleCommitFiles = 8
+
+// CommitConfirmedMsg is sent when the user confirms the commit message
+type CommitConfirmedMsg struct {
+	Message string
+	Files   []string
+}
+
+// CloseCommitDialogMsg is sent when the commit dialog is closed without committing
+type CloseCommitDialogMsg struct{}
+
+// CommitDialog interface for the commit changes dialog
+type CommitDialog interface {
+	tea.Model
+	layout.Bindings
+	SetCommit(message string, files []string)
+}
+
+type commitDialogCmp struct {
+	textarea textarea.Model
+	files    []string
+	width    int
+	height   int
+}
+
+type commitKeyMap struct {
+	Commit key.Binding
+	Escape key.Binding
+}
+
+var commitKeys = commitKeyMap{
+	Commit: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "commit"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+}
+
+func (c *commitDialogCmp) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (c *commitDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.width = msg.Width
+		c.height = msg.Height
+		c.textarea.SetWidth(c.dialogWidth())
+		c.textarea.SetHeight(max(3, min(10, c.height-numVisibleCommitFiles-14)))
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, commitKeys.Escape):
+			return c, util.CmdHandler(CloseCommitDialogMsg{})
+		case key.Matches(msg, commitKeys.Commit):
+			message := strings.TrimSpace(c.textarea.Value())
+			if message == "" {
+				return c, util.ReportWarn("Commit message is empty")
+			}
+			return c, util.CmdHandler(CommitConfirmedMsg{Message: message, Files: c.files})
+		}
+	}
+	var cmd tea.Cmd
+	c.textarea, cmd = c.textarea.Update(msg)
+	return c, cmd
+}
+
+func (c *commitDialogCmp) dialogWidth() int {
+	return max(40, min(80, c.width-15))
+}
+
+func (c *commitDialogCmp) View() string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+	width := c.dialogWidth()
+
+	title := baseStyle.
+		Foreground(t.Primary()).
+		Bold(true).
+		Width(width).
+		Render("Commit changes")
+
+	files := make([]string, 0, numVisibleCommitFiles+1)
+	for i, file := range c.files {
+		if i == numVisibleCommitFiles {
+			more := fmt.Sprintf("  ... and %d more", len(c.files)-numVisibleCommitFiles)
+			files = append(files, baseStyle.Foreground(t.TextMuted()).Width(width).Render(more))
+			break
+		}
+		files = append(files, baseStyle.Foreground(t.TextMuted()).Width(width).MaxHeight(1).Render("  "+file))
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		baseStyle.Width(width).Render(""),
+		c.textarea.View(),
+		baseStyle.Width(width).Render(""),
+		baseStyle.Width(width).Render("Files:"),
+		lipgloss.JoinVertical(lipgloss.Left, files...),
+		baseStyle.Width(width).Render(""),
+		baseStyle.Foreground(t.TextMuted()).Width(width).Render("ctrl+s: commit • esc: cancel"),
+	)
+
+	return baseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(t.Background()).
+		BorderForeground(t.TextMuted()).
+		Width(lipgloss.Width(content) + 4).
+		Render(content)
+}
+
+func (c *commitDialogCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(commitKeys)
+}
+
+func (c *commitDialogCmp) SetCommit(message string, files []string) {
+	c.files = files
+	c.textarea.SetValue(message)
+	c.textarea.Focus()
+}
+
+// NewCommitDialogCmp creates a new dialog to edit and confirm a commit message
+func NewCommitDialogCmp() CommitDialog {
+	t := theme.CurrentTheme()
+	bgColor := t.Background()
+
+	ta := textarea.New()
+	ta.BlurredStyle.Base = styles.BaseStyle().Background(bgColor).Foreground(t.Text())
+	ta.BlurredStyle.CursorLine = styles.BaseStyle().Background(bgColor)
+	ta.BlurredStyle.Text = styles.BaseStyle().Background(bgColor).Foreground(t.Text())
+	ta.FocusedStyle.Base = styles.BaseStyle().Background(bgColor).Foreground(t.Text())
+	ta.FocusedStyle.CursorLine = styles.BaseStyle().Background(bgColor)
+	ta.FocusedStyle.Text = styles.BaseStyle().Background(bgColor).Foreground(t.Text())
+	ta.Prompt = " "
+	ta.ShowLineNumbers = false
+	ta.CharLimit = -1
+	ta.SetWidth(60)
+	ta.SetHeight(6)
+
+	return &commitDialogCmp{
+		textarea: ta,
+	}
+}
@@ -68,6 +68,8 @@ type CompletionProvider interface {
 type CompletionSelectedMsg struct {
 	SearchString    string
 	CompletionValue string
+	// ProviderID is the id of the provider of the completion
+	ProviderID string
 }
 
 type CompletionDialogCompleteItemMsg struct {
@@ -83,8 +85,10 @@ type CompletionDialog interface {
 }
 
 type completionDialogCmp struct {
-	query                string
-	completionProvider   CompletionProvider
+	query               string
+	completionProviders []CompletionProvider
+	// itemProviders maps the listed items to the id of their provider
+	itemProviders        map[CompletionItemI]string
 	width                int
 	height               int
 	pseudoSearchTextArea textarea.Model
@@ -120,11 +124,30 @@ func (c *completionDialogCmp) complete(item CompletionItemI) tea.Cmd {
 		util.CmdHandler(CompletionSelectedMsg{
 			SearchString:    value,
 			CompletionValue: item.GetValue(),
+			ProviderID:      c.itemProviders[item],
 		}),
 		c.close(),
 	)
 }
 
+// setEntries lists the entries of all the providers matching the query
+func (c *completionDialogCmp) setEntries(query string) {
+	var items []CompletionItemI
+	c.itemProviders = make(map[CompletionItemI]string)
+	for _, provider := range c.completionProviders {
+		entries, err := provider.GetChildEntries(query)
+		if err != nil {
+			logging.Error("Failed to get child entries", "provider", provider.GetId(), "error", err)
+			continue
+		}
+		for _, entry := range entries {
+			c.itemProviders[entry] = provider.GetId()
+		}
+		items = append(items, entries...)
+	}
+	c.listView.SetItems(items)
+}
+
 func (c *completionDialogCmp) close() tea.Cmd {
 	c.listView.SetItems([]CompletionItemI{})
 	c.pseudoSearchTextArea.Reset()
@@ -153,12 +176,7 @@ func (c *completionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 				if query != c.query {
 					logging.Info("Query", query)
-					items, err := c.completionProvider.GetChildEntries(query)
-					if err != nil {
-						logging.Error("Failed to get child entries", err)
-					}
-
-					c.listView.SetItems(items)
+					c.setEntries(query)
 					c.query = query
 				}
 
@@ -187,12 +205,7 @@ func (c *completionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			return c, tea.Batch(cmds...)
 		} else {
-			items, err := c.completionProvider.GetChildEntries("")
-			if err != nil {
-				logging.Error("Failed to get child entries", err)
-			}
-
-			c.listView.SetItems(items)
+			c.setEntries("")
 			c.pseudoSearchTextArea.SetValue(msg.String())
 			return c, c.pseudoSearchTextArea.Focus()
 		}
@@ -240,25 +253,24 @@ func (c *completionDialogCmp) BindingKeys() []key.Binding {
 	return layout.KeyMapToSlice(completionDialogKeys)
 }
 
-func NewCompletionDialogCmp(completionProvider CompletionProvider) CompletionDialog {
+// NewCompletionDialogCmp creates a dialog listing the entries of the
+// providers, in order
+func NewCompletionDialogCmp(completionProviders ...CompletionProvider) CompletionDialog {
 	ti := textarea.New()
 
-	items, err := completionProvider.GetChildEntries("")
-	if err != nil {
-		logging.Error("Failed to get child entries", err)
-	}
-
 	li := utilComponents.NewSimpleList(
-		items,
+		[]CompletionItemI{},
 		7,
-		"No file matches found",
+		"No matches found",
 		false,
 	)
 
-	return &completionDialogCmp{
+	c := &completionDialogCmp{
 		query:                "",
-		completionProvider:   completionProvider,
+		completionProviders:  completionProviders,
 		pseudoSearchTextArea: ti,
 		listView:             li,
 	}
+	c.setEntries("")
+	return c
 }
@@ -1,6 +1,7 @@
 package dialog
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -26,8 +28,10 @@ import (
 
 const (
 	maxAttachmentSize = int64(5 * 1024 * 1024) // 5MB
-	downArrow         = "down"
-	upArrow           = "up"
+	// maxTextAttachmentSize bounds the text files, which are inlined in the prompt
+	maxTextAttachmentSize = int64(256 * 1024) // 256KB
+	downArrow             = "down"
+	upArrow               = "up"
 )
 
 type FilePrickerKeyMap struct {
@@ -222,21 +226,10 @@ func (f *filepickerCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (f *filepickerCmp) addAttachmentToMessage() (tea.Model, tea.Cmd) {
-	modeInfo := GetSelectedModel(config.Get())
-	if !modeInfo.SupportsAttachments {
-		logging.ErrorPersist(fmt.Sprintf("Model %s doesn't support attachments", modeInfo.Name))
-		return f, nil
-	}
-
 	selectedFilePath := f.selectedFile
-	if !isExtSupported(selectedFilePath) {
-		logging.ErrorPersist("Unsupported file")
-		return f, nil
-	}
-
 	isFileLarge, err := image.ValidateFileSize(selectedFilePath, maxAttachmentSize)
 	if err != nil {
-		logging.ErrorPersist("unable to read the image")
+		logging.ErrorPersist("unable to read the file")
 		return f, nil
 	}
 	if isFileLarge {
@@ -250,14 +243,49 @@ func (f *filepickerCmp) addAttachmentToMessage() (tea.Model, tea.Cmd) {
 		return f, nil
 	}
 
-	mimeBufferSize := min(512, len(content))
-	mimeType := http.DetectContentType(content[:mimeBufferSize])
+	mimeType := attachmentMIMEType(selectedFilePath, content)
+	switch {
+	case mimeType == "":
+		logging.ErrorPersist("Unsupported file, only images, PDF documents and text files can be attached")
+		return f, nil
+	case message.IsTextMIMEType(mimeType):
+		if int64(len(content)) > maxTextAttachmentSize {
+			logging.ErrorPersist("text file too large, max 256KB")
+			return f, nil
+		}
+	case !message.IsPDFMIMEType(mimeType):
+		// Text files and the text of documents are inlined in the prompt,
+		// only images need a model that supports attachments
+		modeInfo := GetSelectedModel(config.Get())
+		if !modeInfo.SupportsAttachments {
+			logging.ErrorPersist(fmt.Sprintf("Model %s doesn't support images", modeInfo.Name))
+			return f, nil
+		}
+	}
+
 	fileName := filepath.Base(selectedFilePath)
 	attachment := message.Attachment{FilePath: selectedFilePath, FileName: fileName, MimeType: mimeType, Content: content}
 	f.selectedFile = ""
 	return f, util.CmdHandler(AttachmentAddedMsg{attachment})
 }
 
+// attachmentMIMEType returns the MIME type of a file that can be attached,
+// which is an image, a PDF document or a text file, and "" for other files
+func attachmentMIMEType(path string, content []byte) string {
+	mimeBufferSize := min(512, len(content))
+	mimeType := http.DetectContentType(content[:mimeBufferSize])
+	switch {
+	case isImageExt(path) && strings.HasPrefix(mimeType, "image/"):
+		return mimeType
+	case message.IsPDFMIMEType(mimeType), message.IsTextMIMEType(mimeType):
+		return mimeType
+	case utf8.Valid(content) && !bytes.ContainsRune(content, 0):
+		// Source files are often not recognized as text
+		return "text/plain; charset=utf-8"
+	}
+	return ""
+}
+
 func (f *filepickerCmp) View() string {
 	t := theme.CurrentTheme()
 	const maxVisibleDirs = 20
@@ -392,7 +420,7 @@ func (f *filepickerCmp) getCurrentFileBelowCursor() {
 
 	dir := f.dirs[f.cursor]
 	filename := dir.Name()
-	if !dir.IsDir() && isExtSupported(filename) {
+	if !dir.IsDir() && isImageExt(filename) {
 		fullPath := f.cwdDetails.directory + "/" + dir.Name()
 
 		go func() {
@@ -443,9 +471,7 @@ func readDir(path string, showHidden bool) []os.DirEntry {
 		for _, dirEntry := range dirEntries {
 			isHidden, _ := IsHidden(dirEntry.Name())
 			if !isHidden {
-				if dirEntry.IsDir() || isExtSupported(dirEntry.Name()) {
-					sanitizedDirEntries = append(sanitizedDirEntries, dirEntry)
-				}
+				sanitizedDirEntries = append(sanitizedDirEntries, dirEntry)
 			}
 		}
 
@@ -465,7 +491,7 @@ func IsHidden(file string) (bool, error) {
 	return strings.HasPrefix(file, "."), nil
 }
 
-func isExtSupported(path string) bool {
+func isImageExt(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
 	return (ext == ".jpg" || ext == ".jpeg" || ext == ".webp" || ext == ".png")
 }
@@ -0,0 +1,29 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentMIMEType(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    string
+	}{
+		{"screenshot.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png"},
+		{"spec.pdf", "%PDF-1.4\n", "application/pdf"},
+		{"notes.md", "# Notes\n", "text/plain; charset=utf-8"},
+		{"main.go", "package main\n\x1b", "text/plain; charset=utf-8"},
+		{"archive.zip", "PK\x03\x04\x00\x00", ""},
+		{"data.bin", "\x00\x01\x02", ""},
+		// Only files named as images are attached as images
+		{"logo.txt", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, attachmentMIMEType(tt.path, []byte(tt.content)))
+		})
+	}
+}
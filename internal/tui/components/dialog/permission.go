@@ -273,6 +273,10 @@ func (p *permissionDialogCmp) renderBashContent() string {
 
 	if pr, ok := p.permission.Params.(tools.BashPermissionsParams); ok {
 		content := fmt.Sprintf("```bash\n%s\n```", pr.Command)
+		// Show what a compound command line actually runs.
+		if len(pr.Commands) > 1 {
+			content += fmt.Sprintf("\n\nRuns %d commands:\n\n```bash\n%s\n```", len(pr.Commands), strings.Join(pr.Commands, "\n"))
+		}
 
 		// Use the cache for markdown rendering
 		renderedContent := p.GetOrSetMarkdown(p.permission.ID, func() (string, error) {
@@ -314,6 +318,34 @@ func (p *permissionDialogCmp) renderPatchContent() string {
 	return ""
 }
 
+func (p *permissionDialogCmp) renderWorkspaceEditContent() string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+
+	if pr, ok := p.permission.Params.(tools.WorkspaceEditPermissionsParams); ok {
+		width := p.contentViewPort.Width
+		content := p.GetOrSetDiff(p.permission.ID, func() (string, error) {
+			parts := []string{
+				baseStyle.Foreground(t.Text()).Width(width).Render(pr.Summary),
+				baseStyle.Width(width).Render(""),
+			}
+			for _, file := range pr.Files {
+				parts = append(parts, baseStyle.Foreground(t.Primary()).Bold(true).Width(width).Render(file.Path))
+				formatted, err := diff.FormatDiff(file.Diff, diff.WithTotalWidth(width))
+				if err != nil {
+					return "", err
+				}
+				parts = append(parts, strings.TrimSuffix(formatted, "\n"), baseStyle.Width(width).Render(""))
+			}
+			return lipgloss.JoinVertical(lipgloss.Left, parts...), nil
+		})
+
+		p.contentViewPort.SetContent(content)
+		return p.styleViewport()
+	}
+	return ""
+}
+
 func (p *permissionDialogCmp) renderWriteContent() string {
 	if pr, ok := p.permission.Params.(tools.WritePermissionsParams); ok {
 		// Use the cache for diff rendering
@@ -412,6 +444,8 @@ func (p *permissionDialogCmp) render() string {
 		contentFinal = p.renderPatchContent()
 	case tools.WriteToolName:
 		contentFinal = p.renderWriteContent()
+	case tools.RenameSymbolToolName, tools.CodeActionToolName:
+		contentFinal = p.renderWorkspaceEditContent()
 	case tools.FetchToolName:
 		contentFinal = p.renderFetchContent()
 	default:
@@ -462,7 +496,7 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.WriteToolName:
 		p.width = int(float64(p.windowSize.Width) * 0.8)
 		p.height = int(float64(p.windowSize.Height) * 0.8)
-	case tools.FetchToolName:
+	case tools.FetchToolName, tools.WebSearchToolName:
 		p.width = int(float64(p.windowSize.Width) * 0.4)
 		p.height = int(float64(p.windowSize.Height) * 0.3)
 	default:
@@ -3,7 +3,9 @@ package tui
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,6 +14,7 @@ import (
 	"github.com/opencode-ai/opencode/internal/config"
 	"github.com/opencode-ai/opencode/internal/llm/agent"
 	"github.com/opencode-ai/opencode/internal/logging"
+	"github.com/opencode-ai/opencode/internal/mcpclient"
 	"github.com/opencode-ai/opencode/internal/permission"
 	"github.com/opencode-ai/opencode/internal/pubsub"
 	"github.com/opencode-ai/opencode/internal/session"
@@ -37,8 +40,20 @@ type keyMap struct {
 
 type startCompactSessionMsg struct{}
 
+type showCheckpointDialogMsg struct{}
+
+type startCommitMsg struct{}
+
+type commitDraftedMsg struct {
+	draft app.CommitDraft
+	err   error
+}
+
 const (
 	quitKey = "q"
+	// mcpPromptCommandPrefix starts the ids of the commands of MCP prompts
+	mcpPromptCommandPrefix = "mcp:"
+	mcpPromptTimeout       = 30 * time.Second
 )
 
 var keys = keyMap{
@@ -136,6 +151,12 @@ type appModel struct {
 	showMultiArgumentsDialog bool
 	multiArgumentsDialog     dialog.MultiArgumentsDialogCmp
 
+	showCheckpointDialog bool
+	checkpointDialog     dialog.CheckpointDialog
+
+	showCommitDialog bool
+	commitDialog     dialog.CommitDialog
+
 	isCompacting      bool
 	compactingMessage string
 }
@@ -208,6 +229,14 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.commandDialog = command.(dialog.CommandDialog)
 		cmds = append(cmds, commandCmd)
 
+		checkpoints, checkpointCmd := a.checkpointDialog.Update(msg)
+		a.checkpointDialog = checkpoints.(dialog.CheckpointDialog)
+		cmds = append(cmds, checkpointCmd)
+
+		commit, commitCmd := a.commitDialog.Update(msg)
+		a.commitDialog = commit.(dialog.CommitDialog)
+		cmds = append(cmds, commitCmd)
+
 		filepicker, filepickerCmd := a.filepicker.Update(msg)
 		a.filepicker = filepicker.(dialog.FilepickerCmp)
 		cmds = append(cmds, filepickerCmd)
@@ -303,6 +332,70 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.showCommandDialog = false
 		return a, nil
 
+	case showCheckpointDialogMsg:
+		if a.selectedSession.ID == "" {
+			return a, util.ReportWarn("No active session")
+		}
+		if a.app.CoderAgent.IsSessionBusy(a.selectedSession.ID) {
+			return a, util.ReportWarn("Agent is busy, please wait...")
+		}
+		checkpoints, err := a.app.Checkpoints.List(context.Background(), a.selectedSession.ID)
+		if err != nil {
+			return a, util.ReportError(err)
+		}
+		if len(checkpoints) == 0 {
+			if !config.Get().Checkpoints.Enabled {
+				return a, util.ReportWarn("Checkpoints are disabled, enable them with checkpoints.enabled in the config")
+			}
+			return a, util.ReportWarn("No checkpoints for this session yet")
+		}
+		a.checkpointDialog.SetCheckpoints(a.selectedSession.ID, checkpoints)
+		a.showCheckpointDialog = true
+		return a, nil
+
+	case dialog.CloseCheckpointDialogMsg:
+		a.showCheckpointDialog = false
+		return a, nil
+
+	case dialog.CheckpointRestoredMsg:
+		a.showCheckpointDialog = false
+		return a, util.ReportInfo(fmt.Sprintf("Restored checkpoint \"%s\", restore \"%s\" to undo", msg.Checkpoint.Prompt, msg.Undo.Prompt))
+
+	case startCommitMsg:
+		if a.selectedSession.ID != "" && a.app.CoderAgent.IsSessionBusy(a.selectedSession.ID) {
+			return a, util.ReportWarn("Agent is busy, please wait...")
+		}
+		sessionID := a.selectedSession.ID
+		return a, tea.Batch(
+			util.ReportInfo("Drafting commit message..."),
+			func() tea.Msg {
+				draft, err := a.app.DraftCommit(context.Background(), sessionID)
+				return commitDraftedMsg{draft: draft, err: err}
+			},
+		)
+
+	case commitDraftedMsg:
+		if msg.err != nil {
+			return a, util.ReportError(msg.err)
+		}
+		a.commitDialog.SetCommit(msg.draft.Message, msg.draft.Files)
+		a.showCommitDialog = true
+		return a, nil
+
+	case dialog.CloseCommitDialogMsg:
+		a.showCommitDialog = false
+		return a, nil
+
+	case dialog.CommitConfirmedMsg:
+		a.showCommitDialog = false
+		return a, func() tea.Msg {
+			hash, err := a.app.Commit(context.Background(), msg.Message, msg.Files)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return util.InfoMsg{Type: util.InfoTypeInfo, Msg: fmt.Sprintf("Committed %s", hash)}
+		}
+
 	case startCompactSessionMsg:
 		// Start compacting the current session
 		a.isCompacting = true
@@ -424,6 +517,14 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Close multi-arguments dialog
 		a.showMultiArgumentsDialog = false
 
+		if msg.Submit && strings.HasPrefix(msg.CommandID, mcpPromptCommandPrefix) {
+			prompt, ok := a.findMCPPrompt(msg.CommandID)
+			if !ok {
+				return a, util.ReportError(fmt.Errorf("MCP prompt not found: %s", msg.CommandID))
+			}
+			return a, a.runMCPPrompt(prompt, msg.Args)
+		}
+
 		// If submitted, replace all named arguments and run the command
 		if msg.Submit {
 			content := msg.Content
@@ -473,9 +574,15 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if a.showMultiArgumentsDialog {
 				a.showMultiArgumentsDialog = false
 			}
+			if a.showCheckpointDialog {
+				a.showCheckpointDialog = false
+			}
+			if a.showCommitDialog {
+				a.showCommitDialog = false
+			}
 			return a, nil
 		case key.Matches(msg, keys.SwitchSession):
-			if a.currentPage == page.ChatPage && !a.showQuit && !a.showPermissions && !a.showCommandDialog {
+			if a.currentPage == page.ChatPage && !a.showQuit && !a.showPermissions && !a.showCommandDialog && !a.showCommitDialog {
 				// Load sessions and show the dialog
 				sessions, err := a.app.Sessions.List(context.Background())
 				if err != nil {
@@ -495,7 +602,7 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if len(a.commands) == 0 {
 					return a, util.ReportWarn("No commands available")
 				}
-				a.commandDialog.SetCommands(a.commands)
+				a.commandDialog.SetCommands(append(slices.Clone(a.commands), a.mcpPromptCommands()...))
 				a.showCommandDialog = true
 				return a, nil
 			}
@@ -656,6 +763,26 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if a.showCheckpointDialog {
+		d, checkpointCmd := a.checkpointDialog.Update(msg)
+		a.checkpointDialog = d.(dialog.CheckpointDialog)
+		cmds = append(cmds, checkpointCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
+	if a.showCommitDialog {
+		d, commitCmd := a.commitDialog.Update(msg)
+		a.commitDialog = d.(dialog.CommitDialog)
+		cmds = append(cmds, commitCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
 	s, _ := a.status.Update(msg)
 	a.status = s.(core.StatusCmp)
 	a.pages[a.currentPage], cmd = a.pages[a.currentPage].Update(msg)
@@ -668,6 +795,76 @@ func (a *appModel) RegisterCommand(cmd dialog.Command) {
 	a.commands = append(a.commands, cmd)
 }
 
+// mcpPromptCommands returns a command for each prompt of the connected MCP
+// servers, asking for the arguments of the prompt before running it
+func (a *appModel) mcpPromptCommands() []dialog.Command {
+	var commands []dialog.Command
+	for _, prompt := range a.app.MCPClients.Prompts() {
+		commands = append(commands, dialog.Command{
+			ID:          mcpPromptCommandID(prompt),
+			Title:       fmt.Sprintf("%s: %s", prompt.Server, prompt.Name),
+			Description: prompt.Description,
+			Handler: func(cmd dialog.Command) tea.Cmd {
+				if len(prompt.Arguments) == 0 {
+					return a.runMCPPrompt(prompt, nil)
+				}
+				argNames := make([]string, 0, len(prompt.Arguments))
+				for _, arg := range prompt.Arguments {
+					argNames = append(argNames, arg.Name)
+				}
+				return util.CmdHandler(dialog.ShowMultiArgumentsDialogMsg{
+					CommandID: cmd.ID,
+					ArgNames:  argNames,
+				})
+			},
+		})
+	}
+	return commands
+}
+
+func mcpPromptCommandID(prompt mcpclient.Prompt) string {
+	return mcpPromptCommandPrefix + prompt.Server + ":" + prompt.Name
+}
+
+func (a *appModel) findMCPPrompt(id string) (mcpclient.Prompt, bool) {
+	for _, prompt := range a.app.MCPClients.Prompts() {
+		if mcpPromptCommandID(prompt) == id {
+			return prompt, true
+		}
+	}
+	return mcpclient.Prompt{}, false
+}
+
+// runMCPPrompt gets a prompt from its server and sends it as a message.
+// Empty arguments are left out, for the server to use their defaults.
+func (a *appModel) runMCPPrompt(prompt mcpclient.Prompt, args map[string]string) tea.Cmd {
+	var arguments map[string]string
+	for name, value := range args {
+		if value == "" {
+			continue
+		}
+		if arguments == nil {
+			arguments = make(map[string]string)
+		}
+		arguments[name] = value
+	}
+
+	mcpClients := a.app.MCPClients
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), mcpPromptTimeout)
+		defer cancel()
+		result, err := mcpClients.GetPrompt(ctx, prompt.Server, prompt.Name, arguments)
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: fmt.Sprintf("failed to get prompt %s: %s", prompt.Name, err)}
+		}
+		content := mcpclient.PromptText(result)
+		if content == "" {
+			return util.InfoMsg{Type: util.InfoTypeWarn, Msg: fmt.Sprintf("prompt %s is empty", prompt.Name)}
+		}
+		return dialog.CommandRunCustomMsg{Content: content}
+	}
+}
+
 func (a *appModel) findCommand(id string) (dialog.Command, bool) {
 	for _, cmd := range a.commands {
 		if cmd.ID == id {
@@ -880,6 +1077,36 @@ func (a appModel) View() string {
 		)
 	}
 
+	if a.showCheckpointDialog {
+		overlay := a.checkpointDialog.View()
+		row := lipgloss.Height(appView) / 2
+		row -= lipgloss.Height(overlay) / 2
+		col := lipgloss.Width(appView) / 2
+		col -= lipgloss.Width(overlay) / 2
+		appView = layout.PlaceOverlay(
+			col,
+			row,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
+	if a.showCommitDialog {
+		overlay := a.commitDialog.View()
+		row := lipgloss.Height(appView) / 2
+		row -= lipgloss.Height(overlay) / 2
+		col := lipgloss.Width(appView) / 2
+		col -= lipgloss.Width(overlay) / 2
+		appView = layout.PlaceOverlay(
+			col,
+			row,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
 	if a.showMultiArgumentsDialog {
 		overlay := a.multiArgumentsDialog.View()
 		row := lipgloss.Height(appView) / 2
@@ -918,7 +1145,9 @@ func New(app *app.App) tea.Model {
 			page.ChatPage: page.NewChatPage(app),
 			page.LogsPage: page.NewLogsPage(),
 		},
-		filepicker: dialog.NewFilepickerCmp(app),
+		filepicker:       dialog.NewFilepickerCmp(app),
+		checkpointDialog: dialog.NewCheckpointDialogCmp(app.Checkpoints),
+		commitDialog:     dialog.NewCommitDialogCmp(),
 	}
 
 	model.RegisterCommand(dialog.Command{
@@ -951,6 +1180,24 @@ If there are Cursor rules (in .cursor/rules/ or .cursorrules) or Copilot rules (
 			}
 		},
 	})
+
+	model.RegisterCommand(dialog.Command{
+		ID:          "checkpoints",
+		Title:       "Checkpoints",
+		Description: "Show the working tree checkpoints of the current session to diff or restore them",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(showCheckpointDialogMsg{})
+		},
+	})
+
+	model.RegisterCommand(dialog.Command{
+		ID:          "commit",
+		Title:       "Commit Changes",
+		Description: "Commit the files changed in this session with a drafted commit message",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(startCommitMsg{})
+		},
+	})
 	// Load custom commands
 	customCommands, err := dialog.LoadCustomCommands()
 	if err != nil {